@@ -8,6 +8,7 @@ import (
         "google.golang.org/grpc"
         "google.golang.org/grpc/reflection"
 
+        "ancient-script-decoder/models"
         pb "ancient-script-decoder/proto"
         "ancient-script-decoder/services"
         "ancient-script-decoder/utils"
@@ -59,23 +60,206 @@ func (s *GRPCServer) Stop() {
 
 // TranslateManuscript handles the translation request via gRPC
 func (s *GRPCServer) TranslateManuscript(ctx context.Context, req *pb.TranslateRequest) (*pb.TranslateResponse, error) {
-        s.logger.Info("Received gRPC translation request", "scriptType", req.ScriptType)
+        s.logger.Info("Received gRPC translation request", "scriptType", req.ScriptType, "targetLanguage", req.TargetLanguage, "project", req.Project, "n", req.N, "pipeline", req.Pipeline)
 
         // Process, translate the manuscript, and extract metadata
-        translatedText, metadata, err := s.serviceHandler.ProcessTranslateWithMetadata(req.ManuscriptImage, req.ScriptType)
+        options := services.TranslationOptions{
+                TargetLanguage:       req.TargetLanguage,
+                Project:              req.Project,
+                Candidates:           int(req.N),
+                AnalyzeAllCandidates: req.AnalyzeCandidates,
+        }
+        if req.Direction != "" {
+                direction, err := utils.ParseReadingDirection(req.Direction)
+                if err != nil {
+                        return nil, err
+                }
+                options.ReadingDirection = direction
+        }
+        if req.Pipeline != "" {
+                pipeline, steps, err := parsePipeline(req.Pipeline)
+                if err != nil {
+                        return nil, err
+                }
+                options.Pipeline, options.PipelineSteps = pipeline, steps
+        }
+        result, err := s.serviceHandler.ProcessTranslateWithMetadata(req.ManuscriptImage, req.ScriptType, options)
         if err != nil {
                 s.logger.Error("Failed to process and translate manuscript", "error", err)
                 return nil, fmt.Errorf("failed to process and translate manuscript: %v", err)
         }
 
         // Generate summary for the translated text
-        summary, err := s.serviceHandler.SummarizeText(translatedText)
+        summary, err := s.serviceHandler.SummarizeText(result.TranslatedText)
         if err != nil {
                 s.logger.Error("Failed to generate summary", "error", err)
                 return nil, fmt.Errorf("failed to generate summary: %v", err)
         }
 
         // Convert Go metadata to protobuf metadata
+        metadataProto := convertMetadata(result.Metadata)
+
+        // Convert the word alignment
+        var alignmentProto []*pb.AlignmentPair
+        for _, pair := range result.Alignment {
+                alignmentProto = append(alignmentProto, &pb.AlignmentPair{
+                        Source: &pb.TextSpan{
+                                Start: int32(pair.Source.Start),
+                                End:   int32(pair.Source.End),
+                                Text:  pair.Source.Text,
+                        },
+                        Target: &pb.TextSpan{
+                                Start: int32(pair.Target.Start),
+                                End:   int32(pair.Target.End),
+                                Text:  pair.Target.Text,
+                        },
+                        Confidence: pair.Confidence,
+                        Status:     pair.Status,
+                })
+        }
+
+        // Convert the words of a Leiden edition
+        var sourceTokensProto []*pb.LeidenToken
+        for _, token := range result.SourceTokens {
+                tokenProto := &pb.LeidenToken{
+                        Text:    token.Text,
+                        Status:  token.Status,
+                        Missing: int32(token.Missing),
+                        Start:   int32(token.Start),
+                        End:     int32(token.End),
+                        Queried: token.Queried,
+                }
+                for _, segment := range token.Segments {
+                        tokenProto.Segments = append(tokenProto.Segments, &pb.LeidenSegment{
+                                Text:   segment.Text,
+                                Status: segment.Status,
+                        })
+                }
+                sourceTokensProto = append(sourceTokensProto, tokenProto)
+        }
+
+        // Convert the scripts detected from the look of the writing
+        var imageScriptsProto []*pb.ScriptCandidate
+        for _, candidate := range result.ImageScripts {
+                imageScriptsProto = append(imageScriptsProto, &pb.ScriptCandidate{
+                        Script:     candidate.Script,
+                        Confidence: candidate.Confidence,
+                })
+        }
+
+        // Convert the glossary violations
+        var violationsProto []*pb.GlossaryViolation
+        for _, violation := range result.GlossaryViolations {
+                violationsProto = append(violationsProto, &pb.GlossaryViolation{
+                        GlossaryId: violation.GlossaryID,
+                        Source:     violation.Source,
+                        Expected:   violation.Expected,
+                        Found:      violation.Found,
+                        Fixed:      violation.Fixed,
+                })
+        }
+
+        // Convert the translation candidates
+        var candidatesProto []*pb.TranslationCandidate
+        for _, candidate := range result.Candidates {
+                candidateProto := &pb.TranslationCandidate{
+                        Text:    candidate.Text,
+                        Score:   candidate.Score,
+                        Backend: candidate.Backend,
+                        Summary: candidate.Summary,
+                }
+                if candidate.Metadata != nil {
+                        candidateProto.Metadata = convertMetadata(*candidate.Metadata)
+                }
+                candidatesProto = append(candidatesProto, candidateProto)
+        }
+
+        // Create response
+        return &pb.TranslateResponse{
+                OriginalScript:     result.OriginalScript,
+                TranslatedText:     result.TranslatedText,
+                Summary:            summary,
+                Metadata:           metadataProto,
+                TargetLanguage:     result.TargetLanguage,
+                SourceText:         result.SourceText,
+                SourceTokens:       sourceTokensProto,
+                Alignment:          alignmentProto,
+                GlossaryViolations: violationsProto,
+                Candidates:         candidatesProto,
+                Layout:             convertLayout(result.Layout),
+                ImageScripts:       imageScriptsProto,
+                OcrConfidence:      result.OCRConfidence,
+        }, nil
+}
+
+// SummarizeText handles the summarization request via gRPC
+func (s *GRPCServer) SummarizeText(ctx context.Context, req *pb.SummarizeRequest) (*pb.SummarizeResponse, error) {
+        s.logger.Info("Received gRPC summarization request", "textLength", len(req.Text))
+
+        // Validate request
+        if req.Text == "" {
+                return nil, fmt.Errorf("text cannot be empty")
+        }
+
+        // Generate summary
+        summary, err := s.serviceHandler.SummarizeText(req.Text)
+        if err != nil {
+                s.logger.Error("Failed to generate summary", "error", err)
+                return nil, fmt.Errorf("failed to generate summary: %v", err)
+        }
+
+        // Create response
+        return &pb.SummarizeResponse{
+                Summary:    summary,
+                TextLength: int32(len(req.Text)),
+        }, nil
+}
+
+// convertLayout converts the layout of a manuscript image to protobuf, nil when the text was not read from an image
+func convertLayout(layout *models.PageLayout) *pb.PageLayout {
+        if layout == nil {
+                return nil
+        }
+        layoutProto := &pb.PageLayout{
+                Width:       int32(layout.Width),
+                Height:      int32(layout.Height),
+                Direction:   layout.Direction,
+                Rotation:    layout.Rotation,
+                Orientation: int32(layout.Orientation),
+                Skew:        layout.Skew,
+        }
+        for _, line := range layout.Lines {
+                lineProto := &pb.LineLayout{
+                        Box:       convertBoundingBox(line.Box),
+                        Direction: line.Direction,
+                }
+                for _, glyph := range line.Glyphs {
+                        lineProto.Glyphs = append(lineProto.Glyphs, &pb.GlyphLayout{
+                                Box:        convertBoundingBox(glyph.Box),
+                                Text:       glyph.Text,
+                                Confidence: glyph.Confidence,
+                                Word:       int32(glyph.Word),
+                                Uncertain:  glyph.Uncertain,
+                                ReviewId:   glyph.ReviewID,
+                        })
+                }
+                layoutProto.Lines = append(layoutProto.Lines, lineProto)
+        }
+        return layoutProto
+}
+
+// convertBoundingBox converts a box of a manuscript image to protobuf
+func convertBoundingBox(box models.BoundingBox) *pb.BoundingBox {
+        return &pb.BoundingBox{
+                X:      int32(box.X),
+                Y:      int32(box.Y),
+                Width:  int32(box.Width),
+                Height: int32(box.Height),
+        }
+}
+
+// convertMetadata converts Go metadata to protobuf metadata
+func convertMetadata(metadata models.Metadata) *pb.MetadataResponse {
         metadataProto := &pb.MetadataResponse{
                 ScriptType:      metadata.ScriptType,
                 ConfidenceScore: metadata.ConfidenceScore,
@@ -115,34 +299,5 @@ func (s *GRPCServer) TranslateManuscript(ctx context.Context, req *pb.TranslateR
                 })
         }
 
-        // Create response
-        return &pb.TranslateResponse{
-                OriginalScript: req.ScriptType,
-                TranslatedText: translatedText,
-                Summary:        summary,
-                Metadata:       metadataProto,
-        }, nil
-}
-
-// SummarizeText handles the summarization request via gRPC
-func (s *GRPCServer) SummarizeText(ctx context.Context, req *pb.SummarizeRequest) (*pb.SummarizeResponse, error) {
-        s.logger.Info("Received gRPC summarization request", "textLength", len(req.Text))
-
-        // Validate request
-        if req.Text == "" {
-                return nil, fmt.Errorf("text cannot be empty")
-        }
-
-        // Generate summary
-        summary, err := s.serviceHandler.SummarizeText(req.Text)
-        if err != nil {
-                s.logger.Error("Failed to generate summary", "error", err)
-                return nil, fmt.Errorf("failed to generate summary: %v", err)
-        }
-
-        // Create response
-        return &pb.SummarizeResponse{
-                Summary:    summary,
-                TextLength: int32(len(req.Text)),
-        }, nil
+        return metadataProto
 }
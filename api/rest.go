@@ -3,9 +3,13 @@ package api
 import (
         "context"
         "encoding/json"
+        "errors"
         "fmt"
         "io"
+        "mime"
         "net/http"
+        "strconv"
+        "strings"
         "time"
 
         "ancient-script-decoder/models"
@@ -37,7 +41,19 @@ func (s *RESTServer) Start() error {
         // Register API endpoints
         mux.HandleFunc("/api/translate", s.handleTranslate)
         mux.HandleFunc("/api/translate/text", s.handleTranslateText)
+        mux.HandleFunc("/api/ocr", s.handleOCR)
+        mux.HandleFunc("/api/image/process", s.handleImageProcess)
         mux.HandleFunc("/api/summarize", s.handleSummarize)
+        mux.HandleFunc("/api/transliterate", s.handleTransliterate)
+        mux.HandleFunc("/api/memory", s.handleMemory)
+        mux.HandleFunc("/api/memory/tmx", s.handleMemoryTMX)
+        mux.HandleFunc("/api/glossaries", s.handleGlossaries)
+        mux.HandleFunc("/api/glossaries/", s.handleGlossary)
+        mux.HandleFunc("/api/signs", s.handleSigns)
+        mux.HandleFunc("/api/signs/", s.handleSign)
+        mux.HandleFunc("/api/signs/index", s.handleSignIndex)
+        mux.HandleFunc("/api/review", s.handleReviewQueue)
+        mux.HandleFunc("/api/review/", s.handleReviewItem)
         mux.HandleFunc("/api/health", s.handleHealth)
         
         // Serve static files
@@ -102,16 +118,55 @@ func (s *RESTServer) handleTranslate(w http.ResponseWriter, r *http.Request) {
                 scriptType = "auto" // Default to auto-detection
         }
 
+        // Get target language and glossary project from form, empty selects the configured default and no glossary
+        options := services.TranslationOptions{
+                TargetLanguage:       r.FormValue("targetLanguage"),
+                Project:              r.FormValue("project"),
+                AnalyzeAllCandidates: r.FormValue("analyzeCandidates") == "true",
+        }
+
+        // Get the number of alternative translations, empty returns the translation only
+        if n := r.FormValue("n"); n != "" {
+                options.Candidates, err = strconv.Atoi(n)
+                if err != nil || options.Candidates < 1 {
+                        http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+                        return
+                }
+        }
+
+        // Get the reading direction of the lines, empty selects the configured default
+        if direction := r.FormValue("direction"); direction != "" {
+                options.ReadingDirection, err = utils.ParseReadingDirection(direction)
+                if err != nil {
+                        http.Error(w, err.Error(), http.StatusBadRequest)
+                        return
+                }
+        }
+
+        // Get the pipeline the image is preprocessed with before OCR, empty selects the default
+        options.Pipeline, options.PipelineSteps, err = requestPipeline(r)
+        if err != nil {
+                http.Error(w, err.Error(), http.StatusBadRequest)
+                return
+        }
+
+        // Get the response format
+        output := r.FormValue("output")
+        if !validOutput(output) {
+                http.Error(w, "output must be json, epidoc, hocr or alto", http.StatusBadRequest)
+                return
+        }
+
         // Process, translate the manuscript, and extract metadata
-        processedText, metadata, err := s.serviceHandler.ProcessTranslateWithMetadata(fileBytes, scriptType)
+        result, err := s.serviceHandler.ProcessTranslateWithMetadata(fileBytes, scriptType, options)
         if err != nil {
                 s.logger.Error("Failed to process and translate manuscript", "error", err)
-                http.Error(w, fmt.Sprintf("Failed to process and translate manuscript: %v", err), http.StatusInternalServerError)
+                http.Error(w, fmt.Sprintf("Failed to process and translate manuscript: %v", err), translationErrorStatus(err))
                 return
         }
 
         // Generate summary for the translated text
-        summary, err := s.serviceHandler.SummarizeText(processedText)
+        summary, err := s.serviceHandler.SummarizeText(result.TranslatedText)
         if err != nil {
                 s.logger.Error("Failed to generate summary", "error", err)
                 http.Error(w, fmt.Sprintf("Failed to generate summary: %v", err), http.StatusInternalServerError)
@@ -120,11 +175,31 @@ func (s *RESTServer) handleTranslate(w http.ResponseWriter, r *http.Request) {
 
         // Create response
         response := models.TranslationResponse{
-                OriginalScript: scriptType,
-                TranslatedText: processedText,
-                Summary:        summary,
-                Metadata:       metadata,
-                ProcessedAt:    time.Now().Format(time.RFC3339),
+                OriginalScript:     result.OriginalScript,
+                ScriptCandidates:   result.ScriptCandidates,
+                TargetLanguage:     result.TargetLanguage,
+                SourceText:         result.SourceText,
+                SourceTokens:       result.SourceTokens,
+                TranslatedText:     result.TranslatedText,
+                Alignment:          result.Alignment,
+                MemoryMatches:      result.MemoryMatches,
+                GlossaryViolations: result.GlossaryViolations,
+                Candidates:         result.Candidates,
+                Layout:             result.Layout,
+                ImageScripts:       result.ImageScripts,
+                OCRConfidence:      result.OCRConfidence,
+                Summary:            summary,
+                Metadata:           result.Metadata,
+                ProcessedAt:        time.Now().Format(time.RFC3339),
+        }
+
+        if output == "epidoc" {
+                s.writeEpiDoc(w, response)
+                return
+        }
+        if isOCROutput(output) {
+                s.writeOCR(w, output, services.OCRDocument{Layout: response.Layout, Script: response.OriginalScript, Image: handler.Filename})
+                return
         }
 
         // Send JSON response
@@ -136,6 +211,154 @@ func (s *RESTServer) handleTranslate(w http.ResponseWriter, r *http.Request) {
         }
 }
 
+// handleOCR handles the request to read the text of a manuscript image without translating it
+func (s *RESTServer) handleOCR(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+                return
+        }
+
+        // Parse multipart form data with 10MB limit
+        if err := r.ParseMultipartForm(10 << 20); err != nil {
+                s.logger.Error("Failed to parse form", "error", err)
+                http.Error(w, "Failed to parse form", http.StatusBadRequest)
+                return
+        }
+
+        file, handler, err := r.FormFile("manuscript")
+        if err != nil {
+                s.logger.Error("Failed to get file from form", "error", err)
+                http.Error(w, "Failed to get file from form", http.StatusBadRequest)
+                return
+        }
+        defer file.Close()
+
+        fileBytes, err := io.ReadAll(file)
+        if err != nil {
+                s.logger.Error("Failed to read file", "error", err)
+                http.Error(w, "Failed to read file", http.StatusInternalServerError)
+                return
+        }
+
+        scriptType := r.FormValue("scriptType")
+        if scriptType == "" {
+                scriptType = "auto" // Default to auto-detection
+        }
+
+        // Get the reading direction of the lines, empty selects the configured default
+        var direction utils.ReadingDirection
+        if name := r.FormValue("direction"); name != "" {
+                direction, err = utils.ParseReadingDirection(name)
+                if err != nil {
+                        http.Error(w, err.Error(), http.StatusBadRequest)
+                        return
+                }
+        }
+
+        // Get the pipeline the image is preprocessed with, empty selects the default
+        pipeline, steps, err := requestPipeline(r)
+        if err != nil {
+                http.Error(w, err.Error(), http.StatusBadRequest)
+                return
+        }
+
+        // Get the response format, JSON unless hOCR or ALTO is requested
+        output := r.FormValue("output")
+        if output != "" && output != "json" && !isOCROutput(output) {
+                http.Error(w, "output must be json, hocr or alto", http.StatusBadRequest)
+                return
+        }
+
+        result, err := s.serviceHandler.RecognizeImage(fileBytes, scriptType, direction, pipeline, steps)
+        if err != nil {
+                s.logger.Error("Failed to recognize manuscript", "error", err)
+                http.Error(w, fmt.Sprintf("Failed to recognize manuscript: %v", err), imageErrorStatus(err))
+                return
+        }
+
+        if isOCROutput(output) {
+                s.writeOCR(w, output, services.OCRDocument{Layout: result.Layout, Script: result.Script, Image: handler.Filename})
+                return
+        }
+
+        s.writeJSON(w, http.StatusOK, models.OCRResponse{
+                Script:       result.Script,
+                ImageScripts: result.ImageScripts,
+                Text:         result.Text,
+                Confidence:   result.Confidence,
+                Layout:       result.Layout,
+                ProcessedAt:  time.Now().Format(time.RFC3339),
+        })
+}
+
+// handleImageProcess runs a manuscript image through an image processing pipeline and returns
+// the image as the OCR reads it with that pipeline, straightened and without edge detection, so
+// that preprocessing can be tuned before running OCR. The pipeline is the preset named or the
+// steps given as a JSON array in pipeline, the steps given in steps, or the default pipeline
+// when neither is given, as for translate and OCR requests. The image is sent as is unless output is base64, which
+// returns it base64 encoded in JSON together with, when stages is true, the image as it
+// leaves each step.
+func (s *RESTServer) handleImageProcess(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+                return
+        }
+
+        // Parse multipart form data with 10MB limit
+        if err := r.ParseMultipartForm(10 << 20); err != nil {
+                s.logger.Error("Failed to parse form", "error", err)
+                http.Error(w, "Failed to parse form", http.StatusBadRequest)
+                return
+        }
+
+        file, _, err := r.FormFile("manuscript")
+        if err != nil {
+                s.logger.Error("Failed to get file from form", "error", err)
+                http.Error(w, "Failed to get file from form", http.StatusBadRequest)
+                return
+        }
+        defer file.Close()
+
+        fileBytes, err := io.ReadAll(file)
+        if err != nil {
+                s.logger.Error("Failed to read file", "error", err)
+                http.Error(w, "Failed to read file", http.StatusInternalServerError)
+                return
+        }
+
+        // Get the pipeline, a preset or steps given with the request
+        pipeline, steps, err := requestPipeline(r)
+        if err != nil {
+                http.Error(w, err.Error(), http.StatusBadRequest)
+                return
+        }
+
+        // Get the response format, the image itself unless base64 is requested
+        output := r.FormValue("output")
+        if output != "" && output != "binary" && output != "base64" {
+                http.Error(w, "output must be binary or base64", http.StatusBadRequest)
+                return
+        }
+        stages := r.FormValue("stages") == "true"
+        if stages && output != "base64" {
+                http.Error(w, "stages are only returned with base64 output", http.StatusBadRequest)
+                return
+        }
+
+        result, err := s.serviceHandler.ProcessImage(fileBytes, pipeline, steps, stages)
+        if err != nil {
+                http.Error(w, fmt.Sprintf("Failed to process image: %v", err), imageErrorStatus(err))
+                return
+        }
+
+        if output == "base64" {
+                s.writeJSON(w, http.StatusOK, result)
+                return
+        }
+        w.Header().Set("Content-Type", result.ContentType)
+        w.Write(result.Data)
+}
+
 // handleSummarize handles the summarization request
 func (s *RESTServer) handleSummarize(w http.ResponseWriter, r *http.Request) {
         if r.Method != http.MethodPost {
@@ -181,6 +404,354 @@ func (s *RESTServer) handleSummarize(w http.ResponseWriter, r *http.Request) {
         }
 }
 
+// handleTransliterate handles the conversion between native script and romanization
+func (s *RESTServer) handleTransliterate(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+                return
+        }
+
+        // Parse JSON request
+        var request models.TransliterateRequest
+        if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+                s.logger.Error("Failed to parse request", "error", err)
+                http.Error(w, "Failed to parse request", http.StatusBadRequest)
+                return
+        }
+
+        // Validate request
+        if request.Text == "" {
+                http.Error(w, "Text cannot be empty", http.StatusBadRequest)
+                return
+        }
+
+        // Convert the text
+        response, err := s.serviceHandler.Transliterate(request)
+        if err != nil {
+                s.logger.Error("Failed to transliterate text", "error", err)
+                http.Error(w, fmt.Sprintf("Failed to transliterate text: %v", err), http.StatusBadRequest)
+                return
+        }
+
+        // Send JSON response
+        w.Header().Set("Content-Type", "application/json")
+        if err := json.NewEncoder(w).Encode(response); err != nil {
+                s.logger.Error("Failed to encode response", "error", err)
+                http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+                return
+        }
+}
+
+// handleMemory handles adding an approved translation to the translation memory
+func (s *RESTServer) handleMemory(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+                return
+        }
+
+        // Parse JSON request
+        var request models.MemoryEntryRequest
+        if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+                s.logger.Error("Failed to parse request", "error", err)
+                http.Error(w, "Failed to parse request", http.StatusBadRequest)
+                return
+        }
+
+        // Validate request
+        if request.SourceText == "" || request.TranslatedText == "" {
+                http.Error(w, "Source and translated text cannot be empty", http.StatusBadRequest)
+                return
+        }
+
+        // Store the entry
+        response, err := s.serviceHandler.AddMemoryEntry(request)
+        if err != nil {
+                http.Error(w, fmt.Sprintf("Failed to add translation memory entry: %v", err), http.StatusBadRequest)
+                return
+        }
+
+        // Send JSON response
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusCreated)
+        if err := json.NewEncoder(w).Encode(response); err != nil {
+                s.logger.Error("Failed to encode response", "error", err)
+                return
+        }
+}
+
+// handleMemoryTMX exports the translation memory as TMX on GET and imports a TMX document on POST
+func (s *RESTServer) handleMemoryTMX(w http.ResponseWriter, r *http.Request) {
+        switch r.Method {
+        case http.MethodGet:
+                w.Header().Set("Content-Type", "application/x-tmx+xml")
+                w.Header().Set("Content-Disposition", `attachment; filename="translations.tmx"`)
+                if err := s.serviceHandler.ExportMemory(w); err != nil {
+                        s.logger.Error("Failed to export translation memory", "error", err)
+                        http.Error(w, fmt.Sprintf("Failed to export translation memory: %v", err), http.StatusInternalServerError)
+                }
+        case http.MethodPost:
+                // Limit the TMX document to 10MB
+                response, err := s.serviceHandler.ImportMemory(http.MaxBytesReader(w, r.Body, 10<<20))
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to import translation memory: %v", err), http.StatusBadRequest)
+                        return
+                }
+
+                // Send JSON response
+                w.Header().Set("Content-Type", "application/json")
+                if err := json.NewEncoder(w).Encode(response); err != nil {
+                        s.logger.Error("Failed to encode response", "error", err)
+                        http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+                        return
+                }
+        default:
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        }
+}
+
+// handleGlossaries lists glossaries on GET, optionally filtered by ?project=, and creates one on POST
+func (s *RESTServer) handleGlossaries(w http.ResponseWriter, r *http.Request) {
+        switch r.Method {
+        case http.MethodGet:
+                s.writeJSON(w, http.StatusOK, s.serviceHandler.ListGlossaries(r.URL.Query().Get("project")))
+        case http.MethodPost:
+                var glossary models.Glossary
+                if err := json.NewDecoder(r.Body).Decode(&glossary); err != nil {
+                        s.logger.Error("Failed to parse request", "error", err)
+                        http.Error(w, "Failed to parse request", http.StatusBadRequest)
+                        return
+                }
+
+                created, err := s.serviceHandler.CreateGlossary(glossary)
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to create glossary: %v", err), glossaryErrorStatus(err))
+                        return
+                }
+                s.writeJSON(w, http.StatusCreated, created)
+        default:
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        }
+}
+
+// handleGlossary reads, replaces or deletes the glossary named by /api/glossaries/{id}
+func (s *RESTServer) handleGlossary(w http.ResponseWriter, r *http.Request) {
+        id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/glossaries/"), "/")
+        if id == "" {
+                s.handleGlossaries(w, r)
+                return
+        }
+
+        switch r.Method {
+        case http.MethodGet:
+                glossary, err := s.serviceHandler.GetGlossary(id)
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to get glossary: %v", err), glossaryErrorStatus(err))
+                        return
+                }
+                s.writeJSON(w, http.StatusOK, glossary)
+        case http.MethodPut:
+                var glossary models.Glossary
+                if err := json.NewDecoder(r.Body).Decode(&glossary); err != nil {
+                        s.logger.Error("Failed to parse request", "error", err)
+                        http.Error(w, "Failed to parse request", http.StatusBadRequest)
+                        return
+                }
+
+                updated, err := s.serviceHandler.UpdateGlossary(id, glossary)
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to update glossary: %v", err), glossaryErrorStatus(err))
+                        return
+                }
+                s.writeJSON(w, http.StatusOK, updated)
+        case http.MethodDelete:
+                if err := s.serviceHandler.DeleteGlossary(id); err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to delete glossary: %v", err), glossaryErrorStatus(err))
+                        return
+                }
+                w.WriteHeader(http.StatusNoContent)
+        default:
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        }
+}
+
+// handleSigns lists sign samples on GET, optionally filtered by ?script=, and adds one on POST.
+// A sample is uploaded as multipart form data: the glyph image in "sample", its "script", and
+// the "text" it is read as (a character or a code point such as U+1202D) and/or its sign "name".
+func (s *RESTServer) handleSigns(w http.ResponseWriter, r *http.Request) {
+        switch r.Method {
+        case http.MethodGet:
+                s.writeJSON(w, http.StatusOK, s.serviceHandler.ListSignSamples(r.URL.Query().Get("script")))
+        case http.MethodPost:
+                // Parse multipart form data with 10MB limit
+                if err := r.ParseMultipartForm(10 << 20); err != nil {
+                        s.logger.Error("Failed to parse form", "error", err)
+                        http.Error(w, "Failed to parse form", http.StatusBadRequest)
+                        return
+                }
+
+                file, _, err := r.FormFile("sample")
+                if err != nil {
+                        s.logger.Error("Failed to get file from form", "error", err)
+                        http.Error(w, "Failed to get file from form", http.StatusBadRequest)
+                        return
+                }
+                defer file.Close()
+
+                imageData, err := io.ReadAll(file)
+                if err != nil {
+                        s.logger.Error("Failed to read file", "error", err)
+                        http.Error(w, "Failed to read file", http.StatusInternalServerError)
+                        return
+                }
+
+                sample := models.SignSample{
+                        Script: r.FormValue("script"),
+                        Text:   r.FormValue("text"),
+                        Name:   r.FormValue("name"),
+                }
+                created, err := s.serviceHandler.AddSignSample(sample, imageData)
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to add sign sample: %v", err), signErrorStatus(err))
+                        return
+                }
+                s.writeJSON(w, http.StatusCreated, created)
+        default:
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        }
+}
+
+// handleSign reads or deletes the sign sample named by /api/signs/{id}
+func (s *RESTServer) handleSign(w http.ResponseWriter, r *http.Request) {
+        id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/signs/"), "/")
+        if id == "" {
+                s.handleSigns(w, r)
+                return
+        }
+
+        switch r.Method {
+        case http.MethodGet:
+                sample, err := s.serviceHandler.GetSignSample(id)
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to get sign sample: %v", err), signErrorStatus(err))
+                        return
+                }
+                s.writeJSON(w, http.StatusOK, sample)
+        case http.MethodDelete:
+                if err := s.serviceHandler.DeleteSignSample(id); err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to delete sign sample: %v", err), signErrorStatus(err))
+                        return
+                }
+                w.WriteHeader(http.StatusNoContent)
+        default:
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        }
+}
+
+// handleReviewQueue lists the glyphs queued for review on GET, the pending ones unless a status
+// is given; status=all lists the reviewed items too
+func (s *RESTServer) handleReviewQueue(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodGet {
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+                return
+        }
+
+        query := r.URL.Query()
+        status := query.Get("status")
+        switch status {
+        case "":
+                status = services.ReviewPending
+        case "all":
+                status = ""
+        case services.ReviewPending, services.ReviewAccepted, services.ReviewCorrected, services.ReviewDismissed:
+        default:
+                http.Error(w, "status must be pending, accepted, corrected, dismissed or all", http.StatusBadRequest)
+                return
+        }
+        s.writeJSON(w, http.StatusOK, s.serviceHandler.ListReviewItems(status, query.Get("script")))
+}
+
+// handleReviewItem gets a review queue item on GET, applies a reviewer's decision on POST and
+// dismisses the item on DELETE; /api/review/{id}/image serves the image of its glyph
+func (s *RESTServer) handleReviewItem(w http.ResponseWriter, r *http.Request) {
+        path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/review/"), "/")
+        if path == "" {
+                s.handleReviewQueue(w, r)
+                return
+        }
+
+        if id := strings.TrimSuffix(path, "/image"); id != path {
+                if r.Method != http.MethodGet {
+                        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+                        return
+                }
+                data, err := s.serviceHandler.ReviewItemImage(id)
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to get review image: %v", err), reviewErrorStatus(err))
+                        return
+                }
+                w.Header().Set("Content-Type", "image/png")
+                w.Write(data)
+                return
+        }
+
+        id := path
+        switch r.Method {
+        case http.MethodGet:
+                item, err := s.serviceHandler.GetReviewItem(id)
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to get review item: %v", err), reviewErrorStatus(err))
+                        return
+                }
+                s.writeJSON(w, http.StatusOK, item)
+        case http.MethodPost:
+                var decision models.ReviewDecision
+                if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+                        s.logger.Error("Failed to parse request", "error", err)
+                        http.Error(w, "Failed to parse request", http.StatusBadRequest)
+                        return
+                }
+                item, err := s.serviceHandler.ReviewGlyph(id, decision)
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to review glyph: %v", err), reviewErrorStatus(err))
+                        return
+                }
+                s.writeJSON(w, http.StatusOK, item)
+        case http.MethodDelete:
+                item, err := s.serviceHandler.DismissReviewItem(id)
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to dismiss review item: %v", err), reviewErrorStatus(err))
+                        return
+                }
+                s.writeJSON(w, http.StatusOK, item)
+        default:
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+        }
+}
+
+// handleSignIndex rebuilds the sign index from the sign inventory on POST
+func (s *RESTServer) handleSignIndex(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+                http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+                return
+        }
+
+        response, err := s.serviceHandler.RebuildSignIndex()
+        if err != nil {
+                http.Error(w, fmt.Sprintf("Failed to rebuild sign index: %v", err), http.StatusInternalServerError)
+                return
+        }
+        s.writeJSON(w, http.StatusOK, response)
+}
+
+// writeJSON sends a JSON response with the given status code
+func (s *RESTServer) writeJSON(w http.ResponseWriter, status int, response interface{}) {
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(status)
+        if err := json.NewEncoder(w).Encode(response); err != nil {
+                s.logger.Error("Failed to encode response", "error", err)
+        }
+}
+
 // handleHealth handles the health check request
 func (s *RESTServer) handleHealth(w http.ResponseWriter, r *http.Request) {
         if r.Method != http.MethodGet {
@@ -212,29 +783,89 @@ func (s *RESTServer) handleTranslateText(w http.ResponseWriter, r *http.Request)
 
         // Parse JSON request
         var request struct {
-                OriginalText string `json:"originalText"`
-                ScriptType   string `json:"scriptType"`
+                OriginalText      string `json:"originalText"`
+                ScriptType        string `json:"scriptType"`
+                TargetLanguage    string `json:"targetLanguage"`
+                Project           string `json:"project"`
+                N                 int    `json:"n"`
+                AnalyzeCandidates bool   `json:"analyzeCandidates"`
         }
         
-        if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+        // An EpiDoc document is translated from its edition, with the options given as query parameters
+        epidoc := isXMLContent(r.Header.Get("Content-Type"))
+        if epidoc {
+                // Limit the EpiDoc document to 10MB
+                edition, err := s.serviceHandler.ImportEpiDoc(http.MaxBytesReader(w, r.Body, 10<<20))
+                if err != nil {
+                        http.Error(w, fmt.Sprintf("Failed to import EpiDoc: %v", err), http.StatusBadRequest)
+                        return
+                }
+
+                query := r.URL.Query()
+                request.OriginalText = edition.Text
+                request.ScriptType = query.Get("scriptType")
+                if request.ScriptType == "" {
+                        request.ScriptType = edition.ScriptType
+                }
+                request.TargetLanguage = query.Get("targetLanguage")
+                request.Project = query.Get("project")
+                request.AnalyzeCandidates = query.Get("analyzeCandidates") == "true"
+                if n := query.Get("n"); n != "" {
+                        request.N, err = strconv.Atoi(n)
+                        if err != nil {
+                                http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+                                return
+                        }
+                }
+        } else if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
                 s.logger.Error("Failed to parse request", "error", err)
                 http.Error(w, "Failed to parse request", http.StatusBadRequest)
                 return
         }
 
+        // Get the response format; hOCR and ALTO describe the layout of an image, which a text does not have
+        output := r.URL.Query().Get("output")
+        if !validOutput(output) {
+                http.Error(w, "output must be json, epidoc, hocr or alto", http.StatusBadRequest)
+                return
+        }
+        if isOCROutput(output) {
+                http.Error(w, "hocr and alto output need a manuscript image", http.StatusBadRequest)
+                return
+        }
+
         // Validate request
         if request.OriginalText == "" {
                 http.Error(w, "Text cannot be empty", http.StatusBadRequest)
                 return
         }
 
+        if request.N < 0 {
+                http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+                return
+        }
+
         if request.ScriptType == "" {
                 request.ScriptType = "auto" // Default to auto-detection
         }
 
+        // The text is translated into the requested language, or the default target language when none is given
+        options := services.TranslationOptions{
+                TargetLanguage:       request.TargetLanguage,
+                Project:              request.Project,
+                Candidates:           request.N,
+                AnalyzeAllCandidates: request.AnalyzeCandidates,
+        }
+        result, err := s.serviceHandler.TranslateText(request.OriginalText, request.ScriptType, options)
+        if err != nil {
+                s.logger.Error("Failed to translate text", "error", err)
+                http.Error(w, fmt.Sprintf("Failed to translate text: %v", err), translationErrorStatus(err))
+                return
+        }
+
         // For direct text input, we skip the image processing step
         // and extract metadata directly from the text
-        metadata, err := s.serviceHandler.ExtractMetadata(request.OriginalText, request.ScriptType)
+        metadata, err := s.serviceHandler.ExtractMetadata(result.TranslatedText, result.OriginalScript)
         if err != nil {
                 s.logger.Error("Failed to extract metadata", "error", err)
                 http.Error(w, fmt.Sprintf("Failed to extract metadata: %v", err), http.StatusInternalServerError)
@@ -242,7 +873,7 @@ func (s *RESTServer) handleTranslateText(w http.ResponseWriter, r *http.Request)
         }
 
         // Generate summary for the text
-        summary, err := s.serviceHandler.SummarizeText(request.OriginalText)
+        summary, err := s.serviceHandler.SummarizeText(result.TranslatedText)
         if err != nil {
                 s.logger.Error("Failed to generate summary", "error", err)
                 http.Error(w, fmt.Sprintf("Failed to generate summary: %v", err), http.StatusInternalServerError)
@@ -251,11 +882,24 @@ func (s *RESTServer) handleTranslateText(w http.ResponseWriter, r *http.Request)
 
         // Create response
         response := models.TranslationResponse{
-                OriginalScript: request.ScriptType,
-                TranslatedText: request.OriginalText, // For text input, we don't translate
-                Summary:        summary,
-                Metadata:       metadata,
-                ProcessedAt:    time.Now().Format(time.RFC3339),
+                OriginalScript:     result.OriginalScript,
+                ScriptCandidates:   result.ScriptCandidates,
+                TargetLanguage:     result.TargetLanguage,
+                SourceText:         result.SourceText,
+                SourceTokens:       result.SourceTokens,
+                TranslatedText:     result.TranslatedText,
+                Alignment:          result.Alignment,
+                MemoryMatches:      result.MemoryMatches,
+                GlossaryViolations: result.GlossaryViolations,
+                Candidates:         result.Candidates,
+                Summary:            summary,
+                Metadata:           metadata,
+                ProcessedAt:        time.Now().Format(time.RFC3339),
+        }
+
+        if output == "epidoc" {
+                s.writeEpiDoc(w, response)
+                return
         }
 
         // Send JSON response
@@ -266,3 +910,138 @@ func (s *RESTServer) handleTranslateText(w http.ResponseWriter, r *http.Request)
                 return
         }
 }
+
+// validOutput reports whether output names a supported response format, empty selecting JSON
+func validOutput(output string) bool {
+        return output == "" || output == "json" || output == "epidoc" || isOCROutput(output)
+}
+
+// isOCROutput reports whether output names a format describing the layout of the text read from an image
+func isOCROutput(output string) bool {
+        return output == services.OCRFormatHOCR || output == services.OCRFormatALTO
+}
+
+// isXMLContent reports whether a Content-Type header announces an XML document such as EpiDoc
+func isXMLContent(contentType string) bool {
+        mediaType, _, err := mime.ParseMediaType(contentType)
+        if err != nil {
+                return false
+        }
+        return mediaType == "application/tei+xml" || mediaType == "application/xml" || mediaType == "text/xml"
+}
+
+// writeEpiDoc sends a translation response as an EpiDoc document
+func (s *RESTServer) writeEpiDoc(w http.ResponseWriter, response models.TranslationResponse) {
+        w.Header().Set("Content-Type", "application/tei+xml; charset=utf-8")
+        if err := s.serviceHandler.ExportEpiDoc(w, response); err != nil {
+                s.logger.Error("Failed to export EpiDoc", "error", err)
+                http.Error(w, fmt.Sprintf("Failed to export EpiDoc: %v", err), http.StatusInternalServerError)
+        }
+}
+
+// writeOCR sends the text read from an image as an hOCR or ALTO document
+func (s *RESTServer) writeOCR(w http.ResponseWriter, format string, doc services.OCRDocument) {
+        contentType := "application/xhtml+xml; charset=utf-8"
+        if format == services.OCRFormatALTO {
+                contentType = "application/xml; charset=utf-8"
+        }
+        w.Header().Set("Content-Type", contentType)
+        if err := s.serviceHandler.ExportOCR(w, format, doc); err != nil {
+                s.logger.Error("Failed to export OCR", "format", format, "error", err)
+                http.Error(w, fmt.Sprintf("Failed to export %s: %v", format, err), http.StatusInternalServerError)
+        }
+}
+
+// translationErrorStatus maps translation errors caused by the request, such as an unsupported
+// language pair or an invalid image pipeline, to 400 and everything else to 500
+func translationErrorStatus(err error) int {
+        var pairErr *services.UnsupportedLanguagePairError
+        if errors.As(err, &pairErr) {
+                return http.StatusBadRequest
+        }
+        return imageErrorStatus(err)
+}
+
+// glossaryErrorStatus maps a missing glossary to 404 and validation or storage errors to 400
+func glossaryErrorStatus(err error) int {
+        if errors.Is(err, services.ErrGlossaryNotFound) {
+                return http.StatusNotFound
+        }
+        return http.StatusBadRequest
+}
+
+// signErrorStatus maps a missing sign sample to 404 and validation or storage errors to 400
+func signErrorStatus(err error) int {
+        if errors.Is(err, services.ErrSignNotFound) {
+                return http.StatusNotFound
+        }
+        return http.StatusBadRequest
+}
+
+// reviewErrorStatus maps a missing review item to 404, an item reviewed before to 409 and
+// validation or storage errors to 400
+func reviewErrorStatus(err error) int {
+        switch {
+        case errors.Is(err, services.ErrReviewNotFound):
+                return http.StatusNotFound
+        case errors.Is(err, services.ErrAlreadyReviewed):
+                return http.StatusConflict
+        }
+        return http.StatusBadRequest
+}
+
+// requestPipeline reads the image pipeline of a form: the preset named or the steps given in
+// pipeline, or the steps given in steps. Neither selects the default pipeline.
+func requestPipeline(r *http.Request) (string, []utils.PipelineStep, error) {
+        pipeline, steps, err := parsePipeline(r.FormValue("pipeline"))
+        if err != nil {
+                return "", nil, err
+        }
+        if spec := r.FormValue("steps"); spec != "" {
+                if r.FormValue("pipeline") != "" {
+                        return "", nil, fmt.Errorf("pipeline and steps cannot both be given")
+                }
+                if steps, err = parsePipelineSteps("steps", spec); err != nil {
+                        return "", nil, err
+                }
+        }
+        return pipeline, steps, nil
+}
+
+// parsePipeline reads an image pipeline given with a request: the name of a preset, or the
+// steps as a JSON array
+func parsePipeline(spec string) (string, []utils.PipelineStep, error) {
+        if !strings.HasPrefix(strings.TrimSpace(spec), "[") {
+                return spec, nil, nil
+        }
+        steps, err := parsePipelineSteps("pipeline", spec)
+        return "", steps, err
+}
+
+// parsePipelineSteps decodes and validates the pipeline steps given as a JSON array in a field;
+// misspelt parameters are rejected rather than left at their defaults
+func parsePipelineSteps(field, spec string) ([]utils.PipelineStep, error) {
+        var steps []utils.PipelineStep
+        decoder := json.NewDecoder(strings.NewReader(spec))
+        decoder.DisallowUnknownFields()
+        if err := decoder.Decode(&steps); err != nil {
+                return nil, fmt.Errorf("%s must be a JSON array of pipeline steps: %v", field, err)
+        }
+        if steps == nil {
+                steps = []utils.PipelineStep{}
+        }
+        if err := utils.ValidatePipeline(steps); err != nil {
+                return nil, fmt.Errorf("invalid %s: %w", field, err)
+        }
+        return steps, nil
+}
+
+// imageErrorStatus maps an invalid pipeline step or an unknown pipeline preset to 400 and
+// failures to decode or process the image to 500
+func imageErrorStatus(err error) int {
+        var pipelineErr *utils.PipelineError
+        if errors.As(err, &pipelineErr) || errors.Is(err, services.ErrPipelineNotFound) {
+                return http.StatusBadRequest
+        }
+        return http.StatusInternalServerError
+}
@@ -47,7 +47,41 @@ func main() {
 
         // Initialize services
         imageProcessor := services.NewImageProcessor(config.ImageProcessing)
+        if err := imageProcessor.TemplateError(); err != nil {
+                logger.Warning("Failed to load glyph templates, text cannot be recognized in images", "error", err)
+        } else {
+                logger.Info("Loaded glyph templates", "sizes", imageProcessor.TemplateSizes())
+        }
+        if err := imageProcessor.SignInventoryError(); err != nil {
+                logger.Warning("Failed to load sign inventory, starting with an empty one", "error", err)
+        } else {
+                logger.Info("Loaded sign inventory", "samples", imageProcessor.SignInventory().Size(), "indexed", imageProcessor.SignIndexSizes())
+        }
+        if err := imageProcessor.ReviewQueueError(); err != nil {
+                logger.Warning("Failed to load review queue, starting with an empty one", "error", err)
+        } else {
+                logger.Info("Loaded review queue", "pending", imageProcessor.ReviewQueue().Pending())
+        }
+        if err := imageProcessor.PipelineError(); err != nil {
+                logger.Warning("Invalid image pipelines, leaving them out", "error", err)
+        }
+        logger.Info("Loaded image pipelines", "presets", len(imageProcessor.Pipelines()))
         translator := services.NewTranslator(config.Translation)
+        if err := translator.LexiconError(); err != nil {
+                logger.Warning("Failed to load translation lexicons, internal engine will flag all words as unknown", "error", err)
+        } else {
+                logger.Info("Loaded translation lexicons", "sizes", translator.LexiconSizes())
+        }
+        if err := translator.MemoryError(); err != nil {
+                logger.Warning("Failed to load translation memory, starting with an empty one", "error", err)
+        } else {
+                logger.Info("Loaded translation memory", "entries", translator.Memory().Size())
+        }
+        if err := translator.GlossaryError(); err != nil {
+                logger.Warning("Failed to load glossaries, starting with none", "error", err)
+        } else {
+                logger.Info("Loaded glossaries", "count", translator.Glossaries().Size())
+        }
         
         // Initialize the new improved summarizer
         summarizer := services.NewSummarizer(config.Summarization)
@@ -13,23 +13,144 @@ type Manuscript struct {
         ProcessedAt  time.Time `json:"processedAt,omitempty"`
 }
 
+// ScriptCandidate represents a possible script of a text with its detection confidence
+type ScriptCandidate struct {
+        Script     string  `json:"script"`
+        Confidence float64 `json:"confidence"`
+}
+
+// TextSpan is a range of a text, with offsets counted in Unicode code points
+type TextSpan struct {
+        Start int    `json:"start"`
+        End   int    `json:"end"`
+        Text  string `json:"text"`
+}
+
+// AlignmentPair links the source span that produced a target span of the translation
+// Status is the Leiden status of the least certain source word, empty when it is certain
+type AlignmentPair struct {
+        Source     TextSpan `json:"source"`
+        Target     TextSpan `json:"target"`
+        Confidence float64  `json:"confidence"`
+        Status     string   `json:"status,omitempty"`
+}
+
+// LeidenToken is a word or lacuna of a source text written in Leiden notation
+// Start and End locate the word in the source text the alignment refers to; a lacuna has Start equal to End
+// Segments hold the letters of the word as edited, before transliteration, grouped by status
+type LeidenToken struct {
+        Text     string          `json:"text,omitempty"`
+        Status   string          `json:"status"`
+        Missing  int             `json:"missing,omitempty"`
+        Queried  bool            `json:"queried,omitempty"`
+        Segments []LeidenSegment `json:"segments,omitempty"`
+        Start    int             `json:"start"`
+        End      int             `json:"end"`
+}
+
+// LeidenSegment is a run of letters of a word sharing one status
+type LeidenSegment struct {
+        Text   string `json:"text"`
+        Status string `json:"status"`
+}
+
+// BoundingBox is a rectangle of a manuscript image in pixels, measured from its top left corner
+type BoundingBox struct {
+        X      int `json:"x"`
+        Y      int `json:"y"`
+        Width  int `json:"width"`
+        Height int `json:"height"`
+}
+
+// GlyphLayout is a glyph found in a manuscript image with the text it was read as
+// Word is the index of the word the glyph belongs to within its line; an uncertain glyph,
+// read with low confidence, names the review queue item it was queued as
+type GlyphLayout struct {
+        Box        BoundingBox `json:"box"`
+        Text       string      `json:"text"`
+        Confidence float64     `json:"confidence"`
+        Word       int         `json:"word"`
+        Uncertain  bool        `json:"uncertain,omitempty"`
+        ReviewID   string      `json:"reviewId,omitempty"`
+}
+
+// LineLayout is a line of text found in a manuscript image, with its glyphs in reading order
+type LineLayout struct {
+        Box       BoundingBox   `json:"box"`
+        Direction string        `json:"direction"`
+        Glyphs    []GlyphLayout `json:"glyphs"`
+}
+
+// PageLayout locates the lines and glyphs of the text read from a manuscript image
+// Direction is the reading direction of the text: ltr, rtl or boustrophedon
+// The image is turned upright before it is read, and the boxes are in the turned image:
+// Rotation is the whole clockwise turn in degrees about the centre of the image, made of the
+// configured rotation angle, the detected Orientation of 0, 90, 180 or 270 degrees and the
+// detected Skew of its lines; Width and Height are those of the turned image
+type PageLayout struct {
+        Width       int          `json:"width"`
+        Height      int          `json:"height"`
+        Direction   string       `json:"direction"`
+        Rotation    float64      `json:"rotation"`
+        Orientation int          `json:"orientation"`
+        Skew        float64      `json:"skew"`
+        Lines       []LineLayout `json:"lines"`
+}
+
+// TranslationCandidate is one of the alternative translations of a text, best first
+// Summary and Metadata are only filled in for the candidates below the top one when all
+// candidates are analysed; the top candidate's are those of the response itself
+type TranslationCandidate struct {
+        Text     string    `json:"text"`
+        Score    float64   `json:"score"`
+        Backend  string    `json:"backend"`
+        Summary  string    `json:"summary,omitempty"`
+        Metadata *Metadata `json:"metadata,omitempty"`
+}
+
 // TranslationResult represents the result of a translation
+// ImageScripts ranks the scripts by the look of the writing when the script of an image was detected
+// OCRConfidence is the mean confidence of the glyphs read from an image
 type TranslationResult struct {
-        ManuscriptID   string    `json:"manuscriptId"`
-        OriginalScript string    `json:"originalScript"`
-        TranslatedText string    `json:"translatedText"`
-        Summary        string    `json:"summary"`
-        Metadata       Metadata  `json:"metadata,omitempty"`
-        TranslatedAt   time.Time `json:"translatedAt"`
+        ManuscriptID       string                 `json:"manuscriptId"`
+        OriginalScript     string                 `json:"originalScript"`
+        ScriptCandidates   []ScriptCandidate      `json:"scriptCandidates,omitempty"`
+        TargetLanguage     string                 `json:"targetLanguage"`
+        SourceText         string                 `json:"sourceText,omitempty"`
+        SourceTokens       []LeidenToken          `json:"sourceTokens,omitempty"`
+        TranslatedText     string                 `json:"translatedText"`
+        Alignment          []AlignmentPair        `json:"alignment,omitempty"`
+        MemoryMatches      []MemoryMatch          `json:"memoryMatches,omitempty"`
+        GlossaryViolations []GlossaryViolation    `json:"glossaryViolations,omitempty"`
+        Candidates         []TranslationCandidate `json:"candidates,omitempty"`
+        Layout             *PageLayout            `json:"layout,omitempty"`
+        ImageScripts       []ScriptCandidate      `json:"imageScripts,omitempty"`
+        OCRConfidence      float64                `json:"ocrConfidence,omitempty"`
+        Summary            string                 `json:"summary"`
+        Metadata           Metadata               `json:"metadata,omitempty"`
+        TranslatedAt       time.Time              `json:"translatedAt"`
 }
 
 // TranslationResponse represents the API response for a translation request
+// ImageScripts ranks the scripts by the look of the writing when the script of an image was detected
+// OCRConfidence is the mean confidence of the glyphs read from an image
 type TranslationResponse struct {
-        OriginalScript string   `json:"originalScript"`
-        TranslatedText string   `json:"translatedText"`
-        Summary        string   `json:"summary"`
-        Metadata       Metadata `json:"metadata,omitempty"`
-        ProcessedAt    string   `json:"processedAt"`
+        OriginalScript     string                 `json:"originalScript"`
+        ScriptCandidates   []ScriptCandidate      `json:"scriptCandidates,omitempty"`
+        TargetLanguage     string                 `json:"targetLanguage"`
+        SourceText         string                 `json:"sourceText,omitempty"`
+        SourceTokens       []LeidenToken          `json:"sourceTokens,omitempty"`
+        TranslatedText     string                 `json:"translatedText"`
+        Alignment          []AlignmentPair        `json:"alignment,omitempty"`
+        MemoryMatches      []MemoryMatch          `json:"memoryMatches,omitempty"`
+        GlossaryViolations []GlossaryViolation    `json:"glossaryViolations,omitempty"`
+        Candidates         []TranslationCandidate `json:"candidates,omitempty"`
+        Layout             *PageLayout            `json:"layout,omitempty"`
+        ImageScripts       []ScriptCandidate      `json:"imageScripts,omitempty"`
+        OCRConfidence      float64                `json:"ocrConfidence,omitempty"`
+        Summary            string                 `json:"summary"`
+        Metadata           Metadata               `json:"metadata,omitempty"`
+        ProcessedAt        string                 `json:"processedAt"`
 }
 
 // TimePeriod represents a historical time period
@@ -79,3 +200,167 @@ type SummarizeResponse struct {
         TextLength  int    `json:"textLength"`
         ProcessedAt string `json:"processedAt"`
 }
+
+
+// TransliterateRequest represents a request to convert between native script and romanization
+type TransliterateRequest struct {
+        Text       string `json:"text"`
+        ScriptType string `json:"scriptType,omitempty"` // Defaults to auto-detection
+        Direction  string `json:"direction,omitempty"`  // toUnicode, toRoman, or auto
+}
+
+// TransliterateResponse represents the API response for a transliteration request
+type TransliterateResponse struct {
+        Text        string   `json:"text"`
+        ScriptType  string   `json:"scriptType"`
+        Direction   string   `json:"direction"`
+        Convention  string   `json:"convention"`
+        Unknown     []string `json:"unknown,omitempty"`
+        ProcessedAt string   `json:"processedAt"`
+}
+
+// MemoryMatch is a translation memory entry similar to the translated text
+type MemoryMatch struct {
+        SourceText     string `json:"sourceText"`
+        TranslatedText string `json:"translatedText"`
+        MatchPercent   int    `json:"matchPercent"`
+}
+
+// MemoryEntryRequest represents an approved translation to store in the translation memory
+type MemoryEntryRequest struct {
+        SourceText     string `json:"sourceText"`
+        TranslatedText string `json:"translatedText"`
+        ScriptType     string `json:"scriptType"`
+        TargetLanguage string `json:"targetLanguage,omitempty"`
+}
+
+// MemoryResponse represents the API response after the translation memory changed
+type MemoryResponse struct {
+        Added       int    `json:"added"`
+        Skipped     int    `json:"skipped,omitempty"`
+        TotalSize   int    `json:"totalSize"`
+        ProcessedAt string `json:"processedAt"`
+}
+
+// GlossaryTerm is a source term with the rendering a translation must use
+type GlossaryTerm struct {
+        Source string `json:"source"`
+        Target string `json:"target"`
+        // Forbidden lists renderings that must be replaced by Target, e.g. "commander" for Imperator
+        Forbidden []string `json:"forbidden,omitempty"`
+        Note      string   `json:"note,omitempty"`
+}
+
+// Glossary is a project's term list for one script and target language
+// Empty ScriptType or TargetLanguage apply the glossary to any script or language
+type Glossary struct {
+        ID             string         `json:"id"`
+        Project        string         `json:"project"`
+        Name           string         `json:"name"`
+        ScriptType     string         `json:"scriptType,omitempty"`
+        TargetLanguage string         `json:"targetLanguage,omitempty"`
+        Terms          []GlossaryTerm `json:"terms"`
+        CreatedAt      time.Time      `json:"createdAt"`
+        UpdatedAt      time.Time      `json:"updatedAt"`
+}
+
+// GlossaryViolation reports a glossary term the translation did not render as required
+type GlossaryViolation struct {
+        GlossaryID string `json:"glossaryId"`
+        Source     string `json:"source"`
+        Expected   string `json:"expected"`
+        // Found is the rendering that was used instead, empty if the term is missing altogether
+        Found string `json:"found,omitempty"`
+        // Fixed reports whether the post-edit pass replaced Found with Expected
+        Fixed bool `json:"fixed"`
+}
+
+// SignSample is a labelled glyph image of the sign inventory the OCR learns new signs from
+// A sample is read as Text, or as Name for signs without a Unicode character
+type SignSample struct {
+        ID     string `json:"id"`
+        Script string `json:"script"`
+        // Text is the character the sign is read as, e.g. 𒀭; uploads may give it as a code point such as U+1202D
+        Text string `json:"text,omitempty"`
+        // Name is the conventional name of the sign, e.g. AN
+        Name      string    `json:"name,omitempty"`
+        Width     int       `json:"width"`
+        Height    int       `json:"height"`
+        CreatedAt time.Time `json:"createdAt"`
+}
+
+// SignIndexResponse represents the API response after the sign index was rebuilt
+type SignIndexResponse struct {
+        Scripts     map[string]int `json:"scripts"`
+        TotalSize   int            `json:"totalSize"`
+        ProcessedAt string         `json:"processedAt"`
+}
+
+// ProcessedImage represents a manuscript image run through an image processing pipeline
+// Pipeline names the preset run, empty for steps given with the request. Image and the images
+// of the stages are base64 encoded in the format of the uploaded image.
+type ProcessedImage struct {
+        Pipeline    string       `json:"pipeline,omitempty"`
+        ContentType string       `json:"contentType"`
+        Image       string       `json:"image"`
+        Stages      []ImageStage `json:"stages,omitempty"`
+        ProcessedAt string       `json:"processedAt"`
+        // Data is the processed image, for responses that send it as is
+        Data []byte `json:"-"`
+}
+
+// ImageStage represents an image as it leaves a step of an image processing pipeline
+// Step 0 is the straightened image the steps start from
+type ImageStage struct {
+        Step  int    `json:"step"`
+        Op    string `json:"op"`
+        Image string `json:"image"`
+}
+
+// OCRResult represents the text recognized in a manuscript image, without translation
+// Text marks letters read with low confidence with a dot below, as in Leiden notation
+type OCRResult struct {
+        Script       string            `json:"script"`
+        ImageScripts []ScriptCandidate `json:"imageScripts,omitempty"`
+        Text         string            `json:"text"`
+        Confidence   float64           `json:"confidence"`
+        Layout       *PageLayout       `json:"layout"`
+}
+
+// OCRResponse represents the API response for an OCR request
+type OCRResponse struct {
+        Script       string            `json:"script"`
+        ImageScripts []ScriptCandidate `json:"imageScripts,omitempty"`
+        Text         string            `json:"text"`
+        Confidence   float64           `json:"confidence"`
+        Layout       *PageLayout       `json:"layout"`
+        ProcessedAt  string            `json:"processedAt"`
+}
+
+// ReviewItem is a glyph the OCR read with low confidence, queued for a reviewer to accept or correct
+// Status is pending until the reading is accepted or corrected, which adds the glyph to the sign
+// inventory as SignID, or the item is dismissed
+type ReviewItem struct {
+        ID         string      `json:"id"`
+        Script     string      `json:"script"`
+        Text       string      `json:"text"`
+        Confidence float64     `json:"confidence"`
+        // Line is the text of the line the glyph was read in, for context
+        Line string `json:"line,omitempty"`
+        // Manuscript identifies the image the glyph was found in by a hash of its content
+        Manuscript string      `json:"manuscript"`
+        Box        BoundingBox `json:"box"`
+        Status     string      `json:"status"`
+        Correction string      `json:"correction,omitempty"`
+        SignID     string      `json:"signId,omitempty"`
+        CreatedAt  time.Time   `json:"createdAt"`
+        ReviewedAt *time.Time  `json:"reviewedAt,omitempty"`
+}
+
+// ReviewDecision represents a reviewer's verdict on a review queue item
+// Action is accept, keeping the OCR reading, or correct, replacing it by Text or Name
+type ReviewDecision struct {
+        Action string `json:"action"`
+        Text   string `json:"text,omitempty"`
+        Name   string `json:"name,omitempty"`
+}
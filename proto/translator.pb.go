@@ -9,18 +9,125 @@ import (
         grpc "google.golang.org/grpc"
 )
 
-// TranslateRequest contains the manuscript image and script type
+// TranslateRequest contains the manuscript image, script type, target language, glossary project and number of candidates
 type TranslateRequest struct {
-        ManuscriptImage []byte  `protobuf:"bytes,1,opt,name=manuscript_image,json=manuscriptImage,proto3" json:"manuscript_image,omitempty"`
-        ScriptType      string  `protobuf:"bytes,2,opt,name=script_type,json=scriptType,proto3" json:"script_type,omitempty"`
+        ManuscriptImage   []byte `protobuf:"bytes,1,opt,name=manuscript_image,json=manuscriptImage,proto3" json:"manuscript_image,omitempty"`
+        ScriptType        string `protobuf:"bytes,2,opt,name=script_type,json=scriptType,proto3" json:"script_type,omitempty"`
+        TargetLanguage    string `protobuf:"bytes,3,opt,name=target_language,json=targetLanguage,proto3" json:"target_language,omitempty"`
+        Project           string `protobuf:"bytes,4,opt,name=project,proto3" json:"project,omitempty"`
+        N                 int32  `protobuf:"varint,5,opt,name=n,proto3" json:"n,omitempty"`
+        AnalyzeCandidates bool   `protobuf:"varint,6,opt,name=analyze_candidates,json=analyzeCandidates,proto3" json:"analyze_candidates,omitempty"`
+        Direction         string `protobuf:"bytes,7,opt,name=direction,proto3" json:"direction,omitempty"`
+        Pipeline          string `protobuf:"bytes,8,opt,name=pipeline,proto3" json:"pipeline,omitempty"`
 }
 
 // TranslateResponse contains the translation, summary and historical metadata
 type TranslateResponse struct {
-        OriginalScript string           `protobuf:"bytes,1,opt,name=original_script,json=originalScript,proto3" json:"original_script,omitempty"`
-        TranslatedText string           `protobuf:"bytes,2,opt,name=translated_text,json=translatedText,proto3" json:"translated_text,omitempty"`
-        Summary        string           `protobuf:"bytes,3,opt,name=summary,proto3" json:"summary,omitempty"`
-        Metadata       *MetadataResponse `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+        OriginalScript     string                  `protobuf:"bytes,1,opt,name=original_script,json=originalScript,proto3" json:"original_script,omitempty"`
+        TranslatedText     string                  `protobuf:"bytes,2,opt,name=translated_text,json=translatedText,proto3" json:"translated_text,omitempty"`
+        Summary            string                  `protobuf:"bytes,3,opt,name=summary,proto3" json:"summary,omitempty"`
+        Metadata           *MetadataResponse       `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+        TargetLanguage     string                  `protobuf:"bytes,5,opt,name=target_language,json=targetLanguage,proto3" json:"target_language,omitempty"`
+        SourceText         string                  `protobuf:"bytes,6,opt,name=source_text,json=sourceText,proto3" json:"source_text,omitempty"`
+        Alignment          []*AlignmentPair        `protobuf:"bytes,7,rep,name=alignment,proto3" json:"alignment,omitempty"`
+        GlossaryViolations []*GlossaryViolation    `protobuf:"bytes,8,rep,name=glossary_violations,json=glossaryViolations,proto3" json:"glossary_violations,omitempty"`
+        Candidates         []*TranslationCandidate `protobuf:"bytes,9,rep,name=candidates,proto3" json:"candidates,omitempty"`
+        SourceTokens       []*LeidenToken          `protobuf:"bytes,10,rep,name=source_tokens,json=sourceTokens,proto3" json:"source_tokens,omitempty"`
+        Layout             *PageLayout             `protobuf:"bytes,11,opt,name=layout,proto3" json:"layout,omitempty"`
+        ImageScripts       []*ScriptCandidate      `protobuf:"bytes,12,rep,name=image_scripts,json=imageScripts,proto3" json:"image_scripts,omitempty"`
+        OcrConfidence      float64                 `protobuf:"fixed64,13,opt,name=ocr_confidence,json=ocrConfidence,proto3" json:"ocr_confidence,omitempty"`
+}
+
+// ScriptCandidate is a possible script with the confidence of its detection
+type ScriptCandidate struct {
+        Script     string  `protobuf:"bytes,1,opt,name=script,proto3" json:"script,omitempty"`
+        Confidence float64 `protobuf:"fixed64,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+}
+
+// PageLayout locates the lines and glyphs of the text read from a manuscript image
+type PageLayout struct {
+        Width       int32         `protobuf:"varint,1,opt,name=width,proto3" json:"width,omitempty"`
+        Height      int32         `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+        Direction   string        `protobuf:"bytes,3,opt,name=direction,proto3" json:"direction,omitempty"`
+        Lines       []*LineLayout `protobuf:"bytes,4,rep,name=lines,proto3" json:"lines,omitempty"`
+        Rotation    float64       `protobuf:"fixed64,5,opt,name=rotation,proto3" json:"rotation,omitempty"`
+        Orientation int32         `protobuf:"varint,6,opt,name=orientation,proto3" json:"orientation,omitempty"`
+        Skew        float64       `protobuf:"fixed64,7,opt,name=skew,proto3" json:"skew,omitempty"`
+}
+
+// LineLayout is a line of text with its glyphs in reading order
+type LineLayout struct {
+        Box       *BoundingBox   `protobuf:"bytes,1,opt,name=box,proto3" json:"box,omitempty"`
+        Direction string         `protobuf:"bytes,2,opt,name=direction,proto3" json:"direction,omitempty"`
+        Glyphs    []*GlyphLayout `protobuf:"bytes,3,rep,name=glyphs,proto3" json:"glyphs,omitempty"`
+}
+
+// GlyphLayout is a glyph with the text it was read as
+type GlyphLayout struct {
+        Box        *BoundingBox `protobuf:"bytes,1,opt,name=box,proto3" json:"box,omitempty"`
+        Text       string       `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+        Confidence float64      `protobuf:"fixed64,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+        Word       int32        `protobuf:"varint,4,opt,name=word,proto3" json:"word,omitempty"`
+        Uncertain  bool         `protobuf:"varint,5,opt,name=uncertain,proto3" json:"uncertain,omitempty"`
+        ReviewId   string       `protobuf:"bytes,6,opt,name=review_id,json=reviewId,proto3" json:"review_id,omitempty"`
+}
+
+// BoundingBox is a rectangle of the image in pixels, measured from its top left corner
+type BoundingBox struct {
+        X      int32 `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+        Y      int32 `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+        Width  int32 `protobuf:"varint,3,opt,name=width,proto3" json:"width,omitempty"`
+        Height int32 `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+// LeidenToken is a word of an edition, or a lacuna, with how securely it is read
+type LeidenToken struct {
+        Text     string           `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+        Status   string           `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+        Missing  int32            `protobuf:"varint,3,opt,name=missing,proto3" json:"missing,omitempty"`
+        Start    int32            `protobuf:"varint,4,opt,name=start,proto3" json:"start,omitempty"`
+        End      int32            `protobuf:"varint,5,opt,name=end,proto3" json:"end,omitempty"`
+        Queried  bool             `protobuf:"varint,6,opt,name=queried,proto3" json:"queried,omitempty"`
+        Segments []*LeidenSegment `protobuf:"bytes,7,rep,name=segments,proto3" json:"segments,omitempty"`
+}
+
+// LeidenSegment is a run of letters of a word sharing one status
+type LeidenSegment struct {
+        Text   string `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+        Status string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+// TranslationCandidate is one alternative translation with its score and the backend that produced it
+type TranslationCandidate struct {
+        Text     string            `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+        Score    float64           `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+        Backend  string            `protobuf:"bytes,3,opt,name=backend,proto3" json:"backend,omitempty"`
+        Summary  string            `protobuf:"bytes,4,opt,name=summary,proto3" json:"summary,omitempty"`
+        Metadata *MetadataResponse `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+}
+
+// GlossaryViolation reports a glossary term the translation did not render as required
+type GlossaryViolation struct {
+        GlossaryId string `protobuf:"bytes,1,opt,name=glossary_id,json=glossaryId,proto3" json:"glossary_id,omitempty"`
+        Source     string `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+        Expected   string `protobuf:"bytes,3,opt,name=expected,proto3" json:"expected,omitempty"`
+        Found      string `protobuf:"bytes,4,opt,name=found,proto3" json:"found,omitempty"`
+        Fixed      bool   `protobuf:"varint,5,opt,name=fixed,proto3" json:"fixed,omitempty"`
+}
+
+// TextSpan is a range of a text, with offsets counted in Unicode code points
+type TextSpan struct {
+        Start int32  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+        End   int32  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+        Text  string `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+}
+
+// AlignmentPair links the source span that produced a target span of the translation
+type AlignmentPair struct {
+        Source     *TextSpan `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+        Target     *TextSpan `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+        Confidence float64   `protobuf:"fixed64,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+        Status     string    `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
 }
 
 // MetadataResponse contains historical context information
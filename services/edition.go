@@ -0,0 +1,160 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"ancient-script-decoder/models"
+	"ancient-script-decoder/services/leiden"
+)
+
+// editionConfidence scales the alignment confidence of translations of damaged words
+var editionConfidence = map[leiden.Status]float64{
+	leiden.Uncertain: 0.7,
+	leiden.Erased:    0.9,
+	leiden.Supplied:  0.8,
+	leiden.Restored:  0.5,
+}
+
+// editionMarks are the brackets that carry the status of source words over to their translation,
+// following the way editions mark restorations and doubtful readings in the translation
+var editionMarks = map[leiden.Status][2]string{
+	leiden.Uncertain: {"", "(?)"},
+	leiden.Erased:    {"⟦", "⟧"},
+	leiden.Supplied:  {"⟨", "⟩"},
+	leiden.Restored:  {"[", "]"},
+}
+
+// lacunaMark stands for lost text in a translation
+const lacunaMark = "[...]"
+
+// editionWord is a word of a Leiden edition located in the text prepared for the backends
+type editionWord struct {
+	start, end int
+	status     leiden.Status
+}
+
+// leidenEdition relates a text in Leiden notation to the plain text the backends translate
+type leidenEdition struct {
+	words []editionWord
+	// gaps holds the offsets in the prepared text at which lacunae interrupt it
+	gaps []int
+	// tokens is the structured reading reported with the translation
+	tokens []models.LeidenToken
+}
+
+// prepareEdition transliterates each word of a parsed edition and joins the words into the
+// text passed to the backends, recording where every word and lacuna falls in it.
+// Offsets count code points, like those of the alignment.
+func (t *Translator) prepareEdition(tokens []leiden.Token, scriptType string) (string, *leidenEdition) {
+	edition := &leidenEdition{}
+	var b strings.Builder
+	pos := 0
+
+	for _, token := range tokens {
+		status := token.Status()
+		if token.Lacuna {
+			edition.gaps = append(edition.gaps, pos)
+			edition.tokens = append(edition.tokens, models.LeidenToken{
+				Status:  string(status),
+				Missing: token.Missing,
+				Start:   pos,
+				End:     pos,
+			})
+			continue
+		}
+
+		text := token.Text()
+		if text == "" {
+			// Words deleted by the editor are not translated
+			continue
+		}
+		if pos > 0 {
+			b.WriteString(" ")
+			pos++
+		}
+		word := t.prepareInput(text, scriptType)
+		end := pos + utf8.RuneCountInString(word)
+		b.WriteString(word)
+
+		segments := make([]models.LeidenSegment, 0, len(token.Segments))
+		for _, segment := range token.Segments {
+			segments = append(segments, models.LeidenSegment{Text: segment.Text, Status: string(segment.Status)})
+		}
+
+		edition.words = append(edition.words, editionWord{start: pos, end: end, status: status})
+		edition.tokens = append(edition.tokens, models.LeidenToken{
+			Text:     word,
+			Status:   string(status),
+			Queried:  token.Queried,
+			Segments: segments,
+			Start:    pos,
+			End:      end,
+		})
+		pos = end
+	}
+
+	return b.String(), edition
+}
+
+// mark carries the certainty of the source words over to the translation. The rendering of
+// each aligned span is bracketed by the status of its least certain source word, and lacunae
+// are shown where they interrupt the source. A translation without alignment is left as it is.
+func (e *leidenEdition) mark(text string, alignment []models.AlignmentPair) (string, []models.AlignmentPair) {
+	if len(alignment) == 0 {
+		return text, alignment
+	}
+
+	marked := make([]models.AlignmentPair, len(alignment))
+	copy(marked, alignment)
+	for i := range marked {
+		status := e.spanStatus(marked[i].Source)
+		if status == leiden.Certain || status == leiden.Expanded || marked[i].Target.Start == marked[i].Target.End {
+			continue
+		}
+		marked[i].Status = string(status)
+		marked[i].Confidence *= editionConfidence[status]
+
+		marks := editionMarks[status]
+		start, end := byteOffset(text, marked[i].Target.Start), byteOffset(text, marked[i].Target.End)
+		text, marked = replaceTargetSpan(text, marked, start, end, marks[0]+text[start:end]+marks[1])
+	}
+
+	// Show each lacuna before the rendering of the first word that follows it
+	for _, gap := range e.gaps {
+		insertAt := utf8.RuneCountInString(text)
+		mark := " " + lacunaMark
+		for _, pair := range marked {
+			if pair.Source.Start >= gap {
+				insertAt = pair.Target.Start
+				mark = lacunaMark + " "
+				break
+			}
+		}
+		offset := byteOffset(text, insertAt)
+		text, marked = replaceTargetSpan(text, marked, offset, offset, mark)
+	}
+
+	return text, marked
+}
+
+// spanStatus returns the status of the least certain word overlapping a span of the prepared text
+func (e *leidenEdition) spanStatus(span models.TextSpan) leiden.Status {
+	status := leiden.Certain
+	for _, word := range e.words {
+		if word.start < span.End && word.end > span.Start && leiden.LessCertain(word.status, status) {
+			status = word.status
+		}
+	}
+	return status
+}
+
+// byteOffset converts an offset in code points to a byte offset into text
+func byteOffset(text string, runes int) int {
+	offset := 0
+	for i := 0; i < runes && offset < len(text); i++ {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return offset
+}
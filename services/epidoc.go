@@ -0,0 +1,536 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"ancient-script-decoder/models"
+	"ancient-script-decoder/services/leiden"
+)
+
+// xmlNamespace is the namespace of the xml:lang attribute
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// epidocSchema associates exported documents with the EpiDoc schema
+const epidocSchema = `<?xml-model href="https://epidoc.stoa.org/schema/latest/tei-epidoc.rng" schematypens="http://relaxng.org/ns/structure/1.0"?>` + "\n"
+
+// EpiDocEdition is the edition of an EpiDoc document rewritten in Leiden notation
+type EpiDocEdition struct {
+	Title string
+	// Language is the xml:lang of the edition, e.g. "la" or "grc"
+	Language string
+	// ScriptType is the supported script written in Language, empty if there is none
+	ScriptType string
+	// Text is the edition in Leiden notation, ready to be translated
+	Text string
+}
+
+// epidocSkipped lists the elements whose content is not part of the edited text
+var epidocSkipped = map[string]bool{
+	"note":   true,
+	"rdg":    true,
+	"desc":   true,
+	"figure": true,
+}
+
+// epidocAlternates lists the readings of a choice that give way to the editor's reading
+var epidocAlternates = map[string]bool{
+	"sic":  true,
+	"orig": true,
+	"abbr": true,
+}
+
+// epidocBlocks lists the elements that end a run of text
+var epidocBlocks = map[string]bool{
+	"ab":  true,
+	"p":   true,
+	"div": true,
+	"l":   true,
+	"lg":  true,
+}
+
+// ReadEpiDoc reads the edition of an EpiDoc document into Leiden notation.
+// Restorations, supplied, unclear, erased and superfluous letters, expansions,
+// gaps and queried readings are rewritten with the corresponding brackets and
+// marks; notes, variant readings and the readings a choice corrects are left out.
+func ReadEpiDoc(r io.Reader) (EpiDocEdition, error) {
+	decoder := xml.NewDecoder(r)
+	var edition EpiDocEdition
+	var path []string
+	var langs []string
+	found := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return EpiDocEdition{}, fmt.Errorf("failed to parse EpiDoc: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			lang := xmlLang(t)
+			if lang == "" && len(langs) > 0 {
+				lang = langs[len(langs)-1]
+			}
+
+			switch {
+			case t.Name.Local == "title" && len(path) > 0 && path[len(path)-1] == "titleStmt" && edition.Title == "":
+				var title struct {
+					Text string `xml:",chardata"`
+				}
+				if err := decoder.DecodeElement(&title, &t); err != nil {
+					return EpiDocEdition{}, fmt.Errorf("failed to parse EpiDoc: %v", err)
+				}
+				edition.Title = strings.Join(strings.Fields(title.Text), " ")
+				continue
+			case t.Name.Local == "div" && attrValue(t, "type") == "edition":
+				if found {
+					// Only the first edition is translated
+					if err := decoder.Skip(); err != nil {
+						return EpiDocEdition{}, fmt.Errorf("failed to parse EpiDoc: %v", err)
+					}
+					continue
+				}
+				reader := &epidocReader{decoder: decoder}
+				if err := reader.readContent(""); err != nil {
+					return EpiDocEdition{}, fmt.Errorf("failed to parse EpiDoc edition: %v", err)
+				}
+				edition.Language = lang
+				edition.Text = strings.Join(strings.Fields(reader.b.String()), " ")
+				found = true
+				continue
+			}
+
+			path = append(path, t.Name.Local)
+			langs = append(langs, lang)
+		case xml.EndElement:
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+				langs = langs[:len(langs)-1]
+			}
+		}
+	}
+
+	if !found {
+		return EpiDocEdition{}, fmt.Errorf("EpiDoc document has no edition division")
+	}
+	if edition.Text == "" {
+		return EpiDocEdition{}, fmt.Errorf("EpiDoc edition is empty")
+	}
+	edition.ScriptType = scriptForLanguage(edition.Language)
+	return edition, nil
+}
+
+// epidocReader rewrites the content of an EpiDoc edition in Leiden notation
+type epidocReader struct {
+	decoder *xml.Decoder
+	b       strings.Builder
+	// unclear counts the unclear elements the reader is inside
+	unclear int
+}
+
+// readContent rewrites the content of the current element up to its end tag
+func (r *epidocReader) readContent(parent string) error {
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := r.element(t, parent); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		case xml.CharData:
+			r.text(string(t))
+		}
+	}
+}
+
+// element rewrites an element of the edition and its content
+func (r *epidocReader) element(start xml.StartElement, parent string) error {
+	name := start.Name.Local
+	if epidocSkipped[name] || parent == "choice" && epidocAlternates[name] {
+		return r.decoder.Skip()
+	}
+
+	open, close := "", ""
+	switch name {
+	case "supplied":
+		if attrValue(start, "reason") == "lost" {
+			open, close = "[", "]"
+		} else {
+			open, close = "⟨", "⟩"
+		}
+	case "corr":
+		open, close = "⟨", "⟩"
+	case "ex":
+		open, close = "(", ")"
+	case "del":
+		open, close = "⟦", "⟧"
+	case "surplus":
+		open, close = "{", "}"
+	case "unclear":
+		r.unclear++
+		defer func() { r.unclear-- }()
+	case "gap":
+		r.gap(start)
+		return r.decoder.Skip()
+	case "lb":
+		if attrValue(start, "break") != "no" {
+			r.b.WriteString(" ")
+		}
+		return r.decoder.Skip()
+	case "g", "space":
+		// Symbols, interpuncts and blank spaces separate words
+		r.b.WriteString(" ")
+		return r.decoder.Skip()
+	case "certainty":
+		r.b.WriteString("(?)")
+		return r.decoder.Skip()
+	default:
+		if epidocBlocks[name] {
+			close = " "
+		}
+	}
+
+	r.b.WriteString(open)
+	if err := r.readContent(name); err != nil {
+		return err
+	}
+	r.b.WriteString(close)
+	if attrValue(start, "cert") == "low" {
+		r.b.WriteString("(?)")
+	}
+	return nil
+}
+
+// gap writes a lacuna for lost or illegible text; gaps the editor left out on purpose are ignored
+func (r *epidocReader) gap(start xml.StartElement) {
+	if reason := attrValue(start, "reason"); reason != "lost" && reason != "illegible" {
+		return
+	}
+
+	quantity, err := strconv.Atoi(attrValue(start, "quantity"))
+	unit := attrValue(start, "unit")
+	switch {
+	case err == nil && quantity > 0 && (unit == "" || unit == "character"):
+		if attrValue(start, "precision") == "low" {
+			fmt.Fprintf(&r.b, " [ca.%d] ", quantity)
+		} else {
+			fmt.Fprintf(&r.b, " [.%d.] ", quantity)
+		}
+	default:
+		r.b.WriteString(" [---] ")
+	}
+}
+
+// text writes the letters of the edition, with a dot below those inside an unclear element
+func (r *epidocReader) text(s string) {
+	for _, c := range s {
+		if unicode.IsSpace(c) {
+			r.b.WriteString(" ")
+			continue
+		}
+		r.b.WriteRune(c)
+		if r.unclear > 0 && unicode.IsLetter(c) {
+			r.b.WriteRune('̣')
+		}
+	}
+}
+
+// attrValue returns the value of an attribute without namespace, or "" if it is not set
+func attrValue(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name && attr.Name.Space == "" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// xmlLang returns the xml:lang attribute of an element
+func xmlLang(start xml.StartElement) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "lang" && (attr.Name.Space == xmlNamespace || attr.Name.Space == "xml") {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// scriptForLanguage returns the supported script of a language tag, or "" if there is none
+func scriptForLanguage(lang string) string {
+	code := strings.ToLower(lang)
+	if idx := strings.IndexAny(code, "-_"); idx > 0 {
+		code = code[:idx]
+	}
+	for script, scriptCode := range scriptLanguageCodes {
+		if scriptCode == code {
+			return script
+		}
+	}
+	return ""
+}
+
+// teiDocument is the root of an exported EpiDoc document
+type teiDocument struct {
+	XMLName xml.Name  `xml:"http://www.tei-c.org/ns/1.0 TEI"`
+	Lang    string    `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Header  teiHeader `xml:"teiHeader"`
+	Divs    []teiDiv  `xml:"text>body>div"`
+}
+
+// teiHeader describes the inscription and its languages
+type teiHeader struct {
+	Title       string        `xml:"fileDesc>titleStmt>title"`
+	Publication string        `xml:"fileDesc>publicationStmt>p"`
+	Source      teiMsDesc     `xml:"fileDesc>sourceDesc>msDesc"`
+	Languages   []teiLanguage `xml:"profileDesc>langUsage>language"`
+	Keywords    *teiKeywords  `xml:"profileDesc>textClass,omitempty"`
+}
+
+// teiKeywords classifies the text by its cultural context
+type teiKeywords struct {
+	Terms []string `xml:"keywords>term"`
+}
+
+// teiMsDesc describes the inscribed object: its material, date and place of origin.
+// Optional parts are pointers so that they are left out rather than written empty.
+type teiMsDesc struct {
+	Identifier string       `xml:"msIdentifier>idno"`
+	Physical   *teiPhysDesc `xml:"physDesc,omitempty"`
+	History    *teiHistory  `xml:"history,omitempty"`
+}
+
+// teiPhysDesc records the materials of the inscribed object
+type teiPhysDesc struct {
+	Materials []string `xml:"objectDesc>supportDesc>support>material"`
+}
+
+// teiHistory records when and where the inscription originated
+type teiHistory struct {
+	Dates  []teiOrigDate `xml:"origin>origDate"`
+	Places []string      `xml:"origin>origPlace"`
+}
+
+// teiOrigDate is a date of origin; EpiDoc gives years as four digits, negative before the common era
+type teiOrigDate struct {
+	NotBefore string `xml:"notBefore-custom,attr,omitempty"`
+	NotAfter  string `xml:"notAfter-custom,attr,omitempty"`
+	Cert      string `xml:"cert,attr,omitempty"`
+	Text      string `xml:",chardata"`
+}
+
+// teiLanguage declares a language used in the document
+type teiLanguage struct {
+	Ident string `xml:"ident,attr"`
+	Name  string `xml:",chardata"`
+}
+
+// teiDiv is a division of the body: the edition, a translation or the commentary
+type teiDiv struct {
+	Type       string        `xml:"type,attr"`
+	Lang       string        `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Edition    *teiAb        `xml:"ab,omitempty"`
+	Paragraphs []string      `xml:"p,omitempty"`
+	Events     *teiListEvent `xml:"listEvent,omitempty"`
+}
+
+// teiListEvent lists the historical events the inscription refers to
+type teiListEvent struct {
+	Events []teiEvent `xml:"event"`
+}
+
+// teiAb holds the encoded text of the edition
+type teiAb struct {
+	Content string `xml:",innerxml"`
+}
+
+// teiEvent is a historical event the inscription refers to
+type teiEvent struct {
+	Type        string `xml:"type,attr,omitempty"`
+	When        string `xml:"when,attr,omitempty"`
+	Name        string `xml:"head"`
+	Description string `xml:"p"`
+}
+
+// WriteEpiDoc writes a translation as an EpiDoc document: the edition, encoded from the
+// Leiden reading of the source when there is one, the translation, and the metadata as the
+// material, date and place of origin of the inscription. The summary and the historical
+// events it refers to make up the commentary.
+func WriteEpiDoc(w io.Writer, response models.TranslationResponse) error {
+	sourceLang, ok := scriptLanguageCodes[response.OriginalScript]
+	if !ok {
+		sourceLang = response.OriginalScript
+	}
+	metadata := response.Metadata
+
+	doc := teiDocument{
+		Lang: response.TargetLanguage,
+		Header: teiHeader{
+			Title:       fmt.Sprintf("Edition and translation of a %s text", capitalize(response.OriginalScript)),
+			Publication: fmt.Sprintf("Generated by ancient-script-decoder on %s", response.ProcessedAt),
+			Languages:   []teiLanguage{{Ident: sourceLang, Name: capitalize(response.OriginalScript)}},
+		},
+	}
+	if response.TargetLanguage != "" && response.TargetLanguage != sourceLang {
+		doc.Header.Languages = append(doc.Header.Languages, teiLanguage{Ident: response.TargetLanguage})
+	}
+	if len(metadata.CulturalContext) > 0 {
+		doc.Header.Keywords = &teiKeywords{Terms: metadata.CulturalContext}
+	}
+	if len(metadata.MaterialContext) > 0 {
+		doc.Header.Source.Physical = &teiPhysDesc{Materials: metadata.MaterialContext}
+	}
+
+	history := &teiHistory{}
+	if metadata.DetectedDate != "" {
+		date := teiOrigDate{Text: metadata.DetectedDate}
+		if metadata.ConfidenceScore > 0 {
+			date.Cert = strconv.FormatFloat(metadata.ConfidenceScore, 'f', 2, 64)
+		}
+		history.Dates = append(history.Dates, date)
+	}
+	for _, period := range metadata.TimePeriods {
+		history.Dates = append(history.Dates, teiOrigDate{
+			NotBefore: epidocYear(period.StartYear),
+			NotAfter:  epidocYear(period.EndYear),
+			Text:      period.Name,
+		})
+	}
+	for _, region := range metadata.Regions {
+		history.Places = append(history.Places, region.Name)
+	}
+	if len(history.Dates) > 0 || len(history.Places) > 0 {
+		doc.Header.Source.History = history
+	}
+
+	doc.Divs = append(doc.Divs,
+		teiDiv{Type: "edition", Lang: sourceLang, Edition: &teiAb{Content: epidocEdition(response)}},
+		teiDiv{Type: "translation", Lang: response.TargetLanguage, Paragraphs: []string{response.TranslatedText}},
+	)
+
+	commentary := teiDiv{Type: "commentary"}
+	if response.Summary != "" {
+		commentary.Paragraphs = append(commentary.Paragraphs, response.Summary)
+	}
+	if len(metadata.HistoricalEvents) > 0 {
+		commentary.Events = &teiListEvent{}
+	}
+	for _, event := range metadata.HistoricalEvents {
+		entry := teiEvent{Type: event.EventType, Name: event.Name, Description: event.Description}
+		if event.Year != 0 {
+			entry.When = epidocYear(event.Year)
+		}
+		commentary.Events.Events = append(commentary.Events.Events, entry)
+	}
+	if len(commentary.Paragraphs) > 0 || commentary.Events != nil {
+		doc.Divs = append(doc.Divs, commentary)
+	}
+
+	if _, err := io.WriteString(w, xml.Header+epidocSchema); err != nil {
+		return fmt.Errorf("failed to write EpiDoc: %v", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write EpiDoc: %v", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write EpiDoc: %v", err)
+	}
+	return nil
+}
+
+// epidocYear formats a year with four digits, negative for years before the common era
+func epidocYear(year int) string {
+	if year < 0 {
+		return fmt.Sprintf("-%04d", -year)
+	}
+	return fmt.Sprintf("%04d", year)
+}
+
+// epidocEdition encodes the source of a translation as the content of the edition's ab element
+func epidocEdition(response models.TranslationResponse) string {
+	if len(response.SourceTokens) == 0 {
+		return escapeXML(response.SourceText)
+	}
+
+	words := make([]string, 0, len(response.SourceTokens))
+	for _, token := range response.SourceTokens {
+		words = append(words, epidocWord(token))
+	}
+	return strings.Join(words, " ")
+}
+
+// epidocWord encodes a word of a Leiden reading, or a lacuna, in EpiDoc
+func epidocWord(token models.LeidenToken) string {
+	if leiden.Status(token.Status) == leiden.Lost {
+		if token.Missing > 0 {
+			return fmt.Sprintf(`<gap reason="lost" quantity="%d" unit="character"/>`, token.Missing)
+		}
+		return `<gap reason="lost" extent="unknown" unit="character"/>`
+	}
+
+	segments := token.Segments
+	if len(segments) == 0 {
+		segments = []models.LeidenSegment{{Text: token.Text, Status: token.Status}}
+	}
+	expanded := false
+	for _, segment := range segments {
+		if leiden.Status(segment.Status) == leiden.Expanded {
+			expanded = true
+		}
+	}
+
+	var b strings.Builder
+	for _, segment := range segments {
+		text := escapeXML(segment.Text)
+		switch leiden.Status(segment.Status) {
+		case leiden.Expanded:
+			text = "<ex>" + text + "</ex>"
+		case leiden.Uncertain:
+			text = "<unclear>" + text + "</unclear>"
+		case leiden.Erased:
+			text = `<del rend="erasure">` + text + "</del>"
+		case leiden.Supplied:
+			text = `<supplied reason="omitted">` + text + "</supplied>"
+		case leiden.Restored:
+			text = `<supplied reason="lost">` + text + "</supplied>"
+		case leiden.Deleted:
+			text = "<surplus>" + text + "</surplus>"
+		default:
+			if expanded {
+				text = "<abbr>" + text + "</abbr>"
+			}
+		}
+		b.WriteString(text)
+	}
+
+	word := b.String()
+	if expanded {
+		word = "<expan>" + word + "</expan>"
+	}
+	if token.Queried {
+		word = "<w>" + word + `<certainty locus="value" match=".."/></w>`
+	}
+	return word
+}
+
+// escapeXML escapes text for use as XML character data
+func escapeXML(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
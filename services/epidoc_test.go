@@ -0,0 +1,152 @@
+package services
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ancient-script-decoder/models"
+	"ancient-script-decoder/services/leiden"
+)
+
+// sourceTokens converts the parsed words and lacunae of a Leiden text to their API representation
+func sourceTokens(text string) []models.LeidenToken {
+	var tokens []models.LeidenToken
+	for _, token := range leiden.Parse(text) {
+		converted := models.LeidenToken{
+			Text:    token.Text(),
+			Status:  string(token.Status()),
+			Missing: token.Missing,
+			Queried: token.Queried,
+		}
+		for _, segment := range token.Segments {
+			converted.Segments = append(converted.Segments, models.LeidenSegment{Text: segment.Text, Status: string(segment.Status)})
+		}
+		tokens = append(tokens, converted)
+	}
+	return tokens
+}
+
+func TestEpiDocRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		text   string
+	}{
+		{name: "plain text", script: "latin", text: "Imperator Caesar divi filius"},
+		{name: "restorations", script: "latin", text: "[Aug]ustus [pontifex] maximus"},
+		{name: "lacunae", script: "latin", text: "Imperator [---] Caesar [.3.] filius"},
+		{name: "supplied and superfluous letters", script: "latin", text: "fec⟨i⟩t{s} rex"},
+		{name: "erasure", script: "latin", text: "⟦Getae⟧ Caesari"},
+		{name: "expansion", script: "latin", text: "Imp(erator) Caes(ar)"},
+		{name: "uncertain letters", script: "latin", text: "rẹx fecit"},
+		{name: "queried word", script: "latin", text: "rex(?) fecit"},
+		{name: "markup characters", script: "latin", text: "rex & <filius>"},
+		{name: "greek", script: "greek", text: "βασιλεὺς [μέγ]ας"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := models.TranslationResponse{
+				OriginalScript: test.script,
+				TargetLanguage: "en",
+				SourceText:     leiden.Plain(leiden.Parse(test.text)),
+				SourceTokens:   sourceTokens(test.text),
+				TranslatedText: "the translation",
+				ProcessedAt:    "2024-03-15T09:30:00Z",
+			}
+
+			var buf bytes.Buffer
+			if err := WriteEpiDoc(&buf, response); err != nil {
+				t.Fatalf("WriteEpiDoc() error = %v", err)
+			}
+			edition, err := ReadEpiDoc(&buf)
+			if err != nil {
+				t.Fatalf("ReadEpiDoc() error = %v", err)
+			}
+
+			if edition.ScriptType != test.script {
+				t.Errorf("ScriptType = %q, want %q", edition.ScriptType, test.script)
+			}
+			if edition.Language != scriptLanguageCodes[test.script] {
+				t.Errorf("Language = %q, want %q", edition.Language, scriptLanguageCodes[test.script])
+			}
+			if !strings.Contains(edition.Title, "Latin") && !strings.Contains(edition.Title, "Greek") {
+				t.Errorf("Title = %q, want the script named", edition.Title)
+			}
+			if got, want := sourceTokens(edition.Text), sourceTokens(test.text); !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadEpiDoc(WriteEpiDoc(%q)) = %q\nparsed %+v\nwant   %+v", test.text, edition.Text, got, want)
+			}
+		})
+	}
+}
+
+func TestReadEpiDoc(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantText string
+		wantErr  bool
+	}{
+		{
+			name:     "corrected reading replaces the error",
+			body:     `<ab><choice><sic>fecet</sic><corr>fecit</corr></choice> rex</ab>`,
+			wantText: "⟨fecit⟩ rex",
+		},
+		{
+			name:     "expanded abbreviation in a choice",
+			body:     `<ab><choice><abbr>Imp</abbr><expan>Imp<ex>erator</ex></expan></choice></ab>`,
+			wantText: "Imp(erator)",
+		},
+		{
+			name:     "line break inside a word",
+			body:     `<ab>Cae<lb break="no"/>sar<lb/>rex</ab>`,
+			wantText: "Caesar rex",
+		},
+		{
+			name:     "approximate gap",
+			body:     `<ab>rex <gap reason="lost" quantity="5" unit="character" precision="low"/> fecit</ab>`,
+			wantText: "rex [ca.5] fecit",
+		},
+		{
+			name:     "omitted gap and notes are left out",
+			body:     `<ab>rex <gap reason="omitted"/><note>a comment</note>fecit</ab>`,
+			wantText: "rex fecit",
+		},
+		{
+			name:     "low certainty",
+			body:     `<ab><supplied reason="lost" cert="low">rex</supplied> fecit</ab>`,
+			wantText: "[rex](?) fecit",
+		},
+		{
+			name:    "empty edition",
+			body:    `<ab> </ab>`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := `<TEI xmlns="http://www.tei-c.org/ns/1.0"><teiHeader><fileDesc><titleStmt><title>Test</title></titleStmt></fileDesc></teiHeader>` +
+				`<text><body><div type="edition" xml:lang="la">` + test.body + `</div></body></text></TEI>`
+			edition, err := ReadEpiDoc(strings.NewReader(doc))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ReadEpiDoc() error = %v, want error %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if edition.Text != test.wantText {
+				t.Errorf("Text = %q, want %q", edition.Text, test.wantText)
+			}
+			if edition.Title != "Test" || edition.ScriptType != "latin" {
+				t.Errorf("Title, ScriptType = %q, %q, want %q, %q", edition.Title, edition.ScriptType, "Test", "latin")
+			}
+		})
+	}
+
+	if _, err := ReadEpiDoc(strings.NewReader(`<TEI><text><body><div type="translation"><p>x</p></div></body></text></TEI>`)); err == nil {
+		t.Error("ReadEpiDoc() without an edition division succeeded, want an error")
+	}
+}
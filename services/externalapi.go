@@ -0,0 +1,318 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// The external translation API contract
+//
+// The client sends a POST request to the configured endpoint with a JSON body:
+//
+//	{"text": "...", "sourceScript": "latin", "targetLanguage": "en"}
+//
+// and, when an API key is configured, an "Authorization: Bearer <key>" header.
+// A successful call answers with a 2xx status and:
+//
+//	{"translatedText": "...", "confidence": 0.87}
+//
+// Failures may carry {"error": "..."} in the body. Network errors, 429 and 5xx
+// responses are retried with exponential backoff and count towards opening the circuit
+// breaker; other 4xx responses reject the request itself and do neither.
+
+// ExternalTranslateRequest is the JSON body sent to the external translation API
+type ExternalTranslateRequest struct {
+	Text           string `json:"text"`
+	SourceScript   string `json:"sourceScript"`
+	TargetLanguage string `json:"targetLanguage,omitempty"`
+}
+
+// ExternalTranslateResponse is the JSON body returned by the external translation API
+type ExternalTranslateResponse struct {
+	TranslatedText string  `json:"translatedText"`
+	Confidence     float64 `json:"confidence,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// ErrCircuitOpen is returned when the circuit breaker rejects a call
+var ErrCircuitOpen = errors.New("external translation API circuit breaker is open")
+
+// ExternalAPIError is returned when the external API answers with a status other than 2xx
+type ExternalAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface
+func (e *ExternalAPIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("external API returned %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("external API returned %d", e.StatusCode)
+}
+
+// clientError reports whether the status rejects the request itself rather than signalling
+// that the service is failing or overloaded
+func (e *ExternalAPIError) clientError() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 && e.StatusCode != http.StatusTooManyRequests
+}
+
+// ExternalAPIClientConfig contains the settings of the external API client
+type ExternalAPIClientConfig struct {
+	Endpoint                string
+	APIKey                  string
+	Timeout                 time.Duration
+	MaxRetries              int
+	RetryBackoff            time.Duration
+	MaxRetryBackoff         time.Duration
+	RateLimitPerSecond      float64
+	CircuitBreakerThreshold int
+	CircuitBreakerReset     time.Duration
+}
+
+// ExternalAPIClient calls the external translation API with retries, rate limiting and a circuit breaker
+type ExternalAPIClient struct {
+	config     ExternalAPIClientConfig
+	httpClient *http.Client
+	limiter    *rateLimiter
+	breaker    *circuitBreaker
+}
+
+// NewExternalAPIClient creates a new external API client, filling in defaults for unset values
+func NewExternalAPIClient(config ExternalAPIClientConfig) *ExternalAPIClient {
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 200 * time.Millisecond
+	}
+	if config.MaxRetryBackoff <= 0 {
+		config.MaxRetryBackoff = 5 * time.Second
+	}
+	if config.CircuitBreakerThreshold <= 0 {
+		config.CircuitBreakerThreshold = 5
+	}
+	if config.CircuitBreakerReset <= 0 {
+		config.CircuitBreakerReset = 30 * time.Second
+	}
+
+	return &ExternalAPIClient{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		limiter:    newRateLimiter(config.RateLimitPerSecond),
+		breaker:    newCircuitBreaker(config.CircuitBreakerThreshold, config.CircuitBreakerReset),
+	}
+}
+
+// SetHTTPClient replaces the underlying HTTP client, e.g. to point at a stand-in server
+func (c *ExternalAPIClient) SetHTTPClient(client *http.Client) {
+	c.httpClient = client
+}
+
+// Translate sends a translation request, retrying transient failures
+func (c *ExternalAPIClient) Translate(req ExternalTranslateRequest) (ExternalTranslateResponse, error) {
+	if !c.breaker.allow() {
+		return ExternalTranslateResponse{}, ErrCircuitOpen
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.backoff(attempt))
+		}
+
+		c.limiter.wait()
+
+		resp, retryable, err := c.do(req)
+		if err == nil {
+			c.breaker.recordSuccess()
+			return resp, nil
+		}
+
+		lastErr = err
+		if !retryable {
+			break
+		}
+	}
+
+	// A request the API rejected shows that the service is up, so it neither counts as a
+	// failure nor keeps a half-open circuit from closing
+	var apiErr *ExternalAPIError
+	if errors.As(lastErr, &apiErr) && apiErr.clientError() {
+		c.breaker.recordSuccess()
+	} else {
+		c.breaker.recordFailure()
+	}
+	return ExternalTranslateResponse{}, lastErr
+}
+
+// backoff returns the delay before the given retry attempt
+func (c *ExternalAPIClient) backoff(attempt int) time.Duration {
+	delay := float64(c.config.RetryBackoff) * math.Pow(2, float64(attempt-1))
+	if delay > float64(c.config.MaxRetryBackoff) {
+		return c.config.MaxRetryBackoff
+	}
+	return time.Duration(delay)
+}
+
+// do performs a single HTTP call and reports whether a failure may be retried
+func (c *ExternalAPIClient) do(req ExternalTranslateRequest) (ExternalTranslateResponse, bool, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return ExternalTranslateResponse{}, false, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, c.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return ExternalTranslateResponse{}, false, fmt.Errorf("failed to create request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	if c.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return ExternalTranslateResponse{}, true, fmt.Errorf("request failed: %v", err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, 10<<20))
+	if err != nil {
+		return ExternalTranslateResponse{}, true, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var resp ExternalTranslateResponse
+	decodeErr := json.Unmarshal(data, &resp)
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		apiErr := &ExternalAPIError{StatusCode: httpResp.StatusCode}
+		if decodeErr == nil {
+			apiErr.Message = resp.Error
+		}
+		return ExternalTranslateResponse{}, !apiErr.clientError(), apiErr
+	}
+
+	if decodeErr != nil {
+		return ExternalTranslateResponse{}, false, fmt.Errorf("failed to decode response: %v", decodeErr)
+	}
+	if resp.Error != "" {
+		return ExternalTranslateResponse{}, false, fmt.Errorf("external API error: %s", resp.Error)
+	}
+
+	return resp, false, nil
+}
+
+// rateLimiter spaces out calls so that at most ratePerSecond calls start per second
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter creates a rate limiter; a non-positive rate disables limiting
+func newRateLimiter(ratePerSecond float64) *rateLimiter {
+	limiter := &rateLimiter{}
+	if ratePerSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / ratePerSecond)
+	}
+	return limiter
+}
+
+// wait blocks until the next call is allowed
+func (l *rateLimiter) wait() {
+	if l.interval == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// Circuit breaker states
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker stops calling a failing service until a reset timeout has passed
+type circuitBreaker struct {
+	mu           sync.Mutex
+	state        int
+	failures     int
+	threshold    int
+	resetTimeout time.Duration
+	openedAt     time.Time
+}
+
+// newCircuitBreaker creates a closed circuit breaker
+func newCircuitBreaker(threshold int, resetTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		state:        circuitClosed,
+		threshold:    threshold,
+		resetTimeout: resetTimeout,
+	}
+}
+
+// allow reports whether a call may proceed, moving an expired open circuit to half-open
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.resetTimeout {
+			return false
+		}
+		// Let a single trial call through
+		b.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// A trial call is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+// recordSuccess closes the circuit
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = circuitClosed
+	b.failures = 0
+}
+
+// recordFailure counts a failure and opens the circuit once the threshold is reached
+func (b *circuitBreaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		b.state = circuitOpen
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// standInServer answers the calls of the external API client with the given statuses in turn,
+// repeating the last one, and counts the calls it receives
+func standInServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(atomic.AddInt32(&calls, 1)) - 1
+		if call >= len(statuses) {
+			call = len(statuses) - 1
+		}
+		status := statuses[call]
+
+		var req ExternalTranslateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status >= 200 && status < 300 {
+			json.NewEncoder(w).Encode(ExternalTranslateResponse{TranslatedText: "translated " + req.Text, Confidence: 0.9})
+			return
+		}
+		json.NewEncoder(w).Encode(ExternalTranslateResponse{Error: http.StatusText(status)})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newTestClient(endpoint string, maxRetries, threshold int) *ExternalAPIClient {
+	return NewExternalAPIClient(ExternalAPIClientConfig{
+		Endpoint:                endpoint,
+		MaxRetries:              maxRetries,
+		RetryBackoff:            time.Millisecond,
+		MaxRetryBackoff:         2 * time.Millisecond,
+		CircuitBreakerThreshold: threshold,
+		CircuitBreakerReset:     time.Hour,
+	})
+}
+
+func TestExternalAPIClientRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		wantErr    bool
+		wantStatus int
+		wantCalls  int32
+	}{
+		{name: "success", statuses: []int{200}, maxRetries: 2, wantCalls: 1},
+		{name: "created is a success", statuses: []int{201}, maxRetries: 2, wantCalls: 1},
+		{name: "server error retried", statuses: []int{503, 500, 200}, maxRetries: 2, wantCalls: 3},
+		{name: "rate limit retried", statuses: []int{429, 200}, maxRetries: 2, wantCalls: 2},
+		{name: "retries exhausted", statuses: []int{502}, maxRetries: 2, wantErr: true, wantStatus: 502, wantCalls: 3},
+		{name: "bad request not retried", statuses: []int{400, 200}, maxRetries: 2, wantErr: true, wantStatus: 400, wantCalls: 1},
+		{name: "unauthorized not retried", statuses: []int{401}, maxRetries: 2, wantErr: true, wantStatus: 401, wantCalls: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, calls := standInServer(t, test.statuses...)
+			client := newTestClient(server.URL, test.maxRetries, 10)
+
+			resp, err := client.Translate(ExternalTranslateRequest{Text: "rex", SourceScript: "latin"})
+			if got := atomic.LoadInt32(calls); got != test.wantCalls {
+				t.Errorf("calls = %d, want %d", got, test.wantCalls)
+			}
+			if !test.wantErr {
+				if err != nil {
+					t.Fatalf("Translate() error = %v", err)
+				}
+				if resp.TranslatedText != "translated rex" {
+					t.Errorf("TranslatedText = %q, want %q", resp.TranslatedText, "translated rex")
+				}
+				return
+			}
+
+			var apiErr *ExternalAPIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Translate() error = %v, want an *ExternalAPIError", err)
+			}
+			if apiErr.StatusCode != test.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, test.wantStatus)
+			}
+		})
+	}
+}
+
+func TestExternalAPIClientBackoff(t *testing.T) {
+	client := NewExternalAPIClient(ExternalAPIClientConfig{
+		RetryBackoff:    100 * time.Millisecond,
+		MaxRetryBackoff: time.Second,
+	})
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 100 * time.Millisecond},
+		{attempt: 2, want: 200 * time.Millisecond},
+		{attempt: 3, want: 400 * time.Millisecond},
+		{attempt: 4, want: 800 * time.Millisecond},
+		{attempt: 5, want: time.Second},
+		{attempt: 10, want: time.Second},
+	}
+	for _, test := range tests {
+		if got := client.backoff(test.attempt); got != test.want {
+			t.Errorf("backoff(%d) = %v, want %v", test.attempt, got, test.want)
+		}
+	}
+}
+
+func TestExternalAPIClientCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		requests  int
+		wantOpen  bool
+		wantCalls int32
+	}{
+		{name: "opens after threshold failures", statuses: []int{500}, requests: 4, wantOpen: true, wantCalls: 3},
+		{name: "stays closed below threshold", statuses: []int{500, 500, 200}, requests: 3, wantCalls: 3},
+		{name: "client errors do not open it", statuses: []int{400}, requests: 4, wantCalls: 4},
+		{name: "success resets the failure count", statuses: []int{500, 500, 200, 500, 500}, requests: 5, wantCalls: 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, calls := standInServer(t, test.statuses...)
+			client := newTestClient(server.URL, 0, 3)
+
+			var err error
+			for i := 0; i < test.requests; i++ {
+				_, err = client.Translate(ExternalTranslateRequest{Text: "rex", SourceScript: "latin"})
+			}
+			if got := atomic.LoadInt32(calls); got != test.wantCalls {
+				t.Errorf("calls = %d, want %d", got, test.wantCalls)
+			}
+			if open := errors.Is(err, ErrCircuitOpen); open != test.wantOpen {
+				t.Errorf("last error = %v, want circuit open %v", err, test.wantOpen)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		trialOK   bool
+		wantState int
+	}{
+		{name: "successful trial closes", trialOK: true, wantState: circuitClosed},
+		{name: "failed trial reopens", trialOK: false, wantState: circuitOpen},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			breaker := newCircuitBreaker(1, time.Millisecond)
+			breaker.recordFailure()
+			if breaker.allow() {
+				t.Fatal("allow() = true right after the circuit opened")
+			}
+
+			time.Sleep(2 * time.Millisecond)
+			if !breaker.allow() {
+				t.Fatal("allow() = false after the reset timeout")
+			}
+			if breaker.allow() {
+				t.Fatal("allow() = true while the trial call is in flight")
+			}
+
+			if test.trialOK {
+				breaker.recordSuccess()
+			} else {
+				breaker.recordFailure()
+			}
+			if breaker.state != test.wantState {
+				t.Errorf("state = %d, want %d", breaker.state, test.wantState)
+			}
+		})
+	}
+}
+
+func TestTranslatorFallsBackWhenCircuitOpen(t *testing.T) {
+	server, calls := standInServer(t, http.StatusServiceUnavailable)
+	translator := NewTranslator(TranslationConfig{
+		SupportedScripts:              []string{"latin"},
+		UseExternalAPI:                true,
+		APIEndpoint:                   server.URL,
+		APIRetryBackoffMs:             1,
+		APICircuitBreakerThreshold:    1,
+		APICircuitBreakerResetSeconds: 3600,
+	})
+
+	for i, wantCalls := range []int32{1, 1} {
+		output, err := translator.Translate("rex", "latin", TranslationOptions{})
+		if err != nil {
+			t.Fatalf("Translate() #%d error = %v", i+1, err)
+		}
+		if output.Backend != BackendInternal {
+			t.Errorf("Translate() #%d backend = %q, want %q", i+1, output.Backend, BackendInternal)
+		}
+		if got := atomic.LoadInt32(calls); got != wantCalls {
+			t.Errorf("Translate() #%d calls = %d, want %d", i+1, got, wantCalls)
+		}
+	}
+}
@@ -0,0 +1,450 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"ancient-script-decoder/models"
+)
+
+// ErrGlossaryNotFound is returned when no glossary has the requested ID
+var ErrGlossaryNotFound = errors.New("glossary not found")
+
+// GlossaryStore keeps the per-project glossaries and persists them as a JSON file
+type GlossaryStore struct {
+	mu sync.RWMutex
+	// path is the JSON file the glossaries are persisted to, empty for an in-memory store
+	path       string
+	glossaries map[string]models.Glossary
+	// terms holds the terms of each glossary with their patterns compiled, by glossary ID
+	terms map[string][]glossaryTerm
+}
+
+// NewGlossaryStore creates an empty glossary store persisted to path
+func NewGlossaryStore(path string) *GlossaryStore {
+	return &GlossaryStore{
+		path:       path,
+		glossaries: make(map[string]models.Glossary),
+		terms:      make(map[string][]glossaryTerm),
+	}
+}
+
+// LoadGlossaryStore creates a glossary store from the JSON file at path.
+// A missing file yields an empty store that will be created on the first change.
+func LoadGlossaryStore(path string) (*GlossaryStore, error) {
+	store := NewGlossaryStore(path)
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return store, fmt.Errorf("failed to read glossaries: %v", err)
+	}
+
+	var glossaries []models.Glossary
+	if err := json.Unmarshal(data, &glossaries); err != nil {
+		return store, fmt.Errorf("failed to parse glossaries %s: %v", path, err)
+	}
+	for _, glossary := range glossaries {
+		store.glossaries[glossary.ID] = glossary
+		store.terms[glossary.ID] = compileTerms(glossary)
+	}
+	return store, nil
+}
+
+// List returns the glossaries of a project, or of all projects when project is empty
+func (s *GlossaryStore) List(project string) []models.Glossary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	glossaries := make([]models.Glossary, 0, len(s.glossaries))
+	for _, glossary := range s.glossaries {
+		if project == "" || strings.EqualFold(glossary.Project, project) {
+			glossaries = append(glossaries, glossary)
+		}
+	}
+	sortGlossaries(glossaries)
+	return glossaries
+}
+
+// Get returns the glossary with the given ID
+func (s *GlossaryStore) Get(id string) (models.Glossary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	glossary, ok := s.glossaries[id]
+	if !ok {
+		return models.Glossary{}, ErrGlossaryNotFound
+	}
+	return glossary, nil
+}
+
+// Create validates and stores a new glossary, assigning its ID
+func (s *GlossaryStore) Create(glossary models.Glossary) (models.Glossary, error) {
+	glossary = normalizeGlossary(glossary)
+	if err := validateGlossary(glossary); err != nil {
+		return models.Glossary{}, err
+	}
+
+	id, err := newGlossaryID()
+	if err != nil {
+		return models.Glossary{}, err
+	}
+	now := time.Now().UTC()
+	glossary.ID = id
+	glossary.CreatedAt = now
+	glossary.UpdatedAt = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.glossaries[id] = glossary
+	if err := s.save(); err != nil {
+		delete(s.glossaries, id)
+		return models.Glossary{}, err
+	}
+	s.terms[id] = compileTerms(glossary)
+	return glossary, nil
+}
+
+// Update replaces the glossary with the given ID, keeping its creation time
+func (s *GlossaryStore) Update(id string, glossary models.Glossary) (models.Glossary, error) {
+	glossary = normalizeGlossary(glossary)
+	if err := validateGlossary(glossary); err != nil {
+		return models.Glossary{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.glossaries[id]
+	if !ok {
+		return models.Glossary{}, ErrGlossaryNotFound
+	}
+	glossary.ID = id
+	glossary.CreatedAt = previous.CreatedAt
+	glossary.UpdatedAt = time.Now().UTC()
+
+	s.glossaries[id] = glossary
+	if err := s.save(); err != nil {
+		s.glossaries[id] = previous
+		return models.Glossary{}, err
+	}
+	s.terms[id] = compileTerms(glossary)
+	return glossary, nil
+}
+
+// Delete removes the glossary with the given ID
+func (s *GlossaryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.glossaries[id]
+	if !ok {
+		return ErrGlossaryNotFound
+	}
+
+	delete(s.glossaries, id)
+	if err := s.save(); err != nil {
+		s.glossaries[id] = previous
+		return err
+	}
+	delete(s.terms, id)
+	return nil
+}
+
+// Size returns the number of stored glossaries
+func (s *GlossaryStore) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.glossaries)
+}
+
+// glossaryTerm is a term together with the glossary it came from and the patterns that find
+// its required and forbidden renderings in a translation
+type glossaryTerm struct {
+	models.GlossaryTerm
+	glossaryID string
+	target     termPattern
+	forbidden  []termPattern
+}
+
+// compileTerms compiles the patterns of the terms of a glossary
+func compileTerms(glossary models.Glossary) []glossaryTerm {
+	terms := make([]glossaryTerm, len(glossary.Terms))
+	for i, term := range glossary.Terms {
+		terms[i] = glossaryTerm{GlossaryTerm: term, glossaryID: glossary.ID, target: newTermPattern(term.Target)}
+		for _, forbidden := range term.Forbidden {
+			terms[i].forbidden = append(terms[i].forbidden, newTermPattern(forbidden))
+		}
+	}
+	return terms
+}
+
+// termPattern matches a term as a whole word or phrase, in the casing of the term or in any casing
+type termPattern struct {
+	exact   *regexp.Regexp
+	anyCase *regexp.Regexp
+}
+
+// newTermPattern compiles the patterns of a term; an empty term matches nothing
+func newTermPattern(term string) termPattern {
+	if term == "" {
+		return termPattern{}
+	}
+	pattern := `(^|[^\pL\pN])(` + regexp.QuoteMeta(term) + `)($|[^\pL\pN])`
+	return termPattern{
+		exact:   regexp.MustCompile(pattern),
+		anyCase: regexp.MustCompile("(?i)" + pattern),
+	}
+}
+
+// find locates the term in a text, returning its byte offsets or nil
+func (p termPattern) find(text string, ignoreCase bool) []int {
+	re := p.exact
+	if ignoreCase {
+		re = p.anyCase
+	}
+	if re == nil {
+		return nil
+	}
+	loc := re.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return nil
+	}
+	return loc[4:6]
+}
+
+// termsFor collects the terms of a project's glossaries that apply to the script and target language
+func (s *GlossaryStore) termsFor(project, scriptType, targetLanguage string) []glossaryTerm {
+	if project == "" {
+		return nil
+	}
+
+	glossaries := s.List(project)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var terms []glossaryTerm
+	for _, glossary := range glossaries {
+		if glossary.ScriptType != "" && glossary.ScriptType != scriptType {
+			continue
+		}
+		if glossary.TargetLanguage != "" && glossary.TargetLanguage != targetLanguage {
+			continue
+		}
+		terms = append(terms, s.terms[glossary.ID]...)
+	}
+	return terms
+}
+
+// plainTerms strips the glossary IDs from terms
+func plainTerms(terms []glossaryTerm) []models.GlossaryTerm {
+	plain := make([]models.GlossaryTerm, len(terms))
+	for i, term := range terms {
+		plain[i] = term.GlossaryTerm
+	}
+	return plain
+}
+
+// save writes the glossaries to their JSON file; the caller must hold the write lock
+func (s *GlossaryStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	glossaries := make([]models.Glossary, 0, len(s.glossaries))
+	for _, glossary := range s.glossaries {
+		glossaries = append(glossaries, glossary)
+	}
+	sortGlossaries(glossaries)
+
+	data, err := json.MarshalIndent(glossaries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode glossaries: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to save glossaries: %v", err)
+	}
+
+	// Write to a temporary file first so a failed save does not truncate the store
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save glossaries: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to save glossaries: %v", err)
+	}
+	return nil
+}
+
+// sortGlossaries orders glossaries by project and name
+func sortGlossaries(glossaries []models.Glossary) {
+	sort.SliceStable(glossaries, func(i, j int) bool {
+		if glossaries[i].Project != glossaries[j].Project {
+			return glossaries[i].Project < glossaries[j].Project
+		}
+		if glossaries[i].Name != glossaries[j].Name {
+			return glossaries[i].Name < glossaries[j].Name
+		}
+		return glossaries[i].ID < glossaries[j].ID
+	})
+}
+
+// normalizeGlossary trims the glossary fields and lowercases its script and language
+func normalizeGlossary(glossary models.Glossary) models.Glossary {
+	glossary.Project = strings.TrimSpace(glossary.Project)
+	glossary.Name = strings.TrimSpace(glossary.Name)
+	glossary.ScriptType = strings.ToLower(strings.TrimSpace(glossary.ScriptType))
+	glossary.TargetLanguage = strings.ToLower(strings.TrimSpace(glossary.TargetLanguage))
+
+	terms := make([]models.GlossaryTerm, len(glossary.Terms))
+	for i, term := range glossary.Terms {
+		term.Source = strings.TrimSpace(term.Source)
+		term.Target = strings.TrimSpace(term.Target)
+		terms[i] = term
+	}
+	glossary.Terms = terms
+	return glossary
+}
+
+// validateGlossary checks that a glossary names its project and that every term is complete
+func validateGlossary(glossary models.Glossary) error {
+	if glossary.Project == "" {
+		return fmt.Errorf("glossary has no project")
+	}
+	if glossary.Name == "" {
+		return fmt.Errorf("glossary has no name")
+	}
+	for i, term := range glossary.Terms {
+		if term.Source == "" || term.Target == "" {
+			return fmt.Errorf("glossary term %d needs both source and target", i+1)
+		}
+	}
+	return nil
+}
+
+// newGlossaryID generates a random glossary identifier
+func newGlossaryID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate glossary ID: %v", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// glossaryLexicon builds a lexicon of the terms whose lookups fall back to base.
+// Glossing with it renders every term as its required target.
+func glossaryLexicon(terms []models.GlossaryTerm, scriptType string, base *Lexicon) *Lexicon {
+	lexicon := newLexicon(scriptType)
+	for _, term := range terms {
+		lexicon.Add(LexiconEntry{Headword: term.Source, Gloss: term.Target, PartOfSpeech: "term"})
+	}
+	if base != nil {
+		lexicon.fallback = base
+		lexicon.maxPhraseLen = max(lexicon.maxPhraseLen, base.maxPhraseLen)
+	}
+	return lexicon
+}
+
+// enforceGlossary checks that the translation renders every term found in the source as required.
+// Wrong casing and forbidden renderings are replaced, adjusting the alignment target spans;
+// the violations found are reported whether or not they could be fixed.
+func enforceGlossary(source, translated string, alignment []models.AlignmentPair, terms []glossaryTerm, scriptType string) (string, []models.AlignmentPair, []models.GlossaryViolation) {
+	if len(terms) == 0 {
+		return translated, alignment, nil
+	}
+
+	byHeadword := make(map[string]glossaryTerm, len(terms))
+	for _, term := range terms {
+		byHeadword[term.Source] = term
+	}
+
+	var violations []models.GlossaryViolation
+	seen := make(map[string]bool)
+	for _, token := range glossText(source, glossaryLexicon(plainTerms(terms), scriptType, nil)) {
+		term, ok := byHeadword[token.Lemma]
+		if !token.Known || !ok || seen[term.Source] {
+			continue
+		}
+		seen[term.Source] = true
+
+		if term.target.find(translated, false) != nil {
+			continue
+		}
+
+		violation := models.GlossaryViolation{
+			GlossaryID: term.glossaryID,
+			Source:     term.Source,
+			Expected:   term.Target,
+		}
+
+		// Fix the casing of the required rendering, or replace a forbidden one
+		loc := term.target.find(translated, true)
+		for _, forbidden := range term.forbidden {
+			if loc != nil {
+				break
+			}
+			loc = forbidden.find(translated, true)
+		}
+		if loc != nil {
+			violation.Found = translated[loc[0]:loc[1]]
+			violation.Fixed = true
+			translated, alignment = replaceTargetSpan(translated, alignment, loc[0], loc[1], term.Target)
+		}
+		violations = append(violations, violation)
+	}
+
+	return translated, alignment, violations
+}
+
+// replaceTargetSpan replaces text[start:end] and moves the alignment target spans to match
+func replaceTargetSpan(text string, alignment []models.AlignmentPair, start, end int, replacement string) (string, []models.AlignmentPair) {
+	runeStart := utf8.RuneCountInString(text[:start])
+	runeEnd := runeStart + utf8.RuneCountInString(text[start:end])
+	replacementEnd := runeStart + utf8.RuneCountInString(replacement)
+	delta := replacementEnd - runeEnd
+
+	edited := text[:start] + replacement + text[end:]
+	editedRunes := []rune(edited)
+
+	// Offsets after the replaced text move with its end; offsets within it are kept within
+	// the replacement, so spans covering several words of a shorter replacement shrink to
+	// what is left of them, possibly nothing
+	move := func(offset int) int {
+		if offset >= runeEnd {
+			return offset + delta
+		}
+		return min(offset, replacementEnd)
+	}
+
+	adjusted := make([]models.AlignmentPair, len(alignment))
+	for i, pair := range alignment {
+		target := pair.Target
+		overlaps := target.End > runeStart && target.Start < runeEnd
+		target.Start, target.End = move(target.Start), move(target.End)
+		if overlaps {
+			target.Text = string(editedRunes[target.Start:target.End])
+		}
+		pair.Target = target
+		adjusted[i] = pair
+	}
+
+	return edited, adjusted
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"ancient-script-decoder/models"
+)
+
+// targetSpans builds alignment pairs with the given target spans of text, counted in runes
+func targetSpans(text string, spans ...[2]int) []models.AlignmentPair {
+	runes := []rune(text)
+	pairs := make([]models.AlignmentPair, len(spans))
+	for i, span := range spans {
+		pairs[i].Target = models.TextSpan{Start: span[0], End: span[1], Text: string(runes[span[0]:span[1]])}
+	}
+	return pairs
+}
+
+// span builds a target span
+func span(start, end int, text string) models.TextSpan {
+	return models.TextSpan{Start: start, End: end, Text: text}
+}
+
+func TestReplaceTargetSpan(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		spans       [][2]int
+		phrase      string
+		replacement string
+		want        string
+		wantSpans   []models.TextSpan
+	}{
+		{
+			name:        "same length",
+			text:        "the commander spoke",
+			spans:       [][2]int{{0, 3}, {4, 13}, {14, 19}},
+			phrase:      "commander",
+			replacement: "Imperator",
+			want:        "the Imperator spoke",
+			wantSpans:   []models.TextSpan{span(0, 3, "the"), span(4, 13, "Imperator"), span(14, 19, "spoke")},
+		},
+		{
+			name:        "longer replacement moves later spans",
+			text:        "the general spoke",
+			spans:       [][2]int{{0, 3}, {4, 11}, {12, 17}},
+			phrase:      "general",
+			replacement: "Imperator",
+			want:        "the Imperator spoke",
+			wantSpans:   []models.TextSpan{span(0, 3, "the"), span(4, 13, "Imperator"), span(14, 19, "spoke")},
+		},
+		{
+			name:        "phrase over several spans at the end",
+			text:        "great king",
+			spans:       [][2]int{{0, 5}, {6, 10}},
+			phrase:      "great king",
+			replacement: "shah",
+			want:        "shah",
+			wantSpans:   []models.TextSpan{span(0, 4, "shah"), span(4, 4, "")},
+		},
+		{
+			name:        "phrase over several spans in the middle",
+			text:        "the great king spoke",
+			spans:       [][2]int{{0, 3}, {4, 9}, {10, 14}, {15, 20}},
+			phrase:      "great king",
+			replacement: "shah",
+			want:        "the shah spoke",
+			wantSpans:   []models.TextSpan{span(0, 3, "the"), span(4, 8, "shah"), span(8, 8, ""), span(9, 14, "spoke")},
+		},
+		{
+			name:        "multibyte text",
+			text:        "ὁ στρατηγός εἶπε",
+			spans:       [][2]int{{0, 1}, {2, 11}, {12, 16}},
+			phrase:      "στρατηγός",
+			replacement: "Imperator",
+			want:        "ὁ Imperator εἶπε",
+			wantSpans:   []models.TextSpan{span(0, 1, "ὁ"), span(2, 11, "Imperator"), span(12, 16, "εἶπε")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := strings.Index(test.text, test.phrase)
+			got, alignment := replaceTargetSpan(test.text, targetSpans(test.text, test.spans...), start, start+len(test.phrase), test.replacement)
+			if got != test.want {
+				t.Errorf("text = %q, want %q", got, test.want)
+			}
+			spans := make([]models.TextSpan, len(alignment))
+			for i, pair := range alignment {
+				spans[i] = pair.Target
+			}
+			if !reflect.DeepEqual(spans, test.wantSpans) {
+				t.Errorf("spans = %v, want %v", spans, test.wantSpans)
+			}
+		})
+	}
+}
+
+func TestTermPatternFind(t *testing.T) {
+	tests := []struct {
+		name       string
+		term       string
+		text       string
+		ignoreCase bool
+		want       []int
+	}{
+		{name: "whole word", term: "rex", text: "the rex spoke", want: []int{4, 7}},
+		{name: "not inside a word", term: "rex", text: "the rexes spoke"},
+		{name: "casing must match", term: "Imperator", text: "the imperator spoke"},
+		{name: "any casing", term: "Imperator", text: "the imperator spoke", ignoreCase: true, want: []int{4, 13}},
+		{name: "phrase", term: "great king", text: "a great king", want: []int{2, 12}},
+		{name: "empty term", term: "", text: "anything"},
+		{name: "metacharacters are literal", term: "a.b", text: "axb a.b", want: []int{4, 7}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := newTermPattern(test.term).find(test.text, test.ignoreCase); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("find(%q) = %v, want %v", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestGlossaryStoreCompilesTerms(t *testing.T) {
+	store := NewGlossaryStore("")
+	created, err := store.Create(models.Glossary{
+		Project: "rome",
+		Name:    "titles",
+		Terms:   []models.GlossaryTerm{{Source: "imperator", Target: "Imperator", Forbidden: []string{"commander"}}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	terms := store.termsFor("rome", "latin", "en")
+	if len(terms) != 1 || terms[0].target.exact == nil || len(terms[0].forbidden) != 1 {
+		t.Fatalf("termsFor() after Create = %+v, want one compiled term", terms)
+	}
+
+	if _, err := store.Update(created.ID, models.Glossary{
+		Project: "rome",
+		Name:    "titles",
+		Terms:   []models.GlossaryTerm{{Source: "rex", Target: "king"}},
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	terms = store.termsFor("rome", "latin", "en")
+	if len(terms) != 1 || terms[0].target.find("the king", false) == nil {
+		t.Fatalf("termsFor() after Update = %+v, want the updated term", terms)
+	}
+
+	if err := store.Delete(created.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if terms := store.termsFor("rome", "latin", "en"); len(terms) != 0 {
+		t.Errorf("termsFor() after Delete = %+v, want none", terms)
+	}
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"ancient-script-decoder/models"
+)
+
+// Confidences of the ways a source word can be matched to a gloss
+const (
+	glossConfidenceExact     = 1.0
+	glossConfidenceInflected = 0.8
+	glossConfidenceEnclitic  = 0.7
+)
+
+// glossAlternativeDiscount scales the confidence of every reading but the preferred one,
+// so that the preferred gloss of a segment always ranks first
+const glossAlternativeDiscount = 0.9
+
+// GlossToken is one unit of a gloss-style translation. It covers a single source
+// word, or several words when a lexicon phrase matched.
+type GlossToken struct {
+	Source string `json:"source"`
+	Lemma  string `json:"lemma,omitempty"`
+	Gloss  string `json:"gloss,omitempty"`
+	Known  bool   `json:"known"`
+	// Start and End are byte offsets of the source span in the input text
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Trailing keeps punctuation that followed the source span
+	Trailing string `json:"trailing,omitempty"`
+	// Confidence is how directly the gloss was matched, 0 for unknown words
+	Confidence float64 `json:"confidence"`
+}
+
+// sourceWord is a word of the input text with its punctuation split off
+type sourceWord struct {
+	text       string
+	normalized string
+	start, end int
+	trailing   string
+}
+
+// tokenizeSource splits text into words, recording byte offsets and trailing punctuation
+func tokenizeSource(text, script string) []sourceWord {
+	var words []sourceWord
+
+	i := 0
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		// Collect the whole whitespace-delimited field
+		start := i
+		for i < len(text) {
+			r, size = utf8.DecodeRuneInString(text[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+		field := text[start:i]
+
+		// Split off leading and trailing punctuation
+		core := strings.TrimLeftFunc(field, unicode.IsPunct)
+		coreStart := start + len(field) - len(core)
+		trimmed := strings.TrimRightFunc(core, unicode.IsPunct)
+		if trimmed == "" {
+			// Pure punctuation attaches to the previous word
+			if len(words) > 0 {
+				words[len(words)-1].trailing += field
+			}
+			continue
+		}
+
+		words = append(words, sourceWord{
+			text:       trimmed,
+			normalized: normalizeToken(trimmed, script),
+			start:      coreStart,
+			end:        coreStart + len(trimmed),
+			trailing:   core[len(trimmed):],
+		})
+	}
+
+	return words
+}
+
+// glossText produces a word-by-word gloss of text using the lexicon, preferring the longest phrase match
+func glossText(text string, lexicon *Lexicon) []GlossToken {
+	var tokens []GlossToken
+	for _, readings := range glossSegments(text, lexicon) {
+		tokens = append(tokens, readings[0].tokens...)
+	}
+	return tokens
+}
+
+// glossReading is one way of glossing a stretch of the source text
+type glossReading struct {
+	tokens []GlossToken
+	// score sums the token confidences, each weighted by the number of source words it covers
+	score float64
+	// words is the number of source words the reading covers
+	words int
+}
+
+// glossSegments splits text into the segments glossText translates one at a time: lexicon
+// phrases and single words. Each segment lists its readings, the one glossText uses first.
+// A phrase can also be read word by word, and a word by any other analysis the lemmatizer finds.
+func glossSegments(text string, lexicon *Lexicon) [][]glossReading {
+	words := tokenizeSource(text, lexicon.Script)
+	segments := make([][]glossReading, 0, len(words))
+
+	for i := 0; i < len(words); {
+		// Try the longest phrase first
+		matched := false
+		for n := min(lexicon.maxPhraseLen, len(words)-i); n > 1; n-- {
+			forms := make([]string, n)
+			for k := 0; k < n; k++ {
+				forms[k] = words[i+k].normalized
+			}
+			entry, ok := lexicon.LookupPhrase(forms)
+			if !ok {
+				continue
+			}
+
+			last := words[i+n-1]
+			phrase := glossReading{
+				tokens: []GlossToken{{
+					Source:     text[words[i].start:last.end],
+					Lemma:      entry.Headword,
+					Gloss:      entry.Gloss,
+					Known:      true,
+					Start:      words[i].start,
+					End:        last.end,
+					Trailing:   last.trailing,
+					Confidence: glossConfidenceExact,
+				}},
+				score: glossConfidenceExact * float64(n),
+				words: n,
+			}
+
+			// The same words may also be meant literally, unless none of them is known
+			literal := glossReading{words: n}
+			for k := 0; k < n; k++ {
+				token := glossWord(words[i+k], lexicon)[0]
+				token.Confidence *= glossAlternativeDiscount
+				literal.tokens = append(literal.tokens, token)
+				literal.score += token.Confidence
+			}
+
+			if literal.score > 0 {
+				segments = append(segments, []glossReading{phrase, literal})
+			} else {
+				segments = append(segments, []glossReading{phrase})
+			}
+			i += n
+			matched = true
+			break
+		}
+		if matched {
+			continue
+		}
+
+		tokens := glossWord(words[i], lexicon)
+		readings := make([]glossReading, len(tokens))
+		for k, token := range tokens {
+			if k > 0 {
+				token.Confidence *= glossAlternativeDiscount
+			}
+			readings[k] = glossReading{tokens: []GlossToken{token}, score: token.Confidence, words: 1}
+		}
+		segments = append(segments, readings)
+		i++
+	}
+
+	return segments
+}
+
+// glossWord returns the possible glosses of a single word, preferred first.
+// A word missing from the lexicon yields one unknown token.
+func glossWord(word sourceWord, lexicon *Lexicon) []GlossToken {
+	token := GlossToken{
+		Source:   word.text,
+		Start:    word.start,
+		End:      word.end,
+		Trailing: word.trailing,
+	}
+	if value, ok := parseRomanNumeral(word.text); ok && lexicon.Script == "latin" {
+		token.Known = true
+		token.Gloss = strconv.Itoa(value)
+		token.Confidence = glossConfidenceExact
+		return []GlossToken{token}
+	}
+
+	analyses := lemmatizeAll(word.normalized, lexicon)
+	if len(analyses) == 0 {
+		return []GlossToken{token}
+	}
+
+	tokens := make([]GlossToken, 0, len(analyses))
+	for _, analysis := range analyses {
+		token.Known = true
+		token.Lemma = analysis.entry.Headword
+		token.Gloss = analysis.entry.Gloss
+		switch {
+		case analysis.enclitic != "":
+			token.Gloss += "-" + analysis.enclitic
+			token.Confidence = glossConfidenceEnclitic
+		case normalizeToken(analysis.entry.Headword, lexicon.Script) != word.normalized:
+			token.Confidence = glossConfidenceInflected
+		default:
+			token.Confidence = glossConfidenceExact
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// glossCandidates returns up to n distinct glosses of text, best first, the first being the
+// one glossText produces. Scores are the mean confidence over the source words, so that
+// a phrase counts as much as the words it covers.
+func glossCandidates(text string, lexicon *Lexicon, n int) []glossReading {
+	n = max(n, 1)
+	beam := []glossReading{{}}
+	words := 0
+
+	// Segments are glossed independently, so keeping the n best prefixes after each one is exact
+	for _, readings := range glossSegments(text, lexicon) {
+		words += readings[0].words
+
+		next := make([]glossReading, 0, len(beam)*len(readings))
+		for _, prefix := range beam {
+			for _, reading := range readings {
+				tokens := make([]GlossToken, 0, len(prefix.tokens)+len(reading.tokens))
+				tokens = append(append(tokens, prefix.tokens...), reading.tokens...)
+				next = append(next, glossReading{tokens: tokens, score: prefix.score + reading.score})
+			}
+		}
+		sort.SliceStable(next, func(i, j int) bool {
+			return next[i].score > next[j].score
+		})
+
+		// Different analyses can share a gloss; keep the best-scoring prefix of each rendering
+		beam = beam[:0]
+		seen := make(map[string]bool)
+		for _, prefix := range next {
+			key := glossKey(prefix.tokens)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			beam = append(beam, prefix)
+			if len(beam) == n {
+				break
+			}
+		}
+	}
+
+	if words > 0 {
+		for i := range beam {
+			beam[i].score /= float64(words)
+		}
+	}
+	return beam
+}
+
+// glossKey identifies the rendering of a token sequence
+func glossKey(tokens []GlossToken) string {
+	parts := make([]string, len(tokens))
+	for i, token := range tokens {
+		parts[i] = token.Gloss
+		if !token.Known {
+			parts[i] = "?" + token.Source
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// alignGloss joins the gloss tokens of source into text, flagging unknown words as [?word],
+// and pairs each token's source span with the span of its rendering. Offsets count code points.
+func alignGloss(source string, tokens []GlossToken) (string, []models.AlignmentPair) {
+	var b strings.Builder
+	alignment := make([]models.AlignmentPair, 0, len(tokens))
+
+	targetPos := 0
+	for i, token := range tokens {
+		if i > 0 {
+			b.WriteString(" ")
+			targetPos++
+		}
+
+		rendered := token.Gloss
+		if !token.Known {
+			rendered = "[?" + token.Source + "]"
+		}
+		b.WriteString(rendered)
+		b.WriteString(token.Trailing)
+
+		targetLen := utf8.RuneCountInString(rendered)
+		sourceStart := utf8.RuneCountInString(source[:token.Start])
+		alignment = append(alignment, models.AlignmentPair{
+			Source: models.TextSpan{
+				Start: sourceStart,
+				End:   sourceStart + utf8.RuneCountInString(token.Source),
+				Text:  token.Source,
+			},
+			Target: models.TextSpan{
+				Start: targetPos,
+				End:   targetPos + targetLen,
+				Text:  rendered,
+			},
+			Confidence: token.Confidence,
+		})
+		targetPos += targetLen + utf8.RuneCountInString(token.Trailing)
+	}
+
+	return b.String(), alignment
+}
+
+// parseRomanNumeral parses an upper-case Roman numeral such as XXIV.
+// Only canonically written numerals are accepted, so capitalised words like DIVI are not numbers.
+func parseRomanNumeral(word string) (int, bool) {
+	values := map[rune]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100, 'D': 500, 'M': 1000}
+
+	total, prev := 0, 0
+	runes := []rune(word)
+	for i := len(runes) - 1; i >= 0; i-- {
+		value, ok := values[runes[i]]
+		if !ok {
+			return 0, false
+		}
+		if value < prev {
+			total -= value
+		} else {
+			total += value
+			prev = value
+		}
+	}
+	if total <= 0 || formatRomanNumeral(total) != word {
+		return 0, false
+	}
+	return total, true
+}
+
+// formatRomanNumeral writes a positive integer as a canonical Roman numeral
+func formatRomanNumeral(value int) string {
+	numerals := []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"},
+		{100, "C"}, {90, "XC"}, {50, "L"}, {40, "XL"},
+		{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	}
+
+	var b strings.Builder
+	for _, numeral := range numerals {
+		for value >= numeral.value {
+			b.WriteString(numeral.symbol)
+			value -= numeral.value
+		}
+	}
+	return b.String()
+}
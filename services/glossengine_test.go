@@ -0,0 +1,139 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"ancient-script-decoder/models"
+)
+
+// glossLatin is the lexicon the Latin gloss tests translate with
+var glossLatin = lexiconOf("latin",
+	"senatus", "senate",
+	"populus", "people",
+	"Romanus", "Roman",
+	"puella", "girl",
+	"canto", "sing",
+	"pax", "peace",
+	"deus", "god",
+	"pax deorum", "divine favour",
+	"rex", "king",
+)
+
+func TestGlossText(t *testing.T) {
+	tests := []struct {
+		name            string
+		text            string
+		want            string
+		wantConfidences []float64
+	}{
+		{
+			name:            "headwords and an enclitic",
+			text:            "Senatus populusque Romanus",
+			want:            "senate people-and Roman",
+			wantConfidences: []float64{glossConfidenceExact, glossConfidenceEnclitic, glossConfidenceExact},
+		},
+		{
+			name:            "inflected form and an unknown word",
+			text:            "puella xyzzy cantat.",
+			want:            "girl [?xyzzy] sing.",
+			wantConfidences: []float64{glossConfidenceExact, 0, glossConfidenceInflected},
+		},
+		{
+			name:            "phrase",
+			text:            "pax deorum",
+			want:            "divine favour",
+			wantConfidences: []float64{glossConfidenceExact},
+		},
+		{
+			name:            "Roman numeral and punctuation after a word",
+			text:            "rex, XXIV.",
+			want:            "king, 24.",
+			wantConfidences: []float64{glossConfidenceExact, glossConfidenceExact},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens := glossText(test.text, glossLatin)
+			text, _ := alignGloss(test.text, tokens)
+			if text != test.want {
+				t.Errorf("glossText() = %q, want %q", text, test.want)
+			}
+			var confidences []float64
+			for _, token := range tokens {
+				confidences = append(confidences, token.Confidence)
+			}
+			if !reflect.DeepEqual(confidences, test.wantConfidences) {
+				t.Errorf("glossText() confidences = %v, want %v", confidences, test.wantConfidences)
+			}
+		})
+	}
+}
+
+func TestGlossGreekAlignment(t *testing.T) {
+	greek := lexiconOf("greek", "λόγος", "word", "ἄνθρωπος", "man")
+	text := "λόγος ἀνθρώπου"
+
+	translated, alignment := alignGloss(text, glossText(text, greek))
+	if translated != "word man" {
+		t.Errorf("alignGloss() = %q, want %q", translated, "word man")
+	}
+	want := []models.AlignmentPair{
+		{
+			Source:     models.TextSpan{Start: 0, End: 5, Text: "λόγος"},
+			Target:     models.TextSpan{Start: 0, End: 4, Text: "word"},
+			Confidence: glossConfidenceExact,
+		},
+		{
+			Source:     models.TextSpan{Start: 6, End: 14, Text: "ἀνθρώπου"},
+			Target:     models.TextSpan{Start: 5, End: 8, Text: "man"},
+			Confidence: glossConfidenceInflected,
+		},
+	}
+	if !reflect.DeepEqual(alignment, want) {
+		t.Errorf("alignGloss() alignment = %+v, want %+v", alignment, want)
+	}
+}
+
+func TestGlossCandidates(t *testing.T) {
+	candidates := glossCandidates("pax deorum", glossLatin, 3)
+
+	var got []string
+	var scores []float64
+	for _, candidate := range candidates {
+		text, _ := alignGloss("pax deorum", candidate.tokens)
+		got = append(got, text)
+		scores = append(scores, candidate.score)
+	}
+	// The phrase can also be read literally, each word discounted as an alternative
+	if want := []string{"divine favour", "peace god"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("glossCandidates() = %q, want %q", got, want)
+	}
+	wantLiteral := (glossConfidenceExact + glossConfidenceInflected) * glossAlternativeDiscount / 2
+	if scores[0] != glossConfidenceExact || scores[1] < wantLiteral-1e-9 || scores[1] > wantLiteral+1e-9 {
+		t.Errorf("glossCandidates() scores = %v, want %v and %v", scores, glossConfidenceExact, wantLiteral)
+	}
+}
+
+func TestParseRomanNumeral(t *testing.T) {
+	tests := []struct {
+		word   string
+		want   int
+		wantOK bool
+	}{
+		{word: "XXIV", want: 24, wantOK: true},
+		{word: "MCMXC", want: 1990, wantOK: true},
+		{word: "IV", want: 4, wantOK: true},
+		{word: "IIII"},
+		{word: "DIVI"},
+		{word: "iv"},
+		{word: ""},
+	}
+
+	for _, test := range tests {
+		if got, ok := parseRomanNumeral(test.word); got != test.want || ok != test.wantOK {
+			t.Errorf("parseRomanNumeral(%q) = %d, %v, want %d, %v", test.word, got, ok, test.want, test.wantOK)
+		}
+	}
+}
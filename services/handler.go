@@ -1,7 +1,16 @@
 package services
 
 import (
+        "bytes"
+        "fmt"
+        "image"
+        "image/png"
+        "io"
+        "net/http"
+        "time"
+
         "ancient-script-decoder/models"
+        "ancient-script-decoder/services/transliteration"
         "ancient-script-decoder/utils"
 )
 
@@ -26,32 +35,493 @@ func NewServiceHandler(imageProcessor *ImageProcessor, translator *Translator, s
 }
 
 // ProcessAndTranslate processes an image and translates the extracted text
-func (h *ServiceHandler) ProcessAndTranslate(imageData []byte, scriptType string) (string, error) {
-        // Process the image
-        h.logger.Info("Processing manuscript image")
-        processedImage, err := h.imageProcessor.ProcessImage(imageData)
+// The image is preprocessed with the pipeline of the options, the default one if they give none.
+// The result reports the detected script when scriptType is "auto", ranked by the look of the writing
+func (h *ServiceHandler) ProcessAndTranslate(imageData []byte, scriptType string, options TranslationOptions) (models.TranslationResult, error) {
+        steps, err := h.pipelineSteps(options.Pipeline, options.PipelineSteps)
         if err != nil {
-                h.logger.Error("Failed to process image", "error", err)
-                return "", err
+                return models.TranslationResult{}, err
         }
 
-        // Extract text from the processed image
-        h.logger.Info("Extracting text from processed image", "scriptType", scriptType)
-        extractedText, err := h.imageProcessor.ExtractTextFromImage(processedImage, scriptType)
+        // Extract text from the uploaded image. The OCR runs it through the pipeline without edge
+        // detection, whose outlines lack the filled strokes the glyph templates describe
+        h.logger.Info("Extracting text from manuscript image", "scriptType", scriptType, "pipeline", options.Pipeline, "steps", len(steps))
+        extraction, err := h.imageProcessor.ExtractTextFromImage(imageData, scriptType, options.ReadingDirection, steps)
         if err != nil {
                 h.logger.Error("Failed to extract text", "error", err)
-                return "", err
+                return models.TranslationResult{}, err
+        }
+        h.logger.Info("Recognized text", "script", extraction.Script, "lines", len(extraction.Lines), "glyphs", len(extraction.Glyphs), "confidence", extraction.Confidence(), "rotation", extraction.Orientation.Rotation)
+        reviews := h.queueUncertainGlyphs(imageData, extraction)
+
+        // The text is translated in the script it was read in, unless that script cannot be translated
+        translateScript := scriptType
+        if extraction.ScriptCandidates != nil {
+                h.logger.Info("Detected script from image", "scriptType", extraction.Script, "candidates", len(extraction.ScriptCandidates))
+                if h.translator.isScriptSupported(extraction.Script) {
+                        translateScript = extraction.Script
+                }
         }
 
         // Translate the extracted text
-        h.logger.Info("Translating extracted text", "scriptType", scriptType)
-        translatedText, err := h.translator.TranslateText(extractedText, scriptType)
+        h.logger.Info("Translating extracted text", "scriptType", translateScript, "targetLanguage", options.TargetLanguage, "project", options.Project, "candidates", options.Candidates)
+        output, err := h.translator.Translate(extraction.Text, translateScript, options)
         if err != nil {
                 h.logger.Error("Failed to translate text", "error", err)
-                return "", err
+                return models.TranslationResult{}, err
+        }
+        if translateScript == "auto" {
+                h.logger.Info("Detected script", "scriptType", output.ScriptType, "candidates", len(output.ScriptCandidates))
+        }
+
+        return models.TranslationResult{
+                OriginalScript:     output.ScriptType,
+                ScriptCandidates:   output.ScriptCandidates,
+                TargetLanguage:     output.TargetLanguage,
+                SourceText:         output.SourceText,
+                SourceTokens:       output.SourceTokens,
+                TranslatedText:     output.Text,
+                Alignment:          output.Alignment,
+                MemoryMatches:      convertMemoryMatches(output.MemoryMatches),
+                GlossaryViolations: output.GlossaryViolations,
+                Candidates:         output.Candidates,
+                Layout:             h.pageLayout(extraction, options.ReadingDirection, reviews),
+                ImageScripts:       extraction.ScriptCandidates,
+                OCRConfidence:      extraction.Confidence(),
+                TranslatedAt:       time.Now(),
+        }, nil
+}
+
+// RecognizeImage reads the text of a manuscript image with its layout, without translating it
+// The image is preprocessed with the steps given, else those of the named preset, else the
+// default pipeline. The result reports the detected script when scriptType is "auto"
+func (h *ServiceHandler) RecognizeImage(imageData []byte, scriptType string, direction utils.ReadingDirection, pipeline string, steps []utils.PipelineStep) (models.OCRResult, error) {
+        steps, err := h.pipelineSteps(pipeline, steps)
+        if err != nil {
+                return models.OCRResult{}, err
         }
 
-        return translatedText, nil
+        h.logger.Info("Extracting text from manuscript image", "scriptType", scriptType, "pipeline", pipeline, "steps", len(steps))
+        extraction, err := h.imageProcessor.ExtractTextFromImage(imageData, scriptType, direction, steps)
+        if err != nil {
+                h.logger.Error("Failed to extract text", "error", err)
+                return models.OCRResult{}, err
+        }
+        h.logger.Info("Recognized text", "script", extraction.Script, "lines", len(extraction.Lines), "glyphs", len(extraction.Glyphs), "confidence", extraction.Confidence(), "rotation", extraction.Orientation.Rotation)
+        reviews := h.queueUncertainGlyphs(imageData, extraction)
+
+        return models.OCRResult{
+                Script:       extraction.Script,
+                ImageScripts: extraction.ScriptCandidates,
+                Text:         extraction.Text,
+                Confidence:   extraction.Confidence(),
+                Layout:       h.pageLayout(extraction, direction, reviews),
+        }, nil
+}
+
+// ExportOCR writes the text read from an image with its layout as an hOCR or ALTO document
+func (h *ServiceHandler) ExportOCR(w io.Writer, format string, doc OCRDocument) error {
+        h.logger.Info("Exporting OCR", "format", format, "scriptType", doc.Script, "image", doc.Image)
+        switch format {
+        case OCRFormatHOCR:
+                return WriteHOCR(w, doc)
+        case OCRFormatALTO:
+                return WriteALTO(w, doc)
+        }
+        return fmt.Errorf("unsupported OCR format %q", format)
+}
+
+// queueUncertainGlyphs queues the glyphs read with low confidence for review and returns the IDs
+// of their review items; a failure to queue them is logged and does not fail the request
+func (h *ServiceHandler) queueUncertainGlyphs(imageData []byte, extraction ImageText) []string {
+        reviews, err := h.imageProcessor.QueueUncertainGlyphs(imageData, extraction)
+        if err != nil {
+                h.logger.Warning("Failed to queue uncertain glyphs for review", "error", err)
+                return nil
+        }
+        return reviews
+}
+
+// pageLayout converts the lines and glyphs the OCR found to their API representation, with
+// the review queue items of the uncertain glyphs given in the order of the glyphs and the
+// turn that brought the image upright
+func (h *ServiceHandler) pageLayout(extraction ImageText, direction utils.ReadingDirection, reviews []string) *models.PageLayout {
+        if direction == "" {
+                direction = h.imageProcessor.ReadingDirection()
+        }
+        layout := &models.PageLayout{
+                Width:       extraction.Bounds.Dx(),
+                Height:      extraction.Bounds.Dy(),
+                Direction:   string(direction),
+                Rotation:    extraction.Orientation.Rotation,
+                Orientation: extraction.Orientation.QuarterTurn,
+                Skew:        extraction.Orientation.Skew,
+                Lines:       make([]models.LineLayout, 0, len(extraction.Lines)),
+        }
+        for _, line := range extraction.Lines {
+                layout.Lines = append(layout.Lines, models.LineLayout{
+                        Box:       boundingBox(line.Box, extraction.Bounds),
+                        Direction: string(line.Direction),
+                        Glyphs:    []models.GlyphLayout{},
+                })
+        }
+        for i, glyph := range extraction.Glyphs {
+                line := &layout.Lines[glyph.Line]
+                glyphLayout := models.GlyphLayout{
+                        Box:        boundingBox(glyph.Box, extraction.Bounds),
+                        Text:       glyph.Text,
+                        Confidence: glyph.Confidence,
+                        Word:       glyph.Word,
+                        Uncertain:  glyph.Uncertain,
+                }
+                if i < len(reviews) {
+                        glyphLayout.ReviewID = reviews[i]
+                }
+                line.Glyphs = append(line.Glyphs, glyphLayout)
+        }
+        return layout
+}
+
+// boundingBox converts a rectangle of an image to a box measured from the image's top left corner
+func boundingBox(rect image.Rectangle, bounds image.Rectangle) models.BoundingBox {
+        return models.BoundingBox{
+                X:      rect.Min.X - bounds.Min.X,
+                Y:      rect.Min.Y - bounds.Min.Y,
+                Width:  rect.Dx(),
+                Height: rect.Dy(),
+        }
+}
+
+// TranslateText translates already extracted text into the target language
+func (h *ServiceHandler) TranslateText(text string, scriptType string, options TranslationOptions) (models.TranslationResult, error) {
+        h.logger.Info("Translating text", "scriptType", scriptType, "targetLanguage", options.TargetLanguage, "project", options.Project, "candidates", options.Candidates, "textLength", len(text))
+        output, err := h.translator.Translate(text, scriptType, options)
+        if err != nil {
+                h.logger.Error("Failed to translate text", "error", err)
+                return models.TranslationResult{}, err
+        }
+
+        // Summaries and metadata cover the top candidate unless all of them are to be analysed
+        if options.AnalyzeAllCandidates {
+                h.analyzeCandidates(output.Candidates, output.ScriptType, nil)
+        }
+
+        return models.TranslationResult{
+                OriginalScript:     output.ScriptType,
+                ScriptCandidates:   output.ScriptCandidates,
+                TargetLanguage:     output.TargetLanguage,
+                SourceText:         output.SourceText,
+                SourceTokens:       output.SourceTokens,
+                TranslatedText:     output.Text,
+                Alignment:          output.Alignment,
+                MemoryMatches:      convertMemoryMatches(output.MemoryMatches),
+                GlossaryViolations: output.GlossaryViolations,
+                Candidates:         output.Candidates,
+                TranslatedAt:       time.Now(),
+        }, nil
+}
+
+// AddMemoryEntry stores an approved translation in the translation memory
+func (h *ServiceHandler) AddMemoryEntry(request models.MemoryEntryRequest) (models.MemoryResponse, error) {
+        h.logger.Info("Adding translation memory entry", "scriptType", request.ScriptType, "targetLanguage", request.TargetLanguage)
+        err := h.translator.AddToMemory(MemoryEntry{
+                Source:         request.SourceText,
+                Target:         request.TranslatedText,
+                ScriptType:     request.ScriptType,
+                TargetLanguage: request.TargetLanguage,
+        })
+        if err != nil {
+                h.logger.Error("Failed to add translation memory entry", "error", err)
+                return models.MemoryResponse{}, err
+        }
+
+        return models.MemoryResponse{
+                Added:       1,
+                TotalSize:   h.translator.Memory().Size(),
+                ProcessedAt: time.Now().Format(time.RFC3339),
+        }, nil
+}
+
+// ImportMemory loads the translation units of a TMX document into the translation memory
+func (h *ServiceHandler) ImportMemory(r io.Reader) (models.MemoryResponse, error) {
+        entries, skipped, err := ReadTMX(r)
+        if err != nil {
+                h.logger.Error("Failed to read TMX", "error", err)
+                return models.MemoryResponse{}, err
+        }
+
+        added, err := h.translator.Memory().Import(entries)
+        if err != nil {
+                h.logger.Error("Failed to save translation memory", "error", err)
+                return models.MemoryResponse{}, err
+        }
+        skipped += len(entries) - added
+
+        h.logger.Info("Imported translation memory", "added", added, "skipped", skipped)
+        return models.MemoryResponse{
+                Added:       added,
+                Skipped:     skipped,
+                TotalSize:   h.translator.Memory().Size(),
+                ProcessedAt: time.Now().Format(time.RFC3339),
+        }, nil
+}
+
+// ExportMemory writes the whole translation memory as a TMX document
+func (h *ServiceHandler) ExportMemory(w io.Writer) error {
+        entries := h.translator.Memory().Entries()
+        h.logger.Info("Exporting translation memory", "entries", len(entries))
+        return WriteTMX(w, entries)
+}
+
+// ImportEpiDoc reads the edition of an EpiDoc document into Leiden notation for translation
+func (h *ServiceHandler) ImportEpiDoc(r io.Reader) (EpiDocEdition, error) {
+        edition, err := ReadEpiDoc(r)
+        if err != nil {
+                h.logger.Error("Failed to read EpiDoc", "error", err)
+                return EpiDocEdition{}, err
+        }
+
+        h.logger.Info("Imported EpiDoc edition", "title", edition.Title, "language", edition.Language, "textLength", len(edition.Text))
+        return edition, nil
+}
+
+// ExportEpiDoc writes a translation with its edition and metadata as an EpiDoc document
+func (h *ServiceHandler) ExportEpiDoc(w io.Writer, response models.TranslationResponse) error {
+        h.logger.Info("Exporting EpiDoc", "scriptType", response.OriginalScript, "targetLanguage", response.TargetLanguage)
+        return WriteEpiDoc(w, response)
+}
+
+// convertMemoryMatches converts translation memory matches to their API representation
+func convertMemoryMatches(matches []MemoryMatch) []models.MemoryMatch {
+        var converted []models.MemoryMatch
+        for _, match := range matches {
+                converted = append(converted, models.MemoryMatch{
+                        SourceText:     match.Entry.Source,
+                        TranslatedText: match.Entry.Target,
+                        MatchPercent:   match.MatchPercent,
+                })
+        }
+        return converted
+}
+
+// ListGlossaries returns the glossaries of a project, or of all projects when project is empty
+func (h *ServiceHandler) ListGlossaries(project string) []models.Glossary {
+        return h.translator.Glossaries().List(project)
+}
+
+// GetGlossary returns a glossary by ID
+func (h *ServiceHandler) GetGlossary(id string) (models.Glossary, error) {
+        return h.translator.Glossaries().Get(id)
+}
+
+// CreateGlossary stores a new glossary
+func (h *ServiceHandler) CreateGlossary(glossary models.Glossary) (models.Glossary, error) {
+        h.logger.Info("Creating glossary", "project", glossary.Project, "name", glossary.Name, "terms", len(glossary.Terms))
+        return h.translator.Glossaries().Create(glossary)
+}
+
+// UpdateGlossary replaces a glossary
+func (h *ServiceHandler) UpdateGlossary(id string, glossary models.Glossary) (models.Glossary, error) {
+        h.logger.Info("Updating glossary", "id", id, "terms", len(glossary.Terms))
+        return h.translator.Glossaries().Update(id, glossary)
+}
+
+// DeleteGlossary removes a glossary
+func (h *ServiceHandler) DeleteGlossary(id string) error {
+        h.logger.Info("Deleting glossary", "id", id)
+        return h.translator.Glossaries().Delete(id)
+}
+
+// ListSignSamples returns the sign samples of a script, or of all scripts when script is empty
+func (h *ServiceHandler) ListSignSamples(script string) []models.SignSample {
+        return h.imageProcessor.SignInventory().List(script)
+}
+
+// GetSignSample returns the sign sample with the given ID
+func (h *ServiceHandler) GetSignSample(id string) (models.SignSample, error) {
+        return h.imageProcessor.SignInventory().Get(id)
+}
+
+// AddSignSample stores a labelled glyph image in the sign inventory
+// The OCR matches glyphs against the sample once the sign index is rebuilt
+func (h *ServiceHandler) AddSignSample(sample models.SignSample, imageData []byte) (models.SignSample, error) {
+        h.logger.Info("Adding sign sample", "script", sample.Script, "text", sample.Text, "name", sample.Name)
+        return h.imageProcessor.AddSignSample(sample, imageData)
+}
+
+// DeleteSignSample removes a sample from the sign inventory
+func (h *ServiceHandler) DeleteSignSample(id string) error {
+        h.logger.Info("Deleting sign sample", "id", id)
+        return h.imageProcessor.DeleteSignSample(id)
+}
+
+// RebuildSignIndex reloads the sign inventory and rebuilds the index the OCR matches glyphs against
+func (h *ServiceHandler) RebuildSignIndex() (models.SignIndexResponse, error) {
+        h.logger.Info("Rebuilding sign index")
+        sizes, err := h.imageProcessor.RebuildSignIndex()
+        if err != nil {
+                h.logger.Error("Failed to rebuild sign index", "error", err)
+                return models.SignIndexResponse{}, err
+        }
+
+        total := 0
+        for _, size := range sizes {
+                total += size
+        }
+        h.logger.Info("Rebuilt sign index", "sizes", sizes)
+        return models.SignIndexResponse{
+                Scripts:     sizes,
+                TotalSize:   total,
+                ProcessedAt: time.Now().Format(time.RFC3339),
+        }, nil
+}
+
+// ProcessImage runs a manuscript image through an image processing pipeline so that its
+// preprocessing can be tuned before OCR: the steps given, else those of the named preset, else
+// the default pipeline. The image is processed as the OCR reads it, straightened and without
+// edge detection. With stages, the image as it leaves each step is returned as well.
+func (h *ServiceHandler) ProcessImage(imageData []byte, pipeline string, steps []utils.PipelineStep, stages bool) (models.ProcessedImage, error) {
+        if steps != nil {
+                pipeline = ""
+        }
+        steps, err := h.pipelineSteps(pipeline, steps)
+        if err != nil {
+                return models.ProcessedImage{}, err
+        }
+        h.logger.Info("Processing image", "pipeline", pipeline, "steps", len(steps), "stages", stages)
+
+        result := models.ProcessedImage{
+                Pipeline:    pipeline,
+                ProcessedAt: time.Now().Format(time.RFC3339),
+        }
+        processed := imageData
+        if stages {
+                imageStages, err := h.imageProcessor.RecognitionStages(imageData, steps)
+                if err != nil {
+                        h.logger.Error("Failed to process image", "error", err)
+                        return models.ProcessedImage{}, err
+                }
+                for _, stage := range imageStages {
+                        encoded, err := h.imageProcessor.GetImageBase64(stage.Image)
+                        if err != nil {
+                                return models.ProcessedImage{}, err
+                        }
+                        result.Stages = append(result.Stages, models.ImageStage{Step: stage.Step, Op: stage.Op, Image: encoded})
+                        processed = stage.Image
+                }
+        } else {
+                processed, err = h.imageProcessor.RecognitionImage(imageData, steps)
+                if err != nil {
+                        h.logger.Error("Failed to process image", "error", err)
+                        return models.ProcessedImage{}, err
+                }
+        }
+
+        encoded, err := h.imageProcessor.GetImageBase64(processed)
+        if err != nil {
+                return models.ProcessedImage{}, err
+        }
+        result.Image = encoded
+        result.ContentType = http.DetectContentType(processed)
+        result.Data = processed
+        return result, nil
+}
+
+// pipelineSteps returns the steps an image is processed with: the steps given, else those of
+// the named preset, else the default pipeline. Invalid steps are reported as a *utils.PipelineError
+// and an unknown preset as ErrPipelineNotFound.
+func (h *ServiceHandler) pipelineSteps(pipeline string, steps []utils.PipelineStep) ([]utils.PipelineStep, error) {
+        if steps == nil {
+                return h.imageProcessor.Pipeline(pipeline)
+        }
+        if err := utils.ValidatePipeline(steps); err != nil {
+                return nil, err
+        }
+        return steps, nil
+}
+
+// ListReviewItems returns the review queue items with a status, of a script or of all scripts
+// when script is empty; the least confident come first
+func (h *ServiceHandler) ListReviewItems(status, script string) []models.ReviewItem {
+        return h.imageProcessor.ReviewQueue().List(status, script)
+}
+
+// GetReviewItem returns the review queue item with the given ID
+func (h *ServiceHandler) GetReviewItem(id string) (models.ReviewItem, error) {
+        return h.imageProcessor.ReviewQueue().Get(id)
+}
+
+// ReviewItemImage returns the image of the glyph of a review queue item as PNG
+func (h *ServiceHandler) ReviewItemImage(id string) ([]byte, error) {
+        img, err := h.imageProcessor.ReviewQueue().Image(id)
+        if err != nil {
+                return nil, err
+        }
+        var buf bytes.Buffer
+        if err := png.Encode(&buf, img); err != nil {
+                return nil, fmt.Errorf("failed to encode review image: %v", err)
+        }
+        return buf.Bytes(), nil
+}
+
+// ReviewGlyph accepts or corrects the reading of a queued glyph, adding it to the sign inventory
+func (h *ServiceHandler) ReviewGlyph(id string, decision models.ReviewDecision) (models.ReviewItem, error) {
+        h.logger.Info("Reviewing glyph", "id", id, "action", decision.Action, "text", decision.Text, "name", decision.Name)
+        item, err := h.imageProcessor.ReviewGlyph(id, decision)
+        if err != nil {
+                h.logger.Error("Failed to review glyph", "id", id, "error", err)
+                return models.ReviewItem{}, err
+        }
+        h.logger.Info("Added reviewed glyph to sign inventory", "id", id, "script", item.Script, "signId", item.SignID)
+        return item, nil
+}
+
+// DismissReviewItem closes a review queue item without teaching the OCR its glyph
+func (h *ServiceHandler) DismissReviewItem(id string) (models.ReviewItem, error) {
+        h.logger.Info("Dismissing review item", "id", id)
+        return h.imageProcessor.DismissReview(id)
+}
+
+// Transliterate converts text between its native script and romanization
+// With scriptType "auto" the script is detected, and with direction "auto" the
+// conversion goes away from whichever form the text is written in
+func (h *ServiceHandler) Transliterate(request models.TransliterateRequest) (models.TransliterateResponse, error) {
+        scriptType := request.ScriptType
+        if scriptType == "" || scriptType == "auto" {
+                candidates := h.translator.DetectScript(request.Text)
+                if len(candidates) == 0 {
+                        return models.TransliterateResponse{}, fmt.Errorf("failed to detect script type")
+                }
+                scriptType = candidates[0].Script
+        }
+
+        translit, err := transliteration.Get(scriptType)
+        if err != nil {
+                return models.TransliterateResponse{}, err
+        }
+
+        direction := request.Direction
+        if direction == "" || direction == "auto" {
+                direction = transliteration.DirectionToRoman
+                if transliteration.IsRomanized(scriptType, request.Text) {
+                        direction = transliteration.DirectionToUnicode
+                }
+        }
+
+        h.logger.Info("Transliterating text", "scriptType", scriptType, "direction", direction, "textLength", len(request.Text))
+        result, err := transliteration.Convert(scriptType, request.Text, direction)
+        if err != nil {
+                return models.TransliterateResponse{}, err
+        }
+
+        return models.TransliterateResponse{
+                Text:        result.Text,
+                ScriptType:  scriptType,
+                Direction:   direction,
+                Convention:  translit.Convention(),
+                Unknown:     result.Unknown,
+                ProcessedAt: time.Now().Format(time.RFC3339),
+        }, nil
 }
 
 // SummarizeText summarizes the translated text
@@ -113,20 +583,46 @@ func (h *ServiceHandler) ExtractMetadata(translatedText string, scriptType strin
 }
 
 // ProcessTranslateWithMetadata processes, translates, and extracts metadata in one operation
-func (h *ServiceHandler) ProcessTranslateWithMetadata(imageData []byte, scriptType string) (string, models.Metadata, error) {
+func (h *ServiceHandler) ProcessTranslateWithMetadata(imageData []byte, scriptType string, options TranslationOptions) (models.TranslationResult, error) {
         // First translate the text
-        translatedText, err := h.ProcessAndTranslate(imageData, scriptType)
+        result, err := h.ProcessAndTranslate(imageData, scriptType, options)
         if err != nil {
-                return "", models.Metadata{}, err
+                return models.TranslationResult{}, err
+        }
+        
+        // Summaries and metadata cover the top candidate unless all of them are to be analysed
+        if options.AnalyzeAllCandidates {
+                h.analyzeCandidates(result.Candidates, result.OriginalScript, imageData)
         }
         
         // Extract metadata from translated text and original image
-        metadata, err := h.ExtractMetadata(translatedText, scriptType, imageData)
+        metadata, err := h.ExtractMetadata(result.TranslatedText, result.OriginalScript, imageData)
         if err != nil {
                 // Don't fail the whole operation if metadata extraction fails
                 h.logger.Error("Metadata extraction failed, continuing with empty metadata", "error", err)
-                return translatedText, models.Metadata{}, nil
+                return result, nil
         }
         
-        return translatedText, metadata, nil
+        result.Metadata = metadata
+        return result, nil
+}
+
+// analyzeCandidates summarizes and extracts metadata for the candidates below the top one
+// A candidate whose analysis fails is returned without it rather than failing the request
+func (h *ServiceHandler) analyzeCandidates(candidates []models.TranslationCandidate, scriptType string, imageData []byte) {
+        for i := 1; i < len(candidates); i++ {
+                summary, err := h.SummarizeText(candidates[i].Text)
+                if err != nil {
+                        h.logger.Error("Failed to summarize translation candidate", "candidate", i, "error", err)
+                } else {
+                        candidates[i].Summary = summary
+                }
+
+                metadata, err := h.ExtractMetadata(candidates[i].Text, scriptType, imageData)
+                if err != nil {
+                        h.logger.Error("Failed to extract metadata of translation candidate", "candidate", i, "error", err)
+                        continue
+                }
+                candidates[i].Metadata = &metadata
+        }
 }
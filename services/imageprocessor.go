@@ -2,31 +2,90 @@ package services
 
 import (
         "bytes"
+        "crypto/sha256"
         "encoding/base64"
+        "encoding/hex"
+        "errors"
         "fmt"
         "image"
+        "image/color"
+        "image/draw"
         "image/jpeg"
         "image/png"
         _ "image/jpeg"
         _ "image/png"
+        "math"
+        "sort"
+        "strings"
         "sync"
         
+        "ancient-script-decoder/models"
+        "ancient-script-decoder/services/ocr"
         "ancient-script-decoder/utils"
 )
 
 // ImageProcessingConfig contains the configuration for image processing
 type ImageProcessingConfig struct {
-        EnhancementEnabled   bool    `yaml:"enhancementEnabled"`
-        ContrastFactor       float64 `yaml:"contrastFactor"`
-        BrightnessAdjust     float64 `yaml:"brightnessAdjust"`
-        DenoiseLevel         int     `yaml:"denoiseLevel"`
-        GaussianBlurSigma    float64 `yaml:"gaussianBlurSigma"`
-        GaussianBlurSize     int     `yaml:"gaussianBlurSize"`
-        BoxBlurSize          int     `yaml:"boxBlurSize"`
-        SobelThreshold       uint8   `yaml:"sobelThreshold"`
-        RotationAngle        float64 `yaml:"rotationAngle"`
-        ConcurrencyLevel     int     `yaml:"concurrencyLevel"`
+        EnhancementEnabled    bool    `yaml:"enhancementEnabled"`
+        ContrastFactor        float64 `yaml:"contrastFactor"`
+        BrightnessAdjust      float64 `yaml:"brightnessAdjust"`
+        DenoiseLevel          int     `yaml:"denoiseLevel"`
+        GaussianBlurSigma     float64 `yaml:"gaussianBlurSigma"`
+        GaussianBlurSize      int     `yaml:"gaussianBlurSize"`
+        BoxBlurSize           int     `yaml:"boxBlurSize"`
+        SobelThreshold        uint8   `yaml:"sobelThreshold"`
+        RotationAngle         float64 `yaml:"rotationAngle"`
+        ConcurrencyLevel      int     `yaml:"concurrencyLevel"`
         UseParallelProcessing bool    `yaml:"useParallelProcessing"`
+        // Directory of per-script glyph templates (latin.txt, greek.txt, ...) the OCR matches glyphs against
+        GlyphTemplateDir string `yaml:"glyphTemplateDir"`
+        // Confidence below which recognized letters are marked as uncertain with a dot below
+        OCRUncertainConfidence float64 `yaml:"ocrUncertainConfidence"`
+        // Direction the lines of manuscripts are read in when a request does not give one: ltr, rtl or boustrophedon
+        ReadingDirection string `yaml:"readingDirection"`
+        // Directory of the sign inventory: labelled glyph samples uploaded to teach the OCR new signs
+        SignInventoryDir string `yaml:"signInventoryDir"`
+        // Directory of the review queue collecting the glyphs the OCR read with low confidence
+        ReviewQueueDir string `yaml:"reviewQueueDir"`
+        // Level tilted lines of text before OCR, searching up to MaxSkewAngle degrees either way
+        Deskew       bool    `yaml:"deskew"`
+        MaxSkewAngle float64 `yaml:"maxSkewAngle"`
+        // Turn images photographed sideways or upside down upright before OCR
+        DetectOrientation bool `yaml:"detectOrientation"`
+        // Thresholding that separates ink from background in the pipeline: none, otsu, sauvola or niblack
+        BinarizationMethod string `yaml:"binarizationMethod"`
+        // Side in pixels of the window of the local Sauvola and Niblack thresholds
+        BinarizationWindow int `yaml:"binarizationWindow"`
+        // Weight k of the Sauvola and Niblack thresholds; 0 takes the method's default
+        BinarizationK float64 `yaml:"binarizationK"`
+        // Share of the darkest and of the lightest pixels the contrast stretch clips
+        ContrastClip float64 `yaml:"contrastClip"`
+        // Exponent of the gamma curve; above 1 deepens faded ink, 1 leaves the image unchanged
+        Gamma float64 `yaml:"gamma"`
+        // Histogram cap of contrast-limited adaptive histogram equalization (CLAHE); 0 disables it
+        CLAHEClipLimit float64 `yaml:"claheClipLimit"`
+        // Number of tiles across and down CLAHE equalizes separately
+        CLAHETiles int `yaml:"claheTiles"`
+        // Morphological operation cleaning the binarized glyphs: none, erode, dilate, open, close, tophat or blackhat
+        MorphologyOperation string `yaml:"morphologyOperation"`
+        // Shape of the structuring element of the morphological operation: rect, cross or ellipse
+        MorphologyElement string `yaml:"morphologyElement"`
+        // Side in pixels of the structuring element
+        MorphologySize int `yaml:"morphologySize"`
+        // Filter smoothing noise away when DenoiseLevel is set: box, gaussian, median, bilateral or nlm
+        // (non-local means); empty chooses box or Gaussian blur by DenoiseLevel
+        DenoiseMethod string `yaml:"denoiseMethod"`
+        // Side in pixels of the window of the median filter
+        MedianSize int `yaml:"medianSize"`
+        // Spreads of the distance weights in pixels and of the shade weights in gray levels of the bilateral filter
+        BilateralSigmaSpace float64 `yaml:"bilateralSigmaSpace"`
+        BilateralSigmaColor float64 `yaml:"bilateralSigmaColor"`
+        // Filtering strength of non-local means in gray levels, about the standard deviation of the noise
+        NLMStrength float64 `yaml:"nlmStrength"`
+        // Named image processing pipelines, each an ordered list of steps, e.g. for papyrus or stone rubbings
+        Pipelines map[string][]utils.PipelineStep `yaml:"pipelines"`
+        // Preset ProcessImage runs when a request names none; empty makes the pipeline of the settings above
+        Pipeline string `yaml:"pipeline"`
 }
 
 // ImageProcessor handles the processing of manuscript images
@@ -35,6 +94,24 @@ type ImageProcessor struct {
         cacheLock sync.RWMutex
         // Cache for processed images (map of operation name -> image hash -> result)
         cache     map[string]map[string]image.Image
+        // templates holds the glyph templates of each script, keyed by script
+        templates map[string]*ocr.TemplateSet
+        // templateErr records why the glyph templates could not be loaded
+        templateErr error
+        // signLock guards the sign inventory and its indexes, which are rebuilt while requests are served
+        signLock sync.RWMutex
+        // signs holds the labelled glyph samples the OCR learns new signs from
+        signs *SignInventory
+        // signErr records why the sign inventory could not be loaded
+        signErr error
+        // signIndexes holds the index of the sign samples of each script, keyed by script
+        signIndexes map[string]*ocr.SignIndex
+        // reviews collects the glyphs read with low confidence for review
+        reviews *ReviewQueue
+        // reviewErr records why the review queue could not be loaded
+        reviewErr error
+        // pipelineErr records why pipeline presets were left out
+        pipelineErr error
 }
 
 // NewImageProcessor creates a new image processor
@@ -43,179 +120,470 @@ func NewImageProcessor(config ImageProcessingConfig) *ImageProcessor {
         if config.ConcurrencyLevel <= 0 {
                 config.ConcurrencyLevel = 4
         }
+        if config.OCRUncertainConfidence <= 0 {
+                config.OCRUncertainConfidence = 0.6
+        }
+        if config.ReadingDirection == "" {
+                config.ReadingDirection = string(utils.LeftToRight)
+        }
+        if config.MaxSkewAngle <= 0 {
+                config.MaxSkewAngle = 10
+        }
         
-        return &ImageProcessor{
-                config: config,
-                cache:  make(map[string]map[string]image.Image),
+        p := &ImageProcessor{
+                config:    config,
+                cache:     make(map[string]map[string]image.Image),
+                templates: make(map[string]*ocr.TemplateSet),
         }
-}
 
-// ProcessImage processes the manuscript image to prepare it for OCR
-// Demonstrates error handling, conditional logic, and method chaining
-func (p *ImageProcessor) ProcessImage(imageData []byte) ([]byte, error) {
-        // Decode the image
-        img, format, err := image.Decode(bytes.NewReader(imageData))
+        // Load the glyph templates of the OCR
+        if config.GlyphTemplateDir != "" {
+                templates, err := ocr.LoadTemplateDir(config.GlyphTemplateDir)
+                if err != nil {
+                        p.templateErr = err
+                } else {
+                        p.templates = templates
+                }
+        }
+
+        // Load the sign inventory; on failure it starts empty and is not saved over the unreadable directory
+        signs, err := LoadSignInventory(config.SignInventoryDir)
         if err != nil {
-                return nil, fmt.Errorf("failed to decode image: %v", err)
+                p.signErr = err
+                signs = NewSignInventory("")
         }
+        p.signs = signs
+        p.signIndexes = buildSignIndexes(signs, p.binarizer())
 
-        // Create a slice of image processing algorithms to apply
-        // Demonstrates slices, interfaces, and polymorphism
-        var algorithms []utils.ImageProcessingAlgorithm
+        // Load the review queue; on failure it starts empty and is not saved over the unreadable directory
+        reviews, err := LoadReviewQueue(config.ReviewQueueDir)
+        if err != nil {
+                p.reviewErr = err
+                reviews = NewReviewQueue("")
+        }
+        p.reviews = reviews
+
+        // Validate the pipeline presets up front; invalid ones are left out
+        p.config.Pipelines, p.pipelineErr = p.validatePipelines()
         
-        // Apply grayscale conversion (always applied for OCR preprocessing)
-        algorithms = append(algorithms, utils.NewGrayscaleProcessor(
-                p.config.ConcurrencyLevel, 
+        return p
+}
+
+// TemplateError returns the error that prevented the glyph templates from loading, if any
+func (p *ImageProcessor) TemplateError() error {
+        return p.templateErr
+}
+
+// TemplateSizes returns the number of glyph templates loaded for each script
+func (p *ImageProcessor) TemplateSizes() map[string]int {
+        sizes := make(map[string]int, len(p.templates))
+        for script, set := range p.templates {
+                sizes[script] = len(set.Templates)
+        }
+        return sizes
+}
+
+// SignInventoryError returns the error that prevented the sign inventory from loading, if any
+func (p *ImageProcessor) SignInventoryError() error {
+        p.signLock.RLock()
+        defer p.signLock.RUnlock()
+        return p.signErr
+}
+
+// SignInventory returns the store of labelled glyph samples
+// Samples added to it are matched once the sign index is rebuilt
+func (p *ImageProcessor) SignInventory() *SignInventory {
+        p.signLock.RLock()
+        defer p.signLock.RUnlock()
+        return p.signs
+}
+
+// SignIndexSizes returns the number of sign samples indexed for each script
+func (p *ImageProcessor) SignIndexSizes() map[string]int {
+        p.signLock.RLock()
+        defer p.signLock.RUnlock()
+
+        sizes := make(map[string]int, len(p.signIndexes))
+        for script, index := range p.signIndexes {
+                sizes[script] = index.Size()
+        }
+        return sizes
+}
+
+// AddSignSample stores a labelled glyph image in the sign inventory
+// The OCR matches glyphs against the sample once the sign index is rebuilt
+func (p *ImageProcessor) AddSignSample(sample models.SignSample, imageData []byte) (models.SignSample, error) {
+        // Hold off a rebuild swapping the inventory until the sample is in it
+        p.signLock.RLock()
+        defer p.signLock.RUnlock()
+        return p.signs.Add(sample, imageData)
+}
+
+// DeleteSignSample removes a sample from the sign inventory
+func (p *ImageProcessor) DeleteSignSample(id string) error {
+        p.signLock.RLock()
+        defer p.signLock.RUnlock()
+        return p.signs.Delete(id)
+}
+
+// RebuildSignIndex reloads the sign inventory from its directory, picking up samples added
+// to it, and rebuilds the indexes the OCR matches glyphs against; without a directory the
+// indexes are rebuilt from the samples in memory. It returns the number of samples indexed
+// for each script. Glyphs are not read while the indexes are rebuilt.
+func (p *ImageProcessor) RebuildSignIndex() (map[string]int, error) {
+        p.signLock.Lock()
+        if p.config.SignInventoryDir != "" {
+                signs, err := LoadSignInventory(p.config.SignInventoryDir)
+                if err != nil {
+                        p.signLock.Unlock()
+                        return nil, err
+                }
+                p.signs, p.signErr = signs, nil
+        }
+        p.signIndexes = buildSignIndexes(p.signs, p.binarizer())
+        p.signLock.Unlock()
+
+        return p.SignIndexSizes(), nil
+}
+
+// reindexSigns rebuilds the sign indexes from the samples of the current sign inventory
+func (p *ImageProcessor) reindexSigns() {
+        p.signLock.Lock()
+        defer p.signLock.Unlock()
+        p.signIndexes = buildSignIndexes(p.signs, p.binarizer())
+}
+
+// buildSignIndexes indexes the samples of a sign inventory by script
+func buildSignIndexes(signs *SignInventory, binarizer utils.ImageProcessingAlgorithm) map[string]*ocr.SignIndex {
+        indexes := make(map[string]*ocr.SignIndex)
+        for script, samples := range signs.indexSamples() {
+                indexes[script] = ocr.NewSignIndex(script, samples, binarizer)
+        }
+        return indexes
+}
+
+// binarizer returns the processor of the configured binarization method, which separates ink
+// from background in the images the OCR reads; nil, for Otsu's threshold, when the method is none
+func (p *ImageProcessor) binarizer() utils.ImageProcessingAlgorithm {
+        method, err := utils.ParseBinarizationMethod(p.config.BinarizationMethod)
+        if err != nil {
+                return nil
+        }
+        return utils.NewBinarizationProcessor(
+                method,
+                p.config.BinarizationWindow,
+                p.config.BinarizationK,
+                p.config.ConcurrencyLevel,
                 p.config.UseParallelProcessing,
-        ))
-        
-        // Apply edge detection if enhancement is enabled
+        )
+}
+
+// classifiers returns the glyph templates and the sign index of a script
+func (p *ImageProcessor) classifiers(script string) []ocr.Classifier {
+        var classifiers []ocr.Classifier
+        if set, ok := p.templates[script]; ok {
+                classifiers = append(classifiers, set)
+        }
+        p.signLock.RLock()
+        if index, ok := p.signIndexes[script]; ok {
+                classifiers = append(classifiers, index)
+        }
+        p.signLock.RUnlock()
+        return classifiers
+}
+
+// recognizedScripts returns the scripts with glyph templates or sign samples, sorted by name
+func (p *ImageProcessor) recognizedScripts() []string {
+        seen := make(map[string]bool)
+        for script := range p.templates {
+                seen[script] = true
+        }
+        p.signLock.RLock()
+        for script := range p.signIndexes {
+                seen[script] = true
+        }
+        p.signLock.RUnlock()
+
+        scripts := make([]string, 0, len(seen))
+        for script := range seen {
+                scripts = append(scripts, script)
+        }
+        sort.Strings(scripts)
+        return scripts
+}
+
+// ReadingDirection returns the direction lines are read in when a request does not give one
+func (p *ImageProcessor) ReadingDirection() utils.ReadingDirection {
+        return utils.ReadingDirection(p.config.ReadingDirection)
+}
+
+// ErrPipelineNotFound is returned when no image processing pipeline preset has the requested name
+var ErrPipelineNotFound = errors.New("image pipeline not found")
+
+// PipelineError returns the error that made pipeline presets be left out, if any
+func (p *ImageProcessor) PipelineError() error {
+        return p.pipelineErr
+}
+
+// Pipelines returns the image processing pipeline presets, keyed by name
+func (p *ImageProcessor) Pipelines() map[string][]utils.PipelineStep {
+        pipelines := make(map[string][]utils.PipelineStep, len(p.config.Pipelines))
+        for name, steps := range p.config.Pipelines {
+                pipelines[name] = append([]utils.PipelineStep(nil), steps...)
+        }
+        return pipelines
+}
+
+// Pipeline returns the steps of a pipeline preset. An empty name selects the configured
+// default preset or, without one, the pipeline made of the enhancement, denoising,
+// binarization and morphology settings.
+func (p *ImageProcessor) Pipeline(name string) ([]utils.PipelineStep, error) {
+        if name == "" {
+                name = p.config.Pipeline
+        }
+        if name == "" {
+                return p.defaultPipeline(), nil
+        }
+        steps, ok := p.config.Pipelines[name]
+        if !ok {
+                return nil, fmt.Errorf("%w: %q", ErrPipelineNotFound, name)
+        }
+        return steps, nil
+}
+
+// defaultPipeline makes a pipeline of the enhancement, denoising, binarization and morphology
+// settings: grayscale, then brightness, contrast, gamma and CLAHE when enhancement is enabled,
+// edge detection when the image is not binarized, denoising when the level is set, and
+// thresholding followed by morphology to clean the glyphs. Its steps take their parameters
+// from the settings.
+func (p *ImageProcessor) defaultPipeline() []utils.PipelineStep {
+        steps := []utils.PipelineStep{{Op: utils.GrayscaleStep}}
+        binarize := p.config.BinarizationMethod != "" && p.config.BinarizationMethod != string(utils.NoBinarization)
+
         if p.config.EnhancementEnabled {
-                algorithms = append(algorithms, utils.NewSobelEdgeDetector(
-                        p.config.SobelThreshold,
-                        p.config.ConcurrencyLevel,
-                        p.config.UseParallelProcessing,
-                ))
+                if p.config.BrightnessAdjust != 0 {
+                        steps = append(steps, utils.PipelineStep{Op: utils.BrightnessStep})
+                }
+                if p.config.ContrastFactor > 0 {
+                        steps = append(steps, utils.PipelineStep{Op: utils.ContrastStep})
+                }
+                if p.config.Gamma > 0 && p.config.Gamma != 1 {
+                        steps = append(steps, utils.PipelineStep{Op: utils.GammaStep})
+                }
+                if p.config.CLAHEClipLimit > 0 {
+                        steps = append(steps, utils.PipelineStep{Op: utils.CLAHEStep})
+                }
+                if !binarize {
+                        steps = append(steps, utils.PipelineStep{Op: utils.EdgeDetectionStep})
+                }
         }
-        
-        // Apply denoising if level is set
+
         if p.config.DenoiseLevel > 0 {
-                // Choose between box blur and Gaussian blur based on denoise level
-                if p.config.DenoiseLevel <= 3 {
-                        algorithms = append(algorithms, utils.NewBoxBlurProcessor(
-                                p.config.BoxBlurSize,
-                                p.config.ConcurrencyLevel,
-                                p.config.UseParallelProcessing,
-                        ))
-                } else {
-                        algorithms = append(algorithms, utils.NewGaussianBlurProcessor(
-                                p.config.GaussianBlurSigma,
-                                p.config.GaussianBlurSize,
-                                p.config.ConcurrencyLevel,
-                                p.config.UseParallelProcessing,
-                        ))
+                // Without a method choose between box blur and Gaussian blur based on denoise level
+                method := p.config.DenoiseMethod
+                if method == "" {
+                        method = utils.BoxBlurStep
+                        if p.config.DenoiseLevel > 3 {
+                                method = utils.GaussianBlurStep
+                        }
+                }
+                switch utils.DenoiseMethod(method) {
+                case utils.NoDenoise:
+                case utils.BoxDenoise:
+                        steps = append(steps, utils.PipelineStep{Op: utils.BoxBlurStep})
+                case utils.GaussianDenoise:
+                        steps = append(steps, utils.PipelineStep{Op: utils.GaussianBlurStep})
+                default:
+                        steps = append(steps, utils.PipelineStep{Op: method})
                 }
         }
-        
-        // Process the image through the pipeline
-        processedImg := utils.ProcessImagePipeline(img, algorithms)
-        
-        // Encode the processed image based on the original format
-        var buf bytes.Buffer
-        switch format {
-        case "jpeg":
-                if err := jpeg.Encode(&buf, processedImg, nil); err != nil {
-                        return nil, fmt.Errorf("failed to encode JPEG: %v", err)
+
+        // Binarize last, once noise has been smoothed away, and clean the glyphs
+        if binarize {
+                steps = append(steps, utils.PipelineStep{Op: utils.BinarizeStep})
+        }
+        if morphology := p.config.MorphologyOperation; morphology != "" && morphology != string(utils.NoMorphology) {
+                steps = append(steps, utils.PipelineStep{Op: morphology})
+        }
+        return steps
+}
+
+// validatePipelines checks the pipeline presets and the default pipeline. Invalid presets are
+// left out of the returned ones and reported in the error.
+func (p *ImageProcessor) validatePipelines() (map[string][]utils.PipelineStep, error) {
+        names := make([]string, 0, len(p.config.Pipelines))
+        for name := range p.config.Pipelines {
+                names = append(names, name)
+        }
+        sort.Strings(names)
+
+        valid := make(map[string][]utils.PipelineStep, len(names))
+        var problems []string
+        for _, name := range names {
+                steps := p.config.Pipelines[name]
+                if err := utils.ValidatePipeline(steps); err != nil {
+                        problems = append(problems, fmt.Sprintf("pipeline %q: %v", name, err))
+                        continue
                 }
-        case "png":
-                if err := png.Encode(&buf, processedImg); err != nil {
-                        return nil, fmt.Errorf("failed to encode PNG: %v", err)
+                valid[name] = steps
+        }
+
+        if name := p.config.Pipeline; name != "" {
+                if _, ok := valid[name]; !ok {
+                        problems = append(problems, fmt.Sprintf("default pipeline %q is not a valid preset", name))
                 }
-        default:
-                return nil, fmt.Errorf("unsupported image format: %s", format)
+        } else if err := p.validateSettings(); err != nil {
+                problems = append(problems, fmt.Sprintf("default pipeline: %v", err))
         }
-        
-        return buf.Bytes(), nil
+
+        if len(problems) > 0 {
+                return valid, fmt.Errorf("%s", strings.Join(problems, "; "))
+        }
+        return valid, nil
 }
 
-// ApplyImageTransformations applies various geometric transformations to an image
-// Demonstrates method chaining, error handling, and interface usage
-func (p *ImageProcessor) ApplyImageTransformations(imageData []byte, transformations map[string]interface{}) ([]byte, error) {
+// validateSettings checks that the settings the default pipeline is made of name known methods
+func (p *ImageProcessor) validateSettings() error {
+        if err := utils.ValidatePipeline(p.defaultPipeline()); err != nil {
+                return err
+        }
+        if _, err := utils.ParseBinarizationMethod(p.config.BinarizationMethod); err != nil {
+                return err
+        }
+        if _, err := utils.ParseStructuringElementShape(p.config.MorphologyElement); err != nil {
+                return err
+        }
+        return nil
+}
+
+// ProcessImage processes the manuscript image to prepare it for OCR
+// The image is prepared as RecognitionImage prepares it, with the steps of the named pipeline
+// preset, or of the default pipeline when the name is empty
+func (p *ImageProcessor) ProcessImage(imageData []byte, pipeline string) ([]byte, error) {
+        steps, err := p.Pipeline(pipeline)
+        if err != nil {
+                return nil, err
+        }
+        return p.RecognitionImage(imageData, steps)
+}
+
+// ApplyImageTransformations runs an image through the steps of a pipeline given by the caller
+// The steps are validated before the image is decoded; an invalid step is reported as a
+// *utils.PipelineError
+func (p *ImageProcessor) ApplyImageTransformations(imageData []byte, steps []utils.PipelineStep) ([]byte, error) {
+        if err := utils.ValidatePipeline(steps); err != nil {
+                return nil, err
+        }
+
         // Decode the image
         img, format, err := image.Decode(bytes.NewReader(imageData))
         if err != nil {
                 return nil, fmt.Errorf("failed to decode image: %v", err)
         }
-        
-        // Create a slice of image processing algorithms to apply
-        var algorithms []utils.ImageProcessingAlgorithm
-        
-        // Apply transformations based on the provided options
-        if val, ok := transformations["upsideDown"]; ok && val.(bool) {
-                algorithms = append(algorithms, utils.NewUpsideDownProcessor(
-                        p.config.ConcurrencyLevel,
-                        p.config.UseParallelProcessing,
-                ))
+
+        return p.runPipeline(img, format, steps)
+}
+
+// runPipeline runs an image through the steps of a pipeline and encodes the result in the
+// format of the original image
+func (p *ImageProcessor) runPipeline(img image.Image, format string, steps []utils.PipelineStep) ([]byte, error) {
+        algorithms, err := p.buildPipeline(steps, utils.BackgroundColor(img))
+        if err != nil {
+                return nil, err
         }
+
+        // Process the image through the pipeline
+        processedImg := utils.ProcessImagePipeline(img, algorithms)
         
-        if val, ok := transformations["rotationAngle"]; ok {
-                angle := val.(float64)
-                // Use shear rotation for specific angles for better performance
-                if angle == 90 || angle == 180 || angle == 270 {
-                        algorithms = append(algorithms, utils.NewShearRotateProcessor(
-                                angle,
-                                p.config.ConcurrencyLevel,
-                                p.config.UseParallelProcessing,
-                        ))
-                } else {
-                        algorithms = append(algorithms, utils.NewRotateProcessor(
-                                angle,
-                                p.config.ConcurrencyLevel,
-                                p.config.UseParallelProcessing,
-                        ))
-                }
+        return encodeImage(processedImg, format)
+}
+
+// RecognitionImage returns a manuscript image as ExtractTextFromImage reads it, so that its
+// preprocessing can be tuned: turned upright and level as configured, then run through the
+// steps of a pipeline but edge detection. An invalid step is reported as a *utils.PipelineError.
+func (p *ImageProcessor) RecognitionImage(imageData []byte, steps []utils.PipelineStep) ([]byte, error) {
+        img, format, err := p.decodeUpright(imageData, steps)
+        if err != nil {
+                return nil, err
         }
-        
-        if val, ok := transformations["grayscale"]; ok && val.(bool) {
-                algorithms = append(algorithms, utils.NewGrayscaleProcessor(
-                        p.config.ConcurrencyLevel,
-                        p.config.UseParallelProcessing,
-                ))
+        return p.runPipeline(img, format, recognitionSteps(steps))
+}
+
+// ImageStage is an image as it leaves a step of a pipeline
+type ImageStage struct {
+        // Step is the position of the step, counted from 1; 0 is the straightened image
+        Step int
+        Op   string
+        // Image is encoded in the format of the original image
+        Image []byte
+}
+
+// straightenStage names the stage of the straightened image, before the steps of a pipeline
+const straightenStage = "straighten"
+
+// RecognitionStages prepares a manuscript image like RecognitionImage, keeping the image as it
+// leaves each step so that the effect of every step can be seen. The first stage is the
+// straightened image and the last the image the OCR reads; edge detection steps, which the OCR
+// leaves out, have no stage.
+func (p *ImageProcessor) RecognitionStages(imageData []byte, steps []utils.PipelineStep) ([]ImageStage, error) {
+        img, format, err := p.decodeUpright(imageData, steps)
+        if err != nil {
+                return nil, err
         }
-        
-        if val, ok := transformations["boxBlur"]; ok && val.(bool) {
-                size := p.config.BoxBlurSize
-                if sizeVal, sizeOk := transformations["boxBlurSize"]; sizeOk {
-                        size = sizeVal.(int)
+        data, err := encodeImage(img, format)
+        if err != nil {
+                return nil, err
+        }
+        stages := []ImageStage{{Step: 0, Op: straightenStage, Image: data}}
+
+        // The steps run one after another rather than as a pipeline of goroutines, as each
+        // stage is encoded before the next step takes it
+        surface := utils.BackgroundColor(img)
+        for i, step := range steps {
+                if step.Op == utils.EdgeDetectionStep {
+                        continue
+                }
+                algorithm, err := p.stepProcessor(step, surface)
+                if err != nil {
+                        return nil, &utils.PipelineError{Step: i + 1, Op: step.Op, Err: err}
+                }
+                if algorithm != nil {
+                        img = algorithm.Process(img)
+                }
+                data, err := encodeImage(img, format)
+                if err != nil {
+                        return nil, err
                 }
-                algorithms = append(algorithms, utils.NewBoxBlurProcessor(
-                        size,
-                        p.config.ConcurrencyLevel,
-                        p.config.UseParallelProcessing,
-                ))
+                stages = append(stages, ImageStage{Step: i + 1, Op: step.Op, Image: data})
         }
-        
-        if val, ok := transformations["gaussianBlur"]; ok && val.(bool) {
-                sigma := p.config.GaussianBlurSigma
-                size := p.config.GaussianBlurSize
-                if sigmaVal, sigmaOk := transformations["gaussianBlurSigma"]; sigmaOk {
-                        sigma = sigmaVal.(float64)
-                }
-                if sizeVal, sizeOk := transformations["gaussianBlurSize"]; sizeOk {
-                        size = sizeVal.(int)
-                }
-                algorithms = append(algorithms, utils.NewGaussianBlurProcessor(
-                        sigma,
-                        size,
-                        p.config.ConcurrencyLevel,
-                        p.config.UseParallelProcessing,
-                ))
+        return stages, nil
+}
+
+// decodeUpright validates the steps of a pipeline, then decodes an image and turns it by the
+// configured rotation angle and, if enabled, upright and level
+func (p *ImageProcessor) decodeUpright(imageData []byte, steps []utils.PipelineStep) (image.Image, string, error) {
+        if err := utils.ValidatePipeline(steps); err != nil {
+                return nil, "", err
         }
-        
-        if val, ok := transformations["edgeDetection"]; ok && val.(bool) {
-                threshold := p.config.SobelThreshold
-                if threshVal, threshOk := transformations["edgeThreshold"]; threshOk {
-                        threshold = uint8(threshVal.(int))
-                }
-                algorithms = append(algorithms, utils.NewSobelEdgeDetector(
-                        threshold,
-                        p.config.ConcurrencyLevel,
-                        p.config.UseParallelProcessing,
-                ))
+
+        img, format, err := image.Decode(bytes.NewReader(imageData))
+        if err != nil {
+                return nil, "", fmt.Errorf("failed to decode image: %v", err)
         }
-        
-        // Process the image through the pipeline
-        processedImg := utils.ProcessImagePipeline(img, algorithms)
-        
-        // Encode the processed image based on the original format
+        img, _ = p.straighten(img)
+        return img, format, nil
+}
+
+// encodeImage encodes an image in the format of the original image, JPEG or PNG
+func encodeImage(img image.Image, format string) ([]byte, error) {
         var buf bytes.Buffer
         switch format {
         case "jpeg":
-                if err := jpeg.Encode(&buf, processedImg, nil); err != nil {
+                if err := jpeg.Encode(&buf, img, nil); err != nil {
                         return nil, fmt.Errorf("failed to encode JPEG: %v", err)
                 }
         case "png":
-                if err := png.Encode(&buf, processedImg); err != nil {
+                if err := png.Encode(&buf, img); err != nil {
                         return nil, fmt.Errorf("failed to encode PNG: %v", err)
                 }
         default:
@@ -225,60 +593,492 @@ func (p *ImageProcessor) ApplyImageTransformations(imageData []byte, transformat
         return buf.Bytes(), nil
 }
 
+// buildPipeline validates the steps of a pipeline and creates their processors
+// Demonstrates slices, interfaces, and polymorphism
+func (p *ImageProcessor) buildPipeline(steps []utils.PipelineStep, surface color.Color) ([]utils.ImageProcessingAlgorithm, error) {
+        if err := utils.ValidatePipeline(steps); err != nil {
+                return nil, err
+        }
+
+        algorithms := make([]utils.ImageProcessingAlgorithm, 0, len(steps))
+        for i, step := range steps {
+                algorithm, err := p.stepProcessor(step, surface)
+                if err != nil {
+                        return nil, &utils.PipelineError{Step: i + 1, Op: step.Op, Err: err}
+                }
+                if algorithm != nil {
+                        algorithms = append(algorithms, algorithm)
+                }
+        }
+        return algorithms, nil
+}
+
+// stepProcessor creates the processor of a valid pipeline step, nil for a step that leaves
+// the image as it is. Parameters left at zero take the configured defaults, and rotations
+// fill the corners they uncover with the color of the surface.
+func (p *ImageProcessor) stepProcessor(step utils.PipelineStep, surface color.Color) (utils.ImageProcessingAlgorithm, error) {
+        concurrency, parallel := p.config.ConcurrencyLevel, p.config.UseParallelProcessing
+        switch step.Op {
+        case utils.RotateStep:
+                return utils.NewRotateProcessorWithBackground(step.Angle, surface, concurrency, parallel), nil
+        case utils.UpsideDownStep:
+                return utils.NewUpsideDownProcessor(concurrency, parallel), nil
+        case utils.GrayscaleStep:
+                return utils.NewGrayscaleProcessor(concurrency, parallel), nil
+        case utils.BrightnessStep:
+                return utils.NewBrightnessProcessor(orFloat(step.Adjust, p.config.BrightnessAdjust), concurrency, parallel), nil
+        case utils.ContrastStep:
+                return utils.NewContrastStretchProcessor(
+                        orFloat(step.Factor, p.config.ContrastFactor),
+                        orFloat(step.Clip, p.config.ContrastClip),
+                        concurrency,
+                        parallel,
+                ), nil
+        case utils.GammaStep:
+                return utils.NewGammaProcessor(orFloat(step.Gamma, p.config.Gamma), concurrency, parallel), nil
+        case utils.CLAHEStep:
+                return utils.NewCLAHEProcessor(
+                        orInt(step.Tiles, p.config.CLAHETiles),
+                        orFloat(step.ClipLimit, p.config.CLAHEClipLimit),
+                        concurrency,
+                        parallel,
+                ), nil
+        case utils.BoxBlurStep:
+                return utils.NewBoxBlurProcessor(orInt(step.Size, p.config.BoxBlurSize), concurrency, parallel), nil
+        case utils.GaussianBlurStep:
+                return utils.NewGaussianBlurProcessor(
+                        orFloat(step.Sigma, p.config.GaussianBlurSigma),
+                        orInt(step.Size, p.config.GaussianBlurSize),
+                        concurrency,
+                        parallel,
+                ), nil
+        case utils.MedianStep:
+                return utils.NewMedianProcessor(orInt(step.Size, p.config.MedianSize), concurrency, parallel), nil
+        case utils.BilateralStep:
+                return utils.NewBilateralProcessor(
+                        orFloat(step.SigmaSpace, p.config.BilateralSigmaSpace),
+                        orFloat(step.SigmaColor, p.config.BilateralSigmaColor),
+                        concurrency,
+                        parallel,
+                ), nil
+        case utils.NonLocalMeansStep:
+                return utils.NewNonLocalMeansProcessor(orFloat(step.Strength, p.config.NLMStrength), concurrency, parallel), nil
+        case utils.BinarizeStep:
+                method, err := utils.ParseBinarizationMethod(orString(step.Method, p.config.BinarizationMethod))
+                if err != nil {
+                        return nil, err
+                }
+                return utils.NewBinarizationProcessor(
+                        method,
+                        orInt(step.Window, p.config.BinarizationWindow),
+                        orFloat(step.K, p.config.BinarizationK),
+                        concurrency,
+                        parallel,
+                ), nil
+        case utils.EdgeDetectionStep:
+                threshold := p.config.SobelThreshold
+                if step.Threshold != 0 {
+                        threshold = uint8(step.Threshold)
+                }
+                return utils.NewSobelEdgeDetector(threshold, concurrency, parallel), nil
+        }
+
+        // The remaining operations are morphological
+        operation, err := utils.ParseMorphologyOperation(step.Op)
+        if err != nil {
+                return nil, err
+        }
+        shape, err := utils.ParseStructuringElementShape(orString(step.Element, p.config.MorphologyElement))
+        if err != nil {
+                return nil, err
+        }
+        size := orInt(step.Size, p.config.MorphologySize)
+        return utils.NewMorphologyProcessor(operation, utils.NewStructuringElement(shape, size, size), concurrency, parallel), nil
+}
+
+// orFloat returns value, or fallback when value is zero
+func orFloat(value, fallback float64) float64 {
+        if value == 0 {
+                return fallback
+        }
+        return value
+}
+
+// orInt returns value, or fallback when value is zero
+func orInt(value, fallback int) int {
+        if value == 0 {
+                return fallback
+        }
+        return value
+}
+
+// orString returns value, or fallback when value is empty
+func orString(value, fallback string) string {
+        if value == "" {
+                return fallback
+        }
+        return value
+}
+
 // GetImageBase64 converts an image to base64 for web display
 func (p *ImageProcessor) GetImageBase64(imageData []byte) (string, error) {
         return base64.StdEncoding.EncodeToString(imageData), nil
 }
 
-// ExtractTextFromImage extracts text from the processed image
-// In a real implementation, this would use OCR specific to ancient scripts
-func (p *ImageProcessor) ExtractTextFromImage(processedImageData []byte, scriptType string) (string, error) {
-        // Different script types would use different OCR algorithms in a real implementation
-        scriptHandlers := map[string]func([]byte) (string, error){
-                "latin": p.processLatinScript,
-                "greek": p.processGreekScript,
-                "cuneiform": p.processCuneiformScript,
-                "hieroglyphic": p.processHieroglyphicScript,
-                "runic": p.processRunicScript,
-                "auto": p.autoDetectScript,
+// Orientation is how a manuscript image was turned upright before its text was read
+type Orientation struct {
+        // QuarterTurn is the detected turn of 0, 90, 180 or 270 degrees clockwise
+        QuarterTurn int
+        // Skew is the detected angle in degrees the image was turned clockwise by to level its lines
+        Skew float64
+        // Rotation is the whole angle in degrees the image was turned clockwise by:
+        // the configured rotation angle, the quarter turn and the skew
+        Rotation float64
+}
+
+// minSkewAngle is the skew in degrees below which lines are taken to be level, sparing the image a resampling
+const minSkewAngle = 0.3
+
+// uprightConfidence is the OCR confidence above which an image is taken to be upright without
+// reading it upside down as well; letters read upside down match their templates poorly
+const uprightConfidence = 0.9
+
+// ImageText is the text recognized in a manuscript image
+type ImageText struct {
+        ocr.Result
+        // Script is the script whose templates and sign samples the glyphs were read with
+        Script string
+        // ScriptCandidates ranks the scripts by the look of the writing when the script was detected from the image
+        ScriptCandidates []models.ScriptCandidate
+        // Orientation is how the image was turned upright; the boxes of the glyphs and lines are in the turned image
+        Orientation Orientation
+        // Image is the upright image the text was read from, as the preprocessing pipeline left it
+        Image image.Image
+}
+
+// ExtractTextFromImage recognizes the text of a manuscript image by matching its glyphs
+// against the glyph templates and sign samples of the script. The image is first turned by
+// the configured rotation angle and, if enabled, upright and level: lines running from top
+// to bottom are turned a quarter turn, tilted lines are levelled, and an image read poorly is
+// read upside down as well, keeping the more confident reading. The upright image then runs
+// through the given pipeline steps, or those of the default pipeline when steps is nil, but edge
+// detection; an invalid step is reported as a *utils.PipelineError. The lines are segmented and
+// read in the given direction, the configured one if it is empty, and each recognized
+// character carries the confidence of its match. For "auto" or an unknown script the
+// script is detected from the look of the writing.
+func (p *ImageProcessor) ExtractTextFromImage(imageData []byte, scriptType string, direction utils.ReadingDirection, steps []utils.PipelineStep) (ImageText, error) {
+        if direction == "" {
+                direction = p.ReadingDirection()
         }
-        
-        // Get the appropriate handler function for the script type
-        handler, ok := scriptHandlers[scriptType]
-        if !ok {
-                // Default to auto-detection if script type is not recognized
-                handler = p.autoDetectScript
+        if _, err := utils.ParseReadingDirection(string(direction)); err != nil {
+                return ImageText{}, err
         }
-        
-        // Process the image with the selected handler
-        return handler(processedImageData)
+        if steps == nil {
+                var err error
+                if steps, err = p.Pipeline(""); err != nil {
+                        return ImageText{}, err
+                }
+        }
+        if err := utils.ValidatePipeline(steps); err != nil {
+                return ImageText{}, err
+        }
+        steps = recognitionSteps(steps)
+        binarizer := p.recognitionBinarizer(steps)
+
+        img, _, err := image.Decode(bytes.NewReader(imageData))
+        if err != nil {
+                return ImageText{}, fmt.Errorf("failed to decode image: %v", err)
+        }
+
+        upright, orientation := p.straighten(img)
+        algorithms, err := p.buildPipeline(steps, utils.BackgroundColor(upright))
+        if err != nil {
+                return ImageText{}, err
+        }
+        upright = utils.ProcessImagePipeline(upright, algorithms)
+        text, err := p.readText(upright, binarizer, scriptType, direction)
+        if err != nil {
+                return ImageText{}, err
+        }
+
+        if p.config.DetectOrientation && text.Confidence() < uprightConfidence {
+                flipped := utils.ProcessImagePipeline(p.rotate(img, orientation.Rotation+180), algorithms)
+                if flippedText, err := p.readText(flipped, binarizer, scriptType, direction); err == nil && flippedText.Confidence() > text.Confidence() {
+                        text, upright = flippedText, flipped
+                        orientation.QuarterTurn = (orientation.QuarterTurn + 180) % 360
+                        orientation.Rotation = math.Mod(orientation.Rotation+180, 360)
+                }
+        }
+        text.Orientation = orientation
+        text.Image = upright
+        return text, nil
 }
 
-// Script processing functions - in a real implementation, these would use
-// specialized OCR algorithms for different ancient scripts
+// straighten turns an image by the configured rotation angle and, if enabled, a quarter turn
+// when its lines run from top to bottom and by the skew of its lines. Whether the image is
+// upside down cannot be told from the layout of the ink alone and is left to the OCR.
+func (p *ImageProcessor) straighten(img image.Image) (image.Image, Orientation) {
+        orientation := Orientation{Rotation: p.config.RotationAngle}
+        turned := p.rotate(img, orientation.Rotation)
+        if !p.config.Deskew && !p.config.DetectOrientation {
+                return turned, orientation
+        }
 
-func (p *ImageProcessor) processLatinScript(imageData []byte) (string, error) {
-        return "Example Latin text extracted from manuscript: Senatus Populusque Romanus", nil
+        bitmap := utils.BinarizeWith(turned, p.binarizer())
+        if p.config.DetectOrientation && utils.TextIsVertical(bitmap) {
+                orientation.QuarterTurn = 90
+                bitmap = bitmap.TurnClockwise()
+        }
+        if p.config.Deskew {
+                if skew := utils.EstimateSkew(bitmap, p.config.MaxSkewAngle); math.Abs(skew) >= minSkewAngle {
+                        orientation.Skew = skew
+                }
+        }
+        if orientation.QuarterTurn == 0 && orientation.Skew == 0 {
+                return turned, orientation
+        }
+
+        orientation.Rotation = math.Mod(orientation.Rotation+float64(orientation.QuarterTurn)+orientation.Skew, 360)
+        return p.rotate(img, orientation.Rotation), orientation
+}
+
+// rotate turns an image clockwise by an angle in degrees, filling the corners it no longer
+// covers with the color of its surface
+func (p *ImageProcessor) rotate(img image.Image, angle float64) image.Image {
+        if math.Mod(angle, 360) == 0 {
+                return img
+        }
+        return utils.NewRotateProcessorWithBackground(
+                angle,
+                utils.BackgroundColor(img),
+                p.config.ConcurrencyLevel,
+                p.config.UseParallelProcessing,
+        ).Process(img)
+}
+
+// recognitionSteps returns the steps of a pipeline the OCR reads an image after: all but edge
+// detection, whose outlines lack the filled strokes the glyph templates describe
+func recognitionSteps(steps []utils.PipelineStep) []utils.PipelineStep {
+        kept := make([]utils.PipelineStep, 0, len(steps))
+        for _, step := range steps {
+                if step.Op != utils.EdgeDetectionStep {
+                        kept = append(kept, step)
+                }
+        }
+        return kept
+}
+
+// recognitionBinarizer returns the binarizer the OCR separates ink from background with after
+// the steps of a pipeline: the configured method, or Otsu's threshold when a step binarized the
+// image already, so that its ink is not thresholded a second time
+func (p *ImageProcessor) recognitionBinarizer(steps []utils.PipelineStep) utils.ImageProcessingAlgorithm {
+        for _, step := range steps {
+                if step.Op == utils.BinarizeStep && orString(step.Method, p.config.BinarizationMethod) != string(utils.NoBinarization) {
+                        return nil
+                }
+        }
+        return p.binarizer()
+}
+
+// readText recognizes the text of an upright image in a script with a binarizer, detecting the
+// script for "auto" or an unknown script
+func (p *ImageProcessor) readText(img image.Image, binarizer utils.ImageProcessingAlgorithm, scriptType string, direction utils.ReadingDirection) (ImageText, error) {
+        classifiers := p.classifiers(scriptType)
+        if len(classifiers) == 0 {
+                if _, supported := scriptLanguageCodes[scriptType]; supported {
+                        return ImageText{}, fmt.Errorf("%w for script %s", ocr.ErrNoTemplates, scriptType)
+                }
+                return p.autoDetectScript(img, binarizer, direction)
+        }
+        result, err := ocr.NewRecognizer(p.config.OCRUncertainConfidence, binarizer, classifiers...).Recognize(img, direction)
+        if err != nil {
+                return ImageText{}, err
+        }
+        return ImageText{Result: result, Script: scriptType}, nil
 }
 
-func (p *ImageProcessor) processGreekScript(imageData []byte) (string, error) {
-        return "Example Greek text extracted from manuscript: Ἐν ἀρχῇ ἦν ὁ λόγος", nil
+// autoDetectScript ranks the scripts by the visual features of the writing and reads the
+// image with the templates and sign samples of the likeliest ones. Each reading is weighed
+// by the likelihood of its script and the confidence of its glyphs, so that the OCR decides
+// between scripts that look alike, such as Latin and Greek. Less likely scripts are only
+// tried when the likelier ones read the image poorly.
+func (p *ImageProcessor) autoDetectScript(img image.Image, binarizer utils.ImageProcessingAlgorithm, direction utils.ReadingDirection) (ImageText, error) {
+        bitmap := utils.BinarizeWith(img, binarizer)
+        candidates := rankImageScripts(utils.MeasureWriting(bitmap, utils.NewSegmenter(direction).Segment(bitmap)))
+
+        // Scripts without a visual profile, such as those only in the sign inventory, come last
+        likelihood := make(map[string]float64, len(candidates))
+        var scripts []string
+        lowest := 1.0
+        for _, candidate := range candidates {
+                likelihood[candidate.Script] = candidate.Confidence
+                lowest = math.Min(lowest, candidate.Confidence)
+                if len(p.classifiers(candidate.Script)) > 0 {
+                        scripts = append(scripts, candidate.Script)
+                }
+        }
+        for _, script := range p.recognizedScripts() {
+                if _, ranked := likelihood[script]; !ranked {
+                        likelihood[script] = lowest
+                        scripts = append(scripts, script)
+                }
+        }
+        if len(scripts) == 0 {
+                return ImageText{}, ocr.ErrNoTemplates
+        }
+
+        best := ImageText{ScriptCandidates: candidates}
+        bestScore, bestConfidence := -1.0, 0.0
+        for i, script := range scripts {
+                if i > 0 && likelihood[script] < likelihood[scripts[0]]/2 && bestConfidence >= p.config.OCRUncertainConfidence {
+                        break
+                }
+
+                result, err := ocr.NewRecognizer(p.config.OCRUncertainConfidence, binarizer, p.classifiers(script)...).Recognize(img, direction)
+                if err != nil {
+                        return ImageText{}, err
+                }
+                confidence := result.Confidence()
+                if score := likelihood[script] * confidence; score > bestScore {
+                        best.Result, best.Script = result, script
+                        bestScore, bestConfidence = score, confidence
+                }
+        }
+        return best, nil
 }
 
-func (p *ImageProcessor) processCuneiformScript(imageData []byte) (string, error) {
-        return "Example Cuneiform text extracted from manuscript: Laws of Hammurabi, first section", nil
+// ReviewQueueError returns the error that prevented the review queue from loading, if any
+func (p *ImageProcessor) ReviewQueueError() error {
+        return p.reviewErr
 }
 
-func (p *ImageProcessor) processHieroglyphicScript(imageData []byte) (string, error) {
-        return "Example Hieroglyphic text extracted from manuscript: Excerpt from Book of the Dead", nil
+// ReviewQueue returns the queue of glyphs read with low confidence
+func (p *ImageProcessor) ReviewQueue() *ReviewQueue {
+        return p.reviews
 }
 
-func (p *ImageProcessor) processRunicScript(imageData []byte) (string, error) {
-        return "Example Runic text extracted from manuscript: Norse inscription commemorating victory", nil
+// reviewMargin is the number of pixels of the manuscript kept around a glyph queued for review
+const reviewMargin = 2
+
+// QueueUncertainGlyphs adds the glyphs of a manuscript image read with low confidence to the
+// review queue, cut from the upright image they were read in. It returns the IDs of their review items, in the order of the glyphs of the
+// extraction and empty for the glyphs read with confidence.
+func (p *ImageProcessor) QueueUncertainGlyphs(imageData []byte, extraction ImageText) ([]string, error) {
+        var glyphs []int
+        for i, glyph := range extraction.Glyphs {
+                if glyph.Uncertain {
+                        glyphs = append(glyphs, i)
+                }
+        }
+        if len(glyphs) == 0 || extraction.Script == "" || extraction.Image == nil {
+                return nil, nil
+        }
+
+        hash := sha256.Sum256(imageData)
+        manuscript := hex.EncodeToString(hash[:8])
+
+        items := make([]models.ReviewItem, 0, len(glyphs))
+        crops := make([]image.Image, 0, len(glyphs))
+        for _, i := range glyphs {
+                glyph := extraction.Glyphs[i]
+                items = append(items, models.ReviewItem{
+                        Script:     extraction.Script,
+                        Text:       glyph.Text,
+                        Confidence: glyph.Confidence,
+                        Line:       lineText(extraction.Glyphs, glyph.Line),
+                        Manuscript: manuscript,
+                        Box:        boundingBox(glyph.Box, extraction.Bounds),
+                })
+                crops = append(crops, cropGlyph(extraction.Image, glyph.Box.Inset(-reviewMargin)))
+        }
+
+        queued, err := p.reviews.Enqueue(items, crops)
+        if err != nil {
+                return nil, err
+        }
+        ids := make([]string, len(extraction.Glyphs))
+        for j, i := range glyphs {
+                ids[i] = queued[j].ID
+        }
+        return ids, nil
+}
+
+// ReviewGlyph applies a reviewer's decision on a pending review item. An accepted reading, or
+// the corrected one, is added to the sign inventory with the image of the glyph, and the sign
+// index of its script is rebuilt so that later runs read the glyph as reviewed.
+func (p *ImageProcessor) ReviewGlyph(id string, decision models.ReviewDecision) (models.ReviewItem, error) {
+        item, err := p.reviews.Get(id)
+        if err != nil {
+                return models.ReviewItem{}, err
+        }
+        if item.Status != ReviewPending {
+                return models.ReviewItem{}, ErrAlreadyReviewed
+        }
+
+        sample := models.SignSample{Script: item.Script}
+        status, correction := ReviewAccepted, ""
+        switch decision.Action {
+        case "accept":
+                sample.Text = item.Text
+        case "correct":
+                sample.Text, sample.Name = decision.Text, decision.Name
+                status, correction = ReviewCorrected, strings.TrimSpace(decision.Text)
+                if correction == "" {
+                        correction = strings.TrimSpace(decision.Name)
+                }
+        default:
+                return models.ReviewItem{}, fmt.Errorf("unknown review action %q: must be accept or correct", decision.Action)
+        }
+
+        img, err := p.reviews.Image(id)
+        if err != nil {
+                return models.ReviewItem{}, err
+        }
+        var buf bytes.Buffer
+        if err := png.Encode(&buf, img); err != nil {
+                return models.ReviewItem{}, fmt.Errorf("failed to encode review image: %v", err)
+        }
+        sample, err = p.AddSignSample(sample, buf.Bytes())
+        if err != nil {
+                return models.ReviewItem{}, err
+        }
+
+        item, err = p.reviews.Resolve(id, status, correction, sample.ID)
+        if err != nil {
+                // The item was reviewed concurrently or could not be saved; take the sample back
+                p.DeleteSignSample(sample.ID)
+                return models.ReviewItem{}, err
+        }
+        p.reindexSigns()
+        return item, nil
+}
+
+// DismissReview closes a pending review item without adding its glyph to the sign inventory
+func (p *ImageProcessor) DismissReview(id string) (models.ReviewItem, error) {
+        return p.reviews.Resolve(id, ReviewDismissed, "", "")
+}
+
+// lineText returns the text of a line of recognized glyphs, with its words separated by spaces
+func lineText(glyphs []ocr.Glyph, line int) string {
+        var b strings.Builder
+        word := -1
+        for _, glyph := range glyphs {
+                if glyph.Line != line {
+                        continue
+                }
+                if word >= 0 && glyph.Word != word {
+                        b.WriteString(" ")
+                }
+                word = glyph.Word
+                b.WriteString(glyph.Text)
+        }
+        return b.String()
 }
 
-func (p *ImageProcessor) autoDetectScript(imageData []byte) (string, error) {
-        // In a real implementation, this would analyze the image to determine the script type
-        // Then call the appropriate processing function
-        return "Auto-detected ancient text from manuscript. Script appears to be a form of early Mediterranean writing.", nil
+// cropGlyph copies the part of an image within a rectangle, clipped to the image
+func cropGlyph(img image.Image, rect image.Rectangle) image.Image {
+        rect = rect.Intersect(img.Bounds())
+        crop := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+        draw.Draw(crop, crop.Bounds(), img, rect.Min, draw.Src)
+        return crop
 }
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"reflect"
+	"testing"
+
+	"ancient-script-decoder/models"
+	"ancient-script-decoder/utils"
+)
+
+func TestRecognitionSteps(t *testing.T) {
+	tests := []struct {
+		name              string
+		binarization      string
+		steps             []utils.PipelineStep
+		want              []utils.PipelineStep
+		wantOtsuBinarizer bool
+	}{
+		{
+			name:  "edge detection is left out",
+			steps: []utils.PipelineStep{{Op: utils.GrayscaleStep}, {Op: utils.EdgeDetectionStep}, {Op: utils.MedianStep}},
+			want:  []utils.PipelineStep{{Op: utils.GrayscaleStep}, {Op: utils.MedianStep}},
+			// Without a configured method the OCR thresholds with Otsu
+			wantOtsuBinarizer: true,
+		},
+		{
+			name:         "configured method without a binarize step",
+			binarization: "sauvola",
+			steps:        []utils.PipelineStep{{Op: utils.GrayscaleStep}},
+			want:         []utils.PipelineStep{{Op: utils.GrayscaleStep}},
+		},
+		{
+			name:              "binarized image is thresholded with Otsu",
+			binarization:      "sauvola",
+			steps:             []utils.PipelineStep{{Op: utils.GrayscaleStep}, {Op: utils.BinarizeStep}},
+			want:              []utils.PipelineStep{{Op: utils.GrayscaleStep}, {Op: utils.BinarizeStep}},
+			wantOtsuBinarizer: true,
+		},
+		{
+			name:         "binarize step without binarization",
+			binarization: "sauvola",
+			steps:        []utils.PipelineStep{{Op: utils.BinarizeStep, Method: "none"}},
+			want:         []utils.PipelineStep{{Op: utils.BinarizeStep, Method: "none"}},
+		},
+		{
+			name:              "no configured method",
+			steps:             nil,
+			want:              []utils.PipelineStep{},
+			wantOtsuBinarizer: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewImageProcessor(ImageProcessingConfig{BinarizationMethod: test.binarization})
+			steps := recognitionSteps(test.steps)
+			if !reflect.DeepEqual(steps, test.want) {
+				t.Errorf("recognitionSteps() = %+v, want %+v", steps, test.want)
+			}
+			if binarizer := p.recognitionBinarizer(steps); (binarizer == nil) != test.wantOtsuBinarizer {
+				t.Errorf("recognitionBinarizer() = %v, want Otsu's threshold %v", binarizer, test.wantOtsuBinarizer)
+			}
+		})
+	}
+}
+
+// manuscriptPNG encodes an image of a dark stroke on a light surface
+func manuscriptPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 40; x++ {
+			shade := uint8(220)
+			if x >= 15 && x < 20 && y >= 5 && y < 25 {
+				shade = 30
+			}
+			img.Set(x, y, color.RGBA{R: shade, G: shade, B: shade, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRecognitionPreview(t *testing.T) {
+	p := NewImageProcessor(ImageProcessingConfig{RotationAngle: 90})
+	data := manuscriptPNG(t)
+	steps := []utils.PipelineStep{{Op: utils.GrayscaleStep}, {Op: utils.EdgeDetectionStep}, {Op: utils.BinarizeStep, Method: "otsu"}}
+
+	preview, err := p.RecognitionImage(data, steps)
+	if err != nil {
+		t.Fatalf("RecognitionImage() error = %v", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(preview))
+	if err != nil {
+		t.Fatalf("preview does not decode: %v", err)
+	}
+	if size := img.Bounds().Size(); size != (image.Point{X: 30, Y: 40}) {
+		t.Errorf("preview size = %v, want the image turned by the rotation angle", size)
+	}
+
+	stages, err := p.RecognitionStages(data, steps)
+	if err != nil {
+		t.Fatalf("RecognitionStages() error = %v", err)
+	}
+	var got []string
+	for _, stage := range stages {
+		got = append(got, fmt.Sprintf("%d %s", stage.Step, stage.Op))
+	}
+	if want := []string{"0 straighten", "1 grayscale", "3 binarize"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RecognitionStages() = %q, want %q", got, want)
+	}
+	if !bytes.Equal(stages[len(stages)-1].Image, preview) {
+		t.Error("last stage differs from RecognitionImage()")
+	}
+
+	if _, err := p.RecognitionImage(data, []utils.PipelineStep{{Op: "sharpen"}}); err == nil {
+		t.Error("RecognitionImage() with an unknown operation succeeded, want an error")
+	}
+}
+
+func TestRebuildSignIndexKeepsSamples(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+	}{
+		{name: "in memory"},
+		{name: "persisted", dir: t.TempDir()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewImageProcessor(ImageProcessingConfig{SignInventoryDir: test.dir})
+			if _, err := p.AddSignSample(models.SignSample{Script: "cuneiform", Text: "U+1202D"}, manuscriptPNG(t)); err != nil {
+				t.Fatalf("AddSignSample() error = %v", err)
+			}
+
+			sizes, err := p.RebuildSignIndex()
+			if err != nil {
+				t.Fatalf("RebuildSignIndex() error = %v", err)
+			}
+			if want := map[string]int{"cuneiform": 1}; !reflect.DeepEqual(sizes, want) {
+				t.Errorf("RebuildSignIndex() = %v, want %v", sizes, want)
+			}
+			if size := p.SignInventory().Size(); size != 1 {
+				t.Errorf("inventory size after rebuild = %d, want 1", size)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"math"
+	"sort"
+
+	"ancient-script-decoder/models"
+	"ancient-script-decoder/utils"
+)
+
+// Writing families the scripts are told apart by before their glyphs are read
+const (
+	// wedgeFamily scripts are impressed with a stylus, leaving groups of wedges
+	wedgeFamily = "wedge"
+	// pictographicFamily scripts draw signs of varied size and shape, often stacked
+	pictographicFamily = "pictographic"
+	// alphabeticFamily scripts write a row of letters of even height, one stroke group each
+	alphabeticFamily = "alphabetic"
+)
+
+// imageScriptFamilies assigns the scripts to the writing families
+var imageScriptFamilies = map[string]string{
+	"cuneiform":    wedgeFamily,
+	"hieroglyphic": pictographicFamily,
+	"latin":        alphabeticFamily,
+	"greek":        alphabeticFamily,
+	"runic":        alphabeticFamily,
+}
+
+// familyFloor is the score every family keeps, so that no script is ruled out by the look of
+// the writing alone when the features are ambiguous, e.g. for a handful of glyphs
+const familyFloor = 0.05
+
+// Runes were cut across the grain of wood and avoid horizontal strokes, so writing is taken
+// for runic when the share of horizontal stroke edges is at most runicHorizontalShare and for
+// Latin or Greek when it is at least alphabetHorizontalShare, with a blend in between
+const (
+	runicHorizontalShare    = 0.25
+	alphabetHorizontalShare = 0.35
+)
+
+// rankImageScripts scores the scripts by the visual features of the writing of an image, most
+// likely first. The family of the writing is judged from the glyph statistics: wedge-shaped
+// components mark cuneiform, uneven glyph heights and lines pictographic scripts, and even rows
+// of glyphs made of one piece alphabets. Within the alphabets the stroke directions separate
+// runic from Latin and Greek, which look alike and are left to the OCR to tell apart.
+func rankImageScripts(features utils.WritingFeatures) []models.ScriptCandidate {
+	if features.Glyphs == 0 {
+		return nil
+	}
+
+	wedges := clampUnit(features.Wedges / 0.4)
+	families := map[string]float64{
+		wedgeFamily: 0.7*wedges + 0.3*clampUnit((features.ComponentsPerGlyph-1)/2),
+		pictographicFamily: (0.5*clampUnit(features.HeightVariation/0.4) +
+			0.5*clampUnit((1-features.LineRegularity)/0.5)) * (1 - wedges),
+		alphabeticFamily: features.LineRegularity * clampUnit(2-features.ComponentsPerGlyph) * (1 - wedges),
+	}
+
+	runic := clampUnit((alphabetHorizontalShare - features.Strokes[utils.StrokeHorizontal]) /
+		(alphabetHorizontalShare - runicHorizontalShare))
+	scores := make(map[string]float64, len(imageScriptFamilies))
+	var total float64
+	for script, family := range imageScriptFamilies {
+		score := familyFloor + families[family]
+		switch script {
+		case "runic":
+			score *= 0.2 + 0.8*runic
+		case "latin", "greek":
+			score *= 0.2 + 0.8*(1-runic)
+		}
+		scores[script] = score
+		total += score
+	}
+
+	candidates := make([]models.ScriptCandidate, 0, len(scores))
+	for script, score := range scores {
+		candidates = append(candidates, models.ScriptCandidate{
+			Script:     script,
+			Confidence: score / total,
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].Script < candidates[j].Script
+	})
+	return candidates
+}
+
+// clampUnit limits a value to the range from 0 to 1
+func clampUnit(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
@@ -0,0 +1,317 @@
+// Package leiden parses the Leiden conventions epigraphers use to record the
+// state of a damaged text: restorations in [ ], letters supplied by the editor
+// in ⟨ ⟩, expanded abbreviations in ( ), superfluous letters in { }, erasures
+// in ⟦ ⟧ or [[ ]], uncertain letters with a dot below, and lacunae such as
+// [---] or [.3.].
+package leiden
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Status describes how securely letters of the text can be read
+type Status string
+
+// Statuses of letters, from the most to the least certain
+const (
+	// Certain letters are clearly legible
+	Certain Status = "certain"
+	// Expanded letters resolve an abbreviation, e.g. Imp(erator)
+	Expanded Status = "expanded"
+	// Uncertain letters are damaged and their reading is doubtful, e.g. ạ
+	Uncertain Status = "uncertain"
+	// Erased letters were deliberately erased in antiquity but remain legible, e.g. ⟦Getae⟧
+	Erased Status = "erased"
+	// Supplied letters were omitted or miswritten by the scribe and added by the editor, e.g. ⟨e⟩
+	Supplied Status = "supplied"
+	// Restored letters are lost and restored by the editor, e.g. [Aug]ustus
+	Restored Status = "restored"
+	// Lost marks a lacuna the editor could not restore, e.g. [---]
+	Lost Status = "lost"
+	// Deleted letters are superfluous and removed by the editor, e.g. {s}
+	Deleted Status = "deleted"
+)
+
+// rank orders the statuses by decreasing certainty; deleted letters are not read at all
+var rank = map[Status]int{
+	Certain:   0,
+	Expanded:  1,
+	Uncertain: 2,
+	Erased:    3,
+	Supplied:  4,
+	Restored:  5,
+	Lost:      6,
+}
+
+// LessCertain reports whether letters with status a are less securely read than letters with status b
+func LessCertain(a, b Status) bool {
+	return rank[a] > rank[b]
+}
+
+// Segment is a run of letters of a word sharing one status
+type Segment struct {
+	Text   string `json:"text"`
+	Status Status `json:"status"`
+}
+
+// Token is a word of the text, or a lacuna between words
+type Token struct {
+	Segments []Segment `json:"segments,omitempty"`
+	// Lacuna marks a gap of lost text; a lacuna has no segments
+	Lacuna bool `json:"lacuna,omitempty"`
+	// Missing is the number of letters lost in a lacuna, 0 if the extent is unknown
+	Missing int `json:"missing,omitempty"`
+	// Queried records an editor's (?) after the word
+	Queried bool `json:"queried,omitempty"`
+}
+
+// Text returns the reading of the word without markup, leaving out deleted letters
+func (t Token) Text() string {
+	var b strings.Builder
+	for _, segment := range t.Segments {
+		if segment.Status != Deleted {
+			b.WriteString(segment.Text)
+		}
+	}
+	return b.String()
+}
+
+// Status returns the status of the least certain letter of the word
+func (t Token) Status() Status {
+	if t.Lacuna {
+		return Lost
+	}
+
+	status := Certain
+	read := false
+	for _, segment := range t.Segments {
+		if segment.Status == Deleted {
+			continue
+		}
+		read = true
+		if LessCertain(segment.Status, status) {
+			status = segment.Status
+		}
+	}
+	if !read && len(t.Segments) > 0 {
+		return Deleted
+	}
+	if t.Queried && LessCertain(Uncertain, status) {
+		status = Uncertain
+	}
+	return status
+}
+
+// dotBelow is the combining mark of uncertain letters
+const dotBelow = '̣'
+
+// markupPattern finds the signs of Leiden markup: editorial brackets, dotted letters,
+// a queried word and an expansion attached to a word
+var markupPattern = regexp.MustCompile(`[\[\]⟨⟩⟦⟧{}]|\x{0323}|\(\?\)|\pL\(\pL+\)`)
+
+// HasMarkup reports whether text uses Leiden notation
+func HasMarkup(text string) bool {
+	return markupPattern.MatchString(norm.NFD.String(text))
+}
+
+// Plain returns the reading of the tokens without markup, leaving out lacunae and deleted words
+func Plain(tokens []Token) string {
+	words := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if text := token.Text(); text != "" {
+			words = append(words, text)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// bracket is an open editorial bracket
+type bracket struct {
+	status Status
+	closer string
+}
+
+// parser splits a text into tokens while tracking the open brackets
+type parser struct {
+	runes   []rune
+	open    []bracket
+	tokens  []Token
+	current Token
+}
+
+// Parse reads a text in Leiden notation into words and lacunae.
+// Brackets may span several words; a bracket left open runs to the end of the text.
+func Parse(text string) []Token {
+	p := &parser{runes: []rune(norm.NFD.String(text))}
+
+	for i := 0; i < len(p.runes); {
+		r := p.runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			p.endWord()
+			i++
+		case p.closes(i):
+			closer := p.open[len(p.open)-1].closer
+			p.open = p.open[:len(p.open)-1]
+			i += len([]rune(closer))
+		case p.hasPrefix(i, "[[") || r == '⟦':
+			closer := "]]"
+			if r == '⟦' {
+				closer = "⟧"
+			}
+			p.open = append(p.open, bracket{Erased, closer})
+			i += len([]rune(closer))
+		case r == '[':
+			content, end, ok := p.enclosed(i, ']')
+			if ok && isLacuna(content) {
+				p.endWord()
+				p.tokens = append(p.tokens, Token{Lacuna: true, Missing: lacunaSize(content)})
+				i = end + 1
+				continue
+			}
+			p.open = append(p.open, bracket{Restored, "]"})
+			i++
+		case r == '⟨' || r == '<' && p.hasCloser(i, '>'):
+			closer := "⟩"
+			if r == '<' {
+				closer = ">"
+			}
+			p.open = append(p.open, bracket{Supplied, closer})
+			i++
+		case r == '{' && p.hasCloser(i, '}'):
+			p.open = append(p.open, bracket{Deleted, "}"})
+			i++
+		case p.hasPrefix(i, "(?)"):
+			if len(p.current.Segments) > 0 {
+				p.current.Queried = true
+			} else if len(p.tokens) > 0 {
+				p.tokens[len(p.tokens)-1].Queried = true
+			}
+			i += 3
+		case r == '(' && p.isExpansion(i):
+			p.open = append(p.open, bracket{Expanded, ")"})
+			i++
+		case r == ']' || r == '⟩' || r == '⟧' || r == '}':
+			// A closing bracket without its opening one, e.g. a restoration begun on an earlier line
+			i++
+		case r == dotBelow:
+			i++
+		default:
+			status := p.status()
+			if i+1 < len(p.runes) && p.runes[i+1] == dotBelow && !LessCertain(status, Uncertain) {
+				status = Uncertain
+			}
+			p.add(r, status)
+			i++
+		}
+	}
+	p.endWord()
+
+	return p.tokens
+}
+
+// status returns the status of the innermost open bracket
+func (p *parser) status() Status {
+	if len(p.open) == 0 {
+		return Certain
+	}
+	return p.open[len(p.open)-1].status
+}
+
+// add appends a letter to the current word; combining marks stay with their base letter
+func (p *parser) add(r rune, status Status) {
+	segments := p.current.Segments
+	if n := len(segments); n > 0 && (segments[n-1].Status == status || unicode.Is(unicode.Mn, r)) {
+		segments[n-1].Text += string(r)
+		return
+	}
+	p.current.Segments = append(segments, Segment{Text: string(r), Status: status})
+}
+
+// endWord closes the current word; the abbreviation vac. for a blank space is not a word
+func (p *parser) endWord() {
+	if len(p.current.Segments) == 0 {
+		return
+	}
+	for i := range p.current.Segments {
+		p.current.Segments[i].Text = norm.NFC.String(p.current.Segments[i].Text)
+	}
+	if p.current.Text() != "vac." {
+		p.tokens = append(p.tokens, p.current)
+	}
+	p.current = Token{}
+}
+
+// hasPrefix reports whether the text continues with s at position i
+func (p *parser) hasPrefix(i int, s string) bool {
+	end := i + len([]rune(s))
+	if end > len(p.runes) {
+		return false
+	}
+	return string(p.runes[i:end]) == s
+}
+
+// closes reports whether the innermost open bracket closes at position i
+func (p *parser) closes(i int) bool {
+	return len(p.open) > 0 && p.hasPrefix(i, p.open[len(p.open)-1].closer)
+}
+
+// hasCloser reports whether the bracket at position i is closed by closer before the text ends
+func (p *parser) hasCloser(i int, closer rune) bool {
+	_, _, ok := p.enclosed(i, closer)
+	return ok
+}
+
+// enclosed returns the content of the bracket opened at position i and the position of its closer
+func (p *parser) enclosed(i int, closer rune) (string, int, bool) {
+	for j := i + 1; j < len(p.runes); j++ {
+		if p.runes[j] == closer {
+			return string(p.runes[i+1 : j]), j, true
+		}
+	}
+	return "", 0, false
+}
+
+// isExpansion reports whether the parenthesis at position i resolves an abbreviation:
+// it must follow a letter and enclose letters only
+func (p *parser) isExpansion(i int) bool {
+	content, _, ok := p.enclosed(i, ')')
+	if !ok || content == "" || len(p.current.Segments) == 0 {
+		return false
+	}
+	for _, r := range content {
+		if !unicode.IsLetter(r) && r != dotBelow && !unicode.Is(unicode.Mn, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// lacunaPattern matches the content of a bracket marking lost text:
+// dashes for an unknown extent, dots or a number for the letters lost, optionally "ca."
+var lacunaPattern = regexp.MustCompile(`^[\s\-–—.·]*(?:c(?:a)?\.?\s*)?\d*[\s\-–—.·?]*$`)
+
+// isLacuna reports whether the content of a square bracket stands for lost text rather than a restoration
+func isLacuna(content string) bool {
+	return strings.TrimSpace(content) != "" && strings.ContainsAny(content, "-–—.·0123456789") &&
+		lacunaPattern.MatchString(content)
+}
+
+// lacunaSize returns the number of letters a lacuna is estimated to span, or 0 if it is not given
+func lacunaSize(content string) int {
+	digits := strings.TrimFunc(content, func(r rune) bool { return !unicode.IsDigit(r) })
+	if digits != "" {
+		if n, err := strconv.Atoi(digits); err == nil {
+			return n
+		}
+	}
+	if strings.ContainsAny(content, "-–—") {
+		return 0
+	}
+	return strings.Count(content, ".") + strings.Count(content, "·")
+}
@@ -0,0 +1,191 @@
+package leiden
+
+import (
+	"reflect"
+	"testing"
+)
+
+// word builds a token of segments given as alternating text and status
+func word(parts ...interface{}) Token {
+	var token Token
+	for i := 0; i < len(parts); i += 2 {
+		token.Segments = append(token.Segments, Segment{Text: parts[i].(string), Status: parts[i+1].(Status)})
+	}
+	return token
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Token
+	}{
+		{
+			name: "plain words",
+			text: "Imperator Caesar",
+			want: []Token{word("Imperator", Certain), word("Caesar", Certain)},
+		},
+		{
+			name: "restoration within a word",
+			text: "[Aug]ustus",
+			want: []Token{word("Aug", Restored, "ustus", Certain)},
+		},
+		{
+			name: "restoration spanning words",
+			text: "[divi f]ilius",
+			want: []Token{word("divi", Restored), word("f", Restored, "ilius", Certain)},
+		},
+		{
+			name: "lacuna of unknown extent",
+			text: "rex [---] fecit",
+			want: []Token{word("rex", Certain), {Lacuna: true}, word("fecit", Certain)},
+		},
+		{
+			name: "lacuna of counted letters",
+			text: "rex [.3.] fecit",
+			want: []Token{word("rex", Certain), {Lacuna: true, Missing: 3}, word("fecit", Certain)},
+		},
+		{
+			name: "lacuna of dots",
+			text: "[....]",
+			want: []Token{{Lacuna: true, Missing: 4}},
+		},
+		{
+			name: "approximate lacuna",
+			text: "[ca. 12]",
+			want: []Token{{Lacuna: true, Missing: 12}},
+		},
+		{
+			name: "supplied letters",
+			text: "fec⟨i⟩t",
+			want: []Token{word("fec", Certain, "i", Supplied, "t", Certain)},
+		},
+		{
+			name: "supplied letters in ASCII brackets",
+			text: "fec<i>t",
+			want: []Token{word("fec", Certain, "i", Supplied, "t", Certain)},
+		},
+		{
+			name: "superfluous letters",
+			text: "fecit{s}",
+			want: []Token{word("fecit", Certain, "s", Deleted)},
+		},
+		{
+			name: "erasure",
+			text: "⟦Getae⟧ [[Geta]]",
+			want: []Token{word("Getae", Erased), word("Geta", Erased)},
+		},
+		{
+			name: "expanded abbreviation",
+			text: "Imp(erator)",
+			want: []Token{word("Imp", Certain, "erator", Expanded)},
+		},
+		{
+			name: "parenthesis that is not an expansion",
+			text: "rex (2)",
+			want: []Token{word("rex", Certain), word("(2)", Certain)},
+		},
+		{
+			name: "dotted letters",
+			text: "rẹx",
+			want: []Token{word("r", Certain, "e", Uncertain, "x", Certain)},
+		},
+		{
+			name: "dotted letter within a restoration is restored",
+			text: "[rẹx]",
+			want: []Token{word("rex", Restored)},
+		},
+		{
+			name: "queried word",
+			text: "rex(?) fecit",
+			want: []Token{{Segments: []Segment{{Text: "rex", Status: Certain}}, Queried: true}, word("fecit", Certain)},
+		},
+		{
+			name: "query after a space",
+			text: "rex (?)",
+			want: []Token{{Segments: []Segment{{Text: "rex", Status: Certain}}, Queried: true}},
+		},
+		{
+			name: "vacat is not a word",
+			text: "fecit vac. rex",
+			want: []Token{word("fecit", Certain), word("rex", Certain)},
+		},
+		{
+			name: "unclosed bracket runs to the end",
+			text: "[rex fecit",
+			want: []Token{word("rex", Restored), word("fecit", Restored)},
+		},
+		{
+			name: "stray closing bracket",
+			text: "re]x",
+			want: []Token{word("rex", Certain)},
+		},
+		{
+			name: "nested brackets",
+			text: "[re⟨x⟩]",
+			want: []Token{word("re", Restored, "x", Supplied)},
+		},
+		{
+			name: "empty text",
+			text: "",
+			want: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Parse(test.text); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTokenStatus(t *testing.T) {
+	tests := []struct {
+		text string
+		want Status
+	}{
+		{text: "rex", want: Certain},
+		{text: "Imp(erator)", want: Expanded},
+		{text: "rẹx", want: Uncertain},
+		{text: "rex(?)", want: Uncertain},
+		{text: "[Aug]ustus", want: Restored},
+		{text: "[re]ẹx", want: Restored},
+		{text: "{s}", want: Deleted},
+		{text: "[---]", want: Lost},
+	}
+
+	for _, test := range tests {
+		tokens := Parse(test.text)
+		if len(tokens) != 1 {
+			t.Fatalf("Parse(%q) = %d tokens, want 1", test.text, len(tokens))
+		}
+		if got := tokens[0].Status(); got != test.want {
+			t.Errorf("Parse(%q) status = %s, want %s", test.text, got, test.want)
+		}
+	}
+}
+
+func TestPlainAndHasMarkup(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantPlain string
+		wantMarks bool
+	}{
+		{text: "Imperator Caesar", wantPlain: "Imperator Caesar"},
+		{text: "[Aug]ustus [---] fec⟨i⟩t{s}", wantPlain: "Augustus fecit", wantMarks: true},
+		{text: "Imp(erator) rex(?)", wantPlain: "Imperator rex", wantMarks: true},
+		{text: "rẹx", wantPlain: "rex", wantMarks: true},
+		{text: "rex (2)", wantPlain: "rex (2)"},
+	}
+
+	for _, test := range tests {
+		if got := Plain(Parse(test.text)); got != test.wantPlain {
+			t.Errorf("Plain(Parse(%q)) = %q, want %q", test.text, got, test.wantPlain)
+		}
+		if got := HasMarkup(test.text); got != test.wantMarks {
+			t.Errorf("HasMarkup(%q) = %v, want %v", test.text, got, test.wantMarks)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"sort"
+	"strings"
+)
+
+// inflectionRule maps an inflectional ending to the dictionary endings it may come from
+type inflectionRule struct {
+	ending       string
+	replacements []string
+}
+
+// latinInflections covers the regular noun, adjective and present/imperfect/perfect verb endings.
+// Forms are matched after normalization, so "v" is written as "u".
+var latinInflections = []inflectionRule{
+	// First declension
+	{"arum", []string{"a"}},
+	{"ae", []string{"a"}},
+	{"am", []string{"a"}},
+	{"as", []string{"a", "o"}},
+	// Second declension
+	{"orum", []string{"us", "um", "er"}},
+	{"os", []string{"us"}},
+	{"um", []string{"us", "is", ""}},
+	{"o", []string{"us", "um"}},
+	{"i", []string{"us", "um", "is", ""}},
+	{"a", []string{"um"}},
+	// Third declension
+	{"ionibus", []string{"io"}},
+	{"ionum", []string{"io"}},
+	{"ionis", []string{"io"}},
+	{"ionem", []string{"io"}},
+	{"iones", []string{"io"}},
+	{"ioni", []string{"io"}},
+	{"ione", []string{"io"}},
+	{"ibus", []string{"is", "", "us", "s"}},
+	{"em", []string{"is", "", "s"}},
+	{"es", []string{"is", "", "s"}},
+	{"is", []string{"us", "um", "a", "is", "", "s"}},
+	{"e", []string{"is", "", "s"}},
+	// Fourth and fifth declension
+	{"uum", []string{"us"}},
+	{"ui", []string{"us"}},
+	{"u", []string{"us"}},
+	{"ebus", []string{"es"}},
+	{"ei", []string{"es"}},
+	// Verbs, first conjugation
+	{"auerunt", []string{"o"}},
+	{"auit", []string{"o"}},
+	{"abant", []string{"o"}},
+	{"abat", []string{"o"}},
+	{"amus", []string{"o"}},
+	{"atis", []string{"o"}},
+	{"ant", []string{"o"}},
+	{"are", []string{"o"}},
+	{"at", []string{"o"}},
+	// Verbs, second to fourth conjugation
+	{"iunt", []string{"io"}},
+	{"ebant", []string{"eo", "o", "io"}},
+	{"ebat", []string{"eo", "o", "io"}},
+	{"erunt", []string{"o", "eo", "io"}},
+	{"imus", []string{"o", "io"}},
+	{"itis", []string{"o", "io"}},
+	{"unt", []string{"o", "eo"}},
+	{"ere", []string{"eo", "o"}},
+	{"ire", []string{"io"}},
+	{"it", []string{"o", "io", "eo"}},
+	{"et", []string{"eo"}},
+	{"ent", []string{"eo"}},
+}
+
+// latinEnclitics are particles attached to the end of a Latin word
+var latinEnclitics = []struct {
+	suffix string
+	gloss  string
+}{
+	{"que", "and"},
+	{"ue", "or"},
+	{"ne", "(question)"},
+}
+
+// greekInflections covers the common noun, adjective and present/imperfect verb endings.
+// Forms are matched after diacritics have been stripped and final sigma unified.
+var greekInflections = []inflectionRule{
+	// Second declension
+	{"ου", []string{"οσ", "ον"}},
+	{"ω", []string{"οσ", "ον"}},
+	{"ον", []string{"οσ"}},
+	{"οι", []string{"οσ"}},
+	{"ων", []string{"οσ", "ον", "η", "α", "ησ"}},
+	{"οισ", []string{"οσ", "ον"}},
+	{"ουσ", []string{"οσ"}},
+	{"α", []string{"ον", "η"}},
+	// First declension
+	{"ησ", []string{"η"}},
+	{"ην", []string{"η"}},
+	{"αι", []string{"η", "α"}},
+	{"αισ", []string{"η", "α"}},
+	{"ασ", []string{"α", "η"}},
+	{"αν", []string{"α"}},
+	// Verbs, present and imperfect
+	{"ουσιν", []string{"ω"}},
+	{"ουσι", []string{"ω"}},
+	{"ομεν", []string{"ω"}},
+	{"ετε", []string{"ω"}},
+	{"ειν", []string{"ω"}},
+	{"εισ", []string{"ω"}},
+	{"ει", []string{"ω"}},
+	{"ον", []string{"ω"}},
+	{"εν", []string{"ω"}},
+}
+
+// sortedRules returns the rules ordered from the longest ending to the shortest
+func sortedRules(rules []inflectionRule) []inflectionRule {
+	sorted := make([]inflectionRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len([]rune(sorted[i].ending)) > len([]rune(sorted[j].ending))
+	})
+	return sorted
+}
+
+var (
+	latinRules = sortedRules(latinInflections)
+	greekRules = sortedRules(greekInflections)
+)
+
+// lemmaAnalysis is one way of reading an inflected form as a lexicon entry
+type lemmaAnalysis struct {
+	entry LexiconEntry
+	// enclitic is the gloss of an enclitic split off the form, if any
+	enclitic string
+}
+
+// lemmatize finds the lexicon entry of an inflected, normalized form.
+// It also returns the gloss of any enclitic that was split off.
+func lemmatize(form string, lexicon *Lexicon) (LexiconEntry, string, bool) {
+	analyses := lemmatizeAll(form, lexicon)
+	if len(analyses) == 0 {
+		return LexiconEntry{}, "", false
+	}
+	return analyses[0].entry, analyses[0].enclitic, true
+}
+
+// lemmatizeAll returns every distinct analysis of an inflected, normalized form, most direct first:
+// the form itself, then inflection rules from the longest ending, then enclitic splits.
+// A lexicon with a fallback is searched completely, and its fallback only if that found nothing.
+func lemmatizeAll(form string, lexicon *Lexicon) []lemmaAnalysis {
+	if lexicon.fallback != nil {
+		own := *lexicon
+		own.fallback = nil
+		if analyses := lemmatizeAll(form, &own); len(analyses) > 0 {
+			return analyses
+		}
+		return lemmatizeAll(form, lexicon.fallback)
+	}
+
+	var analyses []lemmaAnalysis
+	seen := make(map[lemmaAnalysis]bool)
+	add := func(entry LexiconEntry, enclitic string) {
+		analysis := lemmaAnalysis{entry: entry, enclitic: enclitic}
+		if !seen[analysis] {
+			seen[analysis] = true
+			analyses = append(analyses, analysis)
+		}
+	}
+
+	if entry, ok := lexicon.Lookup(form); ok {
+		add(entry, "")
+	}
+
+	var rules []inflectionRule
+	switch lexicon.Script {
+	case "latin":
+		rules = latinRules
+	case "greek":
+		rules = greekRules
+	default:
+		return analyses
+	}
+
+	for _, entry := range applyInflectionRules(form, rules, lexicon) {
+		add(entry, "")
+	}
+
+	// Latin words may carry an enclitic such as -que
+	if lexicon.Script == "latin" {
+		for _, enclitic := range latinEnclitics {
+			if !strings.HasSuffix(form, enclitic.suffix) || len(form) <= len(enclitic.suffix)+1 {
+				continue
+			}
+			stem := strings.TrimSuffix(form, enclitic.suffix)
+			if entry, ok := lexicon.Lookup(stem); ok {
+				add(entry, enclitic.gloss)
+			}
+			for _, entry := range applyInflectionRules(stem, rules, lexicon) {
+				add(entry, enclitic.gloss)
+			}
+		}
+	}
+
+	return analyses
+}
+
+// applyInflectionRules returns the headwords found by every ending replacement, in rule order
+func applyInflectionRules(form string, rules []inflectionRule, lexicon *Lexicon) []LexiconEntry {
+	var entries []LexiconEntry
+	for _, rule := range rules {
+		if !strings.HasSuffix(form, rule.ending) {
+			continue
+		}
+		stem := strings.TrimSuffix(form, rule.ending)
+		// Avoid reducing a word to a bare one-letter stem
+		if len([]rune(stem)) < 2 {
+			continue
+		}
+		for _, replacement := range rule.replacements {
+			if entry, ok := lexicon.Lookup(stem + replacement); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
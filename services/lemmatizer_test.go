@@ -0,0 +1,113 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+// lexiconOf builds a lexicon of a script from pairs of headwords and glosses
+func lexiconOf(script string, pairs ...string) *Lexicon {
+	lexicon := newLexicon(script)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		lexicon.Add(LexiconEntry{Headword: pairs[i], Gloss: pairs[i+1]})
+	}
+	return lexicon
+}
+
+func TestLemmatize(t *testing.T) {
+	latin := lexiconOf("latin",
+		"puella", "girl",
+		"populus", "people",
+		"servus", "slave",
+		"natio", "nation",
+		"senatus", "senate",
+		"Iulius", "Julius",
+		"amo", "love",
+		"rosa", "rose",
+	)
+	greek := lexiconOf("greek",
+		"λόγος", "word",
+		"ἄνθρωπος", "man",
+		"ψυχή", "soul",
+		"λύω", "loose",
+	)
+
+	tests := []struct {
+		name         string
+		lexicon      *Lexicon
+		form         string
+		wantHeadword string
+		wantEnclitic string
+		wantOK       bool
+	}{
+		{name: "headword", lexicon: latin, form: "puella", wantHeadword: "puella", wantOK: true},
+		{name: "first declension genitive", lexicon: latin, form: "puellae", wantHeadword: "puella", wantOK: true},
+		{name: "second declension accusative plural", lexicon: latin, form: "servos", wantHeadword: "servus", wantOK: true},
+		{name: "third declension in -io", lexicon: latin, form: "nationibus", wantHeadword: "natio", wantOK: true},
+		{name: "fourth declension dative", lexicon: latin, form: "senatui", wantHeadword: "senatus", wantOK: true},
+		{name: "consonantal i and u", lexicon: latin, form: "Julii", wantHeadword: "Iulius", wantOK: true},
+		{name: "present plural", lexicon: latin, form: "amant", wantHeadword: "amo", wantOK: true},
+		{name: "perfect with a consonantal u", lexicon: latin, form: "amavit", wantHeadword: "amo", wantOK: true},
+		{name: "enclitic -que on an inflected form", lexicon: latin, form: "populumque", wantHeadword: "populus", wantEnclitic: "and", wantOK: true},
+		{name: "enclitic -ve", lexicon: latin, form: "rosave", wantHeadword: "rosa", wantEnclitic: "or", wantOK: true},
+		{name: "enclitic -ne", lexicon: latin, form: "puellaene", wantHeadword: "puella", wantEnclitic: "(question)", wantOK: true},
+		{name: "unknown word", lexicon: latin, form: "xyzzy"},
+		{name: "Greek genitive", lexicon: greek, form: "λόγου", wantHeadword: "λόγος", wantOK: true},
+		{name: "Greek dative plural", lexicon: greek, form: "ἀνθρώποις", wantHeadword: "ἄνθρωπος", wantOK: true},
+		{name: "Greek first declension genitive", lexicon: greek, form: "ψυχῆς", wantHeadword: "ψυχή", wantOK: true},
+		{name: "Greek present plural", lexicon: greek, form: "λύουσι", wantHeadword: "λύω", wantOK: true},
+		{name: "Greek unknown word", lexicon: greek, form: "θάλασσα"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry, enclitic, ok := lemmatize(normalizeToken(test.form, test.lexicon.Script), test.lexicon)
+			if ok != test.wantOK || entry.Headword != test.wantHeadword || enclitic != test.wantEnclitic {
+				t.Errorf("lemmatize(%q) = %q, %q, %v, want %q, %q, %v",
+					test.form, entry.Headword, enclitic, ok, test.wantHeadword, test.wantEnclitic, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestLemmatizeAllOrder(t *testing.T) {
+	// "amat" is a headword itself and also a form of "amo"; "amatque" only splits off the enclitic
+	lexicon := lexiconOf("latin", "amat", "lover", "amo", "love")
+
+	var got []string
+	for _, analysis := range lemmatizeAll("amatque", lexicon) {
+		got = append(got, analysis.entry.Headword+"+"+analysis.enclitic)
+	}
+	if want := []string{"amat+and", "amo+and"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lemmatizeAll(amatque) = %q, want %q", got, want)
+	}
+
+	got = nil
+	for _, analysis := range lemmatizeAll("amat", lexicon) {
+		got = append(got, analysis.entry.Headword)
+	}
+	if want := []string{"amat", "amo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lemmatizeAll(amat) = %q, want the headword before the inflected reading %q", got, want)
+	}
+}
+
+func TestLemmatizeFallback(t *testing.T) {
+	fallback := lexiconOf("latin", "rex", "king", "puella", "girl")
+	lexicon := lexiconOf("latin", "puella", "Mädchen")
+	lexicon.fallback = fallback
+
+	tests := []struct {
+		form      string
+		wantGloss string
+	}{
+		{form: "puellam", wantGloss: "Mädchen"},
+		{form: "rex", wantGloss: "king"},
+	}
+
+	for _, test := range tests {
+		entry, _, ok := lemmatize(test.form, lexicon)
+		if !ok || entry.Gloss != test.wantGloss {
+			t.Errorf("lemmatize(%q) = %q, %v, want %q", test.form, entry.Gloss, ok, test.wantGloss)
+		}
+	}
+}
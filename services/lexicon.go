@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// LexiconEntry is a single headword of a bilingual lexicon
+type LexiconEntry struct {
+	Headword     string `json:"headword"`
+	Gloss        string `json:"gloss"`
+	PartOfSpeech string `json:"pos,omitempty"`
+}
+
+// Lexicon holds the headwords and multi-word phrases known for one script
+type Lexicon struct {
+	Script       string
+	words        map[string]LexiconEntry
+	phrases      map[string]LexiconEntry
+	maxPhraseLen int
+	// fallback is consulted for forms this lexicon does not contain
+	fallback *Lexicon
+}
+
+// newLexicon creates an empty lexicon for a script
+func newLexicon(script string) *Lexicon {
+	return &Lexicon{
+		Script:       script,
+		words:        make(map[string]LexiconEntry),
+		phrases:      make(map[string]LexiconEntry),
+		maxPhraseLen: 1,
+	}
+}
+
+// Add inserts an entry, treating headwords that contain spaces as phrases
+func (l *Lexicon) Add(entry LexiconEntry) {
+	parts := strings.Fields(entry.Headword)
+	if len(parts) == 0 || entry.Gloss == "" {
+		return
+	}
+
+	normalized := make([]string, len(parts))
+	for i, part := range parts {
+		normalized[i] = normalizeToken(part, l.Script)
+	}
+
+	if len(normalized) == 1 {
+		l.words[normalized[0]] = entry
+		return
+	}
+
+	l.phrases[strings.Join(normalized, " ")] = entry
+	if len(normalized) > l.maxPhraseLen {
+		l.maxPhraseLen = len(normalized)
+	}
+}
+
+// Lookup returns the entry for a normalized word form
+func (l *Lexicon) Lookup(normalized string) (LexiconEntry, bool) {
+	entry, ok := l.words[normalized]
+	if !ok && l.fallback != nil {
+		return l.fallback.Lookup(normalized)
+	}
+	return entry, ok
+}
+
+// LookupPhrase returns the entry for a sequence of normalized word forms
+func (l *Lexicon) LookupPhrase(normalized []string) (LexiconEntry, bool) {
+	entry, ok := l.phrases[strings.Join(normalized, " ")]
+	if !ok && l.fallback != nil {
+		return l.fallback.LookupPhrase(normalized)
+	}
+	return entry, ok
+}
+
+// Size returns the number of words and phrases in the lexicon
+func (l *Lexicon) Size() int {
+	return len(l.words) + len(l.phrases)
+}
+
+// LoadLexiconSet loads the lexicons of every target language. Files directly in dir
+// translate into defaultLanguage; each subdirectory holds the lexicons of the
+// language it is named after, e.g. dir/de/latin.tsv for Latin into German.
+func LoadLexiconSet(dir, defaultLanguage string) (map[string]map[string]*Lexicon, error) {
+	set := make(map[string]map[string]*Lexicon)
+
+	lexicons, err := LoadLexicons(dir)
+	if err != nil {
+		return nil, err
+	}
+	set[strings.ToLower(defaultLanguage)] = lexicons
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lexicon directory: %v", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		language := strings.ToLower(entry.Name())
+		lexicons, err := LoadLexicons(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if existing, ok := set[language]; ok {
+			for script, lexicon := range lexicons {
+				existing[script] = lexicon
+			}
+			continue
+		}
+		set[language] = lexicons
+	}
+
+	return set, nil
+}
+
+// LoadLexicons loads every .tsv and .json lexicon file found in dir.
+// The script is taken from the file name up to the first "_" or ".",
+// so latin.tsv and latin_names.json both feed the "latin" lexicon.
+func LoadLexicons(dir string) (map[string]*Lexicon, error) {
+	lexicons := make(map[string]*Lexicon)
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lexicon directory: %v", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		name := file.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".tsv" && ext != ".json" {
+			continue
+		}
+
+		script := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+		if idx := strings.Index(script, "_"); idx > 0 {
+			script = script[:idx]
+		}
+
+		lexicon, ok := lexicons[script]
+		if !ok {
+			lexicon = newLexicon(script)
+			lexicons[script] = lexicon
+		}
+
+		path := filepath.Join(dir, name)
+		if ext == ".tsv" {
+			err = loadTSVLexicon(path, lexicon)
+		} else {
+			err = loadJSONLexicon(path, lexicon)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lexicons, nil
+}
+
+// loadTSVLexicon reads "headword<TAB>gloss[<TAB>pos]" lines, skipping blanks and # comments
+func loadTSVLexicon(path string, lexicon *Lexicon) error {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("failed to open lexicon %s: %v", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			return fmt.Errorf("invalid lexicon line %s:%d: expected headword and gloss", path, lineNum)
+		}
+
+		entry := LexiconEntry{
+			Headword: strings.TrimSpace(fields[0]),
+			Gloss:    strings.TrimSpace(fields[1]),
+		}
+		if len(fields) > 2 {
+			entry.PartOfSpeech = strings.TrimSpace(fields[2])
+		}
+		lexicon.Add(entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read lexicon %s: %v", path, err)
+	}
+	return nil
+}
+
+// loadJSONLexicon reads a JSON array of lexicon entries
+func loadJSONLexicon(path string, lexicon *Lexicon) error {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("failed to read lexicon %s: %v", path, err)
+	}
+
+	var entries []LexiconEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse lexicon %s: %v", path, err)
+	}
+
+	for _, entry := range entries {
+		lexicon.Add(entry)
+	}
+	return nil
+}
+
+// normalizeToken folds a word form to the shape used for lexicon lookups
+func normalizeToken(token, script string) string {
+	token = strings.ToLower(token)
+
+	switch script {
+	case "greek":
+		// Strip accents, breathings and iota subscripts, and unify the final sigma
+		var b strings.Builder
+		for _, r := range norm.NFD.String(token) {
+			if unicode.Is(unicode.Mn, r) {
+				continue
+			}
+			if r == 'ς' {
+				r = 'σ'
+			}
+			b.WriteRune(r)
+		}
+		return norm.NFC.String(b.String())
+	case "latin":
+		// Classical orthography does not distinguish i/j and u/v
+		token = strings.ReplaceAll(token, "j", "i")
+		token = strings.ReplaceAll(token, "v", "u")
+		return token
+	default:
+		return token
+	}
+}
@@ -0,0 +1,203 @@
+// Package ocr recognizes the text of manuscript images offline. The image is
+// binarized, split into lines and glyphs, and every glyph is matched against
+// the glyph templates of a script, which are loaded from disk.
+package ocr
+
+import (
+	"image"
+	"strings"
+	"unicode"
+
+	"ancient-script-decoder/utils"
+)
+
+// ambiguityMargin is the lead over the best-matching other character below which
+// the confidence of a glyph is reduced, down to half for a tie
+const ambiguityMargin = 0.1
+
+// dotBelow marks an uncertain letter in Leiden notation
+const dotBelow = '̣'
+
+// Glyph is a recognized character with its position in the image
+type Glyph struct {
+	Text       string
+	Confidence float64
+	Box        image.Rectangle
+	// Line is the index of the line of text the glyph belongs to, from the top
+	Line int
+	// Word is the index of the word within its line, in reading order
+	Word int
+	// Uncertain marks a glyph recognized with less confidence than the recognizer trusts
+	Uncertain bool
+}
+
+// Line is a line of text found in the image
+type Line struct {
+	Box image.Rectangle
+	// Direction is the direction the line is read in, utils.LeftToRight or utils.RightToLeft
+	Direction utils.ReadingDirection
+}
+
+// Result is the text recognized in an image
+type Result struct {
+	// Text holds one line of text per line of the image; letters recognized with
+	// low confidence carry a dot below, marking them as uncertain in Leiden notation
+	Text string
+	// Glyphs are in reading order
+	Glyphs []Glyph
+	Lines  []Line
+	// Bounds is the rectangle of the image the boxes of the glyphs and lines lie in
+	Bounds image.Rectangle
+}
+
+// Confidence returns the mean confidence of the recognized glyphs, 0 if there are none
+func (r Result) Confidence() float64 {
+	if len(r.Glyphs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, glyph := range r.Glyphs {
+		sum += glyph.Confidence
+	}
+	return sum / float64(len(r.Glyphs))
+}
+
+// Classifier matches the features of a glyph against known shapes of characters.
+// TemplateSet and SignIndex are classifiers.
+type Classifier interface {
+	// match returns the characters closest to a glyph and the confidence of the match
+	match(glyph []float64) (string, float64)
+}
+
+// Recognizer matches the glyphs of an image against templates and sign samples
+type Recognizer struct {
+	classifiers []Classifier
+	// binarizer separates ink from background, nil for Otsu's threshold
+	binarizer utils.ImageProcessingAlgorithm
+	// uncertainBelow is the confidence below which a glyph is marked as uncertain
+	uncertainBelow float64
+}
+
+// NewRecognizer creates a recognizer reading each glyph as the best match of any of the
+// classifiers; glyphs recognized with less than uncertainBelow confidence are marked as uncertain.
+// The image is binarized with binarizer, or with Otsu's threshold when it is nil.
+func NewRecognizer(uncertainBelow float64, binarizer utils.ImageProcessingAlgorithm, classifiers ...Classifier) *Recognizer {
+	return &Recognizer{classifiers: classifiers, binarizer: binarizer, uncertainBelow: uncertainBelow}
+}
+
+// Recognize reads the text of an image line by line, taking the glyphs of each line in the
+// given reading direction. Letters of lines read from right to left are often written
+// mirrored, so their glyphs are matched both as they are and mirrored.
+func (r *Recognizer) Recognize(img image.Image, direction utils.ReadingDirection) (Result, error) {
+	if len(r.classifiers) == 0 {
+		return Result{}, ErrNoTemplates
+	}
+
+	bitmap := utils.BinarizeWith(img, r.binarizer)
+	origin := img.Bounds().Min
+	result := Result{Bounds: img.Bounds()}
+	var lines []string
+
+	for _, line := range utils.NewSegmenter(direction).Segment(bitmap) {
+		lineIndex := len(result.Lines)
+		result.Lines = append(result.Lines, Line{Box: line.Box.Add(origin), Direction: line.Direction})
+
+		var b strings.Builder
+		word, glyphs := 0, len(result.Glyphs)
+		for _, glyph := range line.Glyphs {
+			if glyph.SpaceBefore && b.Len() > 0 {
+				b.WriteString(" ")
+				if len(result.Glyphs) > glyphs {
+					word++
+				}
+			}
+			if glyph.Separator {
+				continue
+			}
+
+			text, confidence := r.classify(features(bitmap, glyph.Box), line.Direction == utils.RightToLeft)
+			result.Glyphs = append(result.Glyphs, Glyph{
+				Text:       text,
+				Confidence: confidence,
+				Box:        glyph.Box.Add(origin),
+				Line:       lineIndex,
+				Word:       word,
+				Uncertain:  confidence < r.uncertainBelow,
+			})
+			b.WriteString(r.render(text, confidence))
+		}
+		if text := strings.Join(strings.Fields(b.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+	}
+
+	result.Text = strings.Join(lines, "\n")
+	return result, nil
+}
+
+// classify returns the characters closest to the features of a glyph and the confidence
+// of the match; a glyph that may be mirrored is also matched mirrored
+func (r *Recognizer) classify(glyph []float64, mayBeMirrored bool) (string, float64) {
+	text, confidence := r.match(glyph)
+	if mayBeMirrored {
+		if mirroredText, mirroredConfidence := r.match(mirror(glyph)); mirroredConfidence > confidence {
+			return mirroredText, mirroredConfidence
+		}
+	}
+	return text, confidence
+}
+
+// match returns the most confident match of the classifiers
+func (r *Recognizer) match(glyph []float64) (string, float64) {
+	best, bestConfidence := "", -1.0
+	for _, classifier := range r.classifiers {
+		if text, confidence := classifier.match(glyph); confidence > bestConfidence {
+			best, bestConfidence = text, confidence
+		}
+	}
+	return best, bestConfidence
+}
+
+// bestMatch picks the characters with the highest score among the best scores of each text.
+// The confidence of the match is the score, lowered when another text comes within the
+// ambiguity margin; ties resolve to the text sorting first.
+func bestMatch(scores map[string]float64) (string, float64) {
+	if len(scores) == 0 {
+		return "", 0
+	}
+
+	best, bestScore := "", -1.0
+	for text, score := range scores {
+		if score > bestScore || score == bestScore && text < best {
+			best, bestScore = text, score
+		}
+	}
+
+	runnerUp := 0.0
+	for text, score := range scores {
+		if text != best && score > runnerUp {
+			runnerUp = score
+		}
+	}
+
+	confidence := bestScore
+	if margin := bestScore - runnerUp; margin < ambiguityMargin {
+		confidence *= 0.5 + 0.5*margin/ambiguityMargin
+	}
+	return best, confidence
+}
+
+// render writes recognized characters, marking letters read with low confidence as uncertain
+func (r *Recognizer) render(text string, confidence float64) string {
+	if confidence >= r.uncertainBelow {
+		return text
+	}
+	var b strings.Builder
+	for _, c := range text {
+		b.WriteRune(c)
+		if unicode.IsLetter(c) {
+			b.WriteRune(dotBelow)
+		}
+	}
+	return b.String()
+}
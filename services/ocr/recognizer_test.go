@@ -0,0 +1,213 @@
+package ocr
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"ancient-script-decoder/utils"
+)
+
+// glyphScale is the size in pixels of a cell of the glyph rows when rendered
+const glyphScale = 3
+
+// renderText draws lines of words of the test letters in dark ink on a light surface,
+// one cell apart within a word and five between words. Letters given in lower case are
+// drawn mirrored, as in lines written from right to left.
+func renderText(lines ...string) *image.Gray {
+	const margin, letterGap, wordGap, lineGap = 2, 1, 5, 4
+
+	width := 0
+	for _, line := range lines {
+		cells := 0
+		for i, word := range strings.Fields(line) {
+			if i > 0 {
+				cells += wordGap
+			}
+			cells += len(word)*(7+letterGap) - letterGap
+		}
+		width = maxInt(width, cells)
+	}
+	height := len(lines)*(7+lineGap) - lineGap
+
+	img := image.NewGray(image.Rect(0, 0, (width+2*margin)*glyphScale, (height+2*margin)*glyphScale))
+	for i := range img.Pix {
+		img.Pix[i] = 230
+	}
+	for l, line := range lines {
+		x, y := margin, margin+l*(7+lineGap)
+		for i, word := range strings.Fields(line) {
+			if i > 0 {
+				x += wordGap
+			}
+			for _, letter := range word {
+				drawGlyph(img, string(letter), x, y)
+				x += 7 + letterGap
+			}
+			x -= letterGap
+		}
+	}
+	return img
+}
+
+// drawGlyph draws a test letter with its top left corner at the cell x, y
+func drawGlyph(img *image.Gray, letter string, x, y int) {
+	rows := glyphRows[strings.ToUpper(letter)]
+	mirrored := letter != strings.ToUpper(letter)
+	for gy, row := range rows {
+		for gx, c := range row {
+			if mirrored {
+				c = rune(row[len(row)-1-gx])
+			}
+			if c != 'X' {
+				continue
+			}
+			for py := 0; py < glyphScale; py++ {
+				for px := 0; px < glyphScale; px++ {
+					img.SetGray((x+gx)*glyphScale+px, (y+gy)*glyphScale+py, color.Gray{Y: 20})
+				}
+			}
+		}
+	}
+}
+
+// erase clears a rectangle of cells of a rendered image, as damage to the surface
+func erase(img *image.Gray, cells image.Rectangle) {
+	for y := cells.Min.Y * glyphScale; y < cells.Max.Y*glyphScale; y++ {
+		for x := cells.Min.X * glyphScale; x < cells.Max.X*glyphScale; x++ {
+			img.SetGray(x, y, color.Gray{Y: 230})
+		}
+	}
+}
+
+func TestRecognize(t *testing.T) {
+	recognizer := NewRecognizer(0.8, nil, loadTemplates(t, "L", "T", "O"))
+	result, err := recognizer.Recognize(renderText("LOT TO", "TOLL"), utils.LeftToRight)
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+
+	if want := "LOT TO\nTOLL"; result.Text != want {
+		t.Errorf("Recognize() text = %q, want %q", result.Text, want)
+	}
+	if len(result.Lines) != 2 {
+		t.Fatalf("Recognize() lines = %d, want 2", len(result.Lines))
+	}
+
+	wantGlyphs := []struct {
+		text       string
+		line, word int
+	}{
+		{"L", 0, 0}, {"O", 0, 0}, {"T", 0, 0}, {"T", 0, 1}, {"O", 0, 1},
+		{"T", 1, 0}, {"O", 1, 0}, {"L", 1, 0}, {"L", 1, 0},
+	}
+	if len(result.Glyphs) != len(wantGlyphs) {
+		t.Fatalf("Recognize() glyphs = %d, want %d", len(result.Glyphs), len(wantGlyphs))
+	}
+	for i, want := range wantGlyphs {
+		glyph := result.Glyphs[i]
+		if glyph.Text != want.text || glyph.Line != want.line || glyph.Word != want.word {
+			t.Errorf("glyph %d = %q in line %d word %d, want %q in line %d word %d",
+				i, glyph.Text, glyph.Line, glyph.Word, want.text, want.line, want.word)
+		}
+		if glyph.Confidence < 0.95 || glyph.Uncertain {
+			t.Errorf("glyph %d confidence = %.2f uncertain %v, want a certain match", i, glyph.Confidence, glyph.Uncertain)
+		}
+		if !glyph.Box.In(result.Lines[want.line].Box) {
+			t.Errorf("glyph %d box %v lies outside its line %v", i, glyph.Box, result.Lines[want.line].Box)
+		}
+	}
+	if confidence := result.Confidence(); confidence < 0.95 {
+		t.Errorf("Confidence() = %.2f, want at least 0.95", confidence)
+	}
+}
+
+func TestRecognizeUncertainLetters(t *testing.T) {
+	// The lower bar of the E is worn away but for a stub, leaving it between E and F
+	img := renderText("TE")
+	erase(img, image.Rect(2+8+3, 2+6, 2+8+7, 2+7))
+
+	recognizer := NewRecognizer(0.8, nil, loadTemplates(t, "T", "E", "F"))
+	result, err := recognizer.Recognize(img, utils.LeftToRight)
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	if len(result.Glyphs) != 2 {
+		t.Fatalf("Recognize() glyphs = %d, want 2", len(result.Glyphs))
+	}
+
+	worn := result.Glyphs[1]
+	if worn.Text != "E" && worn.Text != "F" {
+		t.Errorf("worn glyph = %q, want E or F", worn.Text)
+	}
+	if !worn.Uncertain || worn.Confidence >= 0.8 {
+		t.Errorf("worn glyph confidence = %.2f uncertain %v, want below 0.8 and uncertain", worn.Confidence, worn.Uncertain)
+	}
+	if result.Glyphs[0].Uncertain {
+		t.Errorf("glyph T is uncertain with confidence %.2f", result.Glyphs[0].Confidence)
+	}
+	if want := "T" + worn.Text + "̣"; result.Text != want {
+		t.Errorf("Recognize() text = %q, want %q with a dot below the uncertain letter", result.Text, want)
+	}
+}
+
+func TestRecognizeRightToLeft(t *testing.T) {
+	recognizer := NewRecognizer(0.8, nil, loadTemplates(t, "L", "T", "O"))
+	tests := []struct {
+		name      string
+		lines     []string
+		direction utils.ReadingDirection
+		want      string
+	}{
+		// Mirrored letters drawn from left to right read as LOT from the right
+		{name: "right to left", lines: []string{"Tol"}, direction: utils.RightToLeft, want: "LOT"},
+		{name: "boustrophedon", lines: []string{"LOT", "Tol"}, direction: utils.Boustrophedon, want: "LOT\nLOT"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := recognizer.Recognize(renderText(test.lines...), test.direction)
+			if err != nil {
+				t.Fatalf("Recognize() error = %v", err)
+			}
+			if result.Text != test.want {
+				t.Errorf("Recognize() text = %q, want %q", result.Text, test.want)
+			}
+			if last := result.Lines[len(result.Lines)-1]; last.Direction != utils.RightToLeft {
+				t.Errorf("last line direction = %s, want %s", last.Direction, utils.RightToLeft)
+			}
+		})
+	}
+}
+
+func TestRecognizeWithoutTemplates(t *testing.T) {
+	_, err := NewRecognizer(0.8, nil).Recognize(renderText("L"), utils.LeftToRight)
+	if !errors.Is(err, ErrNoTemplates) {
+		t.Errorf("Recognize() error = %v, want %v", err, ErrNoTemplates)
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		scores         map[string]float64
+		want           string
+		wantConfidence float64
+	}{
+		{name: "clear lead", scores: map[string]float64{"E": 0.9, "F": 0.5}, want: "E", wantConfidence: 0.9},
+		{name: "lead within the margin", scores: map[string]float64{"E": 0.9, "F": 0.85}, want: "E", wantConfidence: 0.675},
+		{name: "tie", scores: map[string]float64{"F": 0.8, "E": 0.8}, want: "E", wantConfidence: 0.4},
+		{name: "no scores", scores: map[string]float64{}, want: "", wantConfidence: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text, confidence := bestMatch(test.scores)
+			if text != test.want || confidence < test.wantConfidence-1e-9 || confidence > test.wantConfidence+1e-9 {
+				t.Errorf("bestMatch() = %q, %v, want %q, %v", text, confidence, test.want, test.wantConfidence)
+			}
+		})
+	}
+}
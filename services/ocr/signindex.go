@@ -0,0 +1,106 @@
+package ocr
+
+import (
+	"errors"
+	"image"
+	"math"
+
+	"ancient-script-decoder/utils"
+)
+
+// ErrBlankSample is returned for a sign sample whose image shows no ink
+var ErrBlankSample = errors.New("sign sample shows no ink")
+
+// Sample is a labelled image of a single sign
+type Sample struct {
+	Text  string
+	Image image.Image
+}
+
+// SignIndex matches glyphs against the labelled samples of a sign inventory by the
+// normalized cross-correlation of their shapes. Unlike the Dice coefficient of the
+// templates, the correlation discounts the ink a glyph and a sample merely share by
+// being dense, which suits signs drawn with thick strokes such as cuneiform wedges.
+type SignIndex struct {
+	Script  string
+	entries []Template
+}
+
+// NewSignIndex indexes the samples of a script, binarizing them with binarizer, or with
+// Otsu's threshold when it is nil, as the recognizer binarizes the images it reads; samples
+// showing no ink are left out
+func NewSignIndex(script string, samples []Sample, binarizer utils.ImageProcessingAlgorithm) *SignIndex {
+	index := &SignIndex{Script: script}
+	for _, sample := range samples {
+		glyph, err := sampleFeatures(sample.Image, binarizer)
+		if err != nil {
+			continue
+		}
+		index.entries = append(index.entries, Template{Text: sample.Text, features: normalize(glyph)})
+	}
+	return index
+}
+
+// ValidateSample checks that an image of a sign shows ink the index can match against
+func ValidateSample(img image.Image) error {
+	_, err := sampleFeatures(img, nil)
+	return err
+}
+
+// Size returns the number of indexed samples
+func (s *SignIndex) Size() int {
+	return len(s.entries)
+}
+
+// match returns the characters of the sample best correlated with a glyph and the
+// confidence of the match, which is lowered when a sample of another sign comes close
+func (s *SignIndex) match(glyph []float64) (string, float64) {
+	normalized := normalize(glyph)
+	scores := make(map[string]float64)
+	for _, entry := range s.entries {
+		var correlation float64
+		for i, value := range normalized {
+			correlation += value * entry.features[i]
+		}
+		// Anticorrelated shapes do not match at all
+		if correlation > scores[entry.Text] {
+			scores[entry.Text] = correlation
+		}
+	}
+	return bestMatch(scores)
+}
+
+// sampleFeatures binarizes the image of a sign and scales its ink into the matching grid
+func sampleFeatures(img image.Image, binarizer utils.ImageProcessingAlgorithm) ([]float64, error) {
+	bitmap := utils.BinarizeWith(img, binarizer)
+	box := inkBounds(bitmap, image.Rect(0, 0, bitmap.Width, bitmap.Height))
+	if box.Empty() {
+		return nil, ErrBlankSample
+	}
+	return features(bitmap, box), nil
+}
+
+// normalize subtracts the mean from a feature grid and scales it to unit length,
+// so that the dot product of two normalized grids is their correlation coefficient
+func normalize(grid []float64) []float64 {
+	var mean float64
+	for _, value := range grid {
+		mean += value
+	}
+	mean /= float64(len(grid))
+
+	normalized := make([]float64, len(grid))
+	var norm float64
+	for i, value := range grid {
+		normalized[i] = value - mean
+		norm += normalized[i] * normalized[i]
+	}
+	if norm == 0 {
+		return normalized
+	}
+	norm = math.Sqrt(norm)
+	for i := range normalized {
+		normalized[i] /= norm
+	}
+	return normalized
+}
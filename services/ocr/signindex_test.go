@@ -0,0 +1,73 @@
+package ocr
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	"ancient-script-decoder/utils"
+)
+
+// blankImage is a sign sample showing only the surface
+func blankImage() image.Image {
+	img := image.NewGray(image.Rect(0, 0, 20, 20))
+	for i := range img.Pix {
+		img.Pix[i] = 230
+	}
+	return img
+}
+
+func TestNewSignIndex(t *testing.T) {
+	index := NewSignIndex("latin", []Sample{
+		{Text: "L", Image: renderText("L")},
+		{Text: "T", Image: renderText("T")},
+		{Text: "O", Image: blankImage()},
+	}, nil)
+	if index.Size() != 2 {
+		t.Errorf("Size() = %d, want 2 with the blank sample left out", index.Size())
+	}
+}
+
+func TestValidateSample(t *testing.T) {
+	if err := ValidateSample(renderText("O")); err != nil {
+		t.Errorf("ValidateSample() error = %v, want nil", err)
+	}
+	if err := ValidateSample(blankImage()); !errors.Is(err, ErrBlankSample) {
+		t.Errorf("ValidateSample() of a blank image error = %v, want %v", err, ErrBlankSample)
+	}
+}
+
+func TestRecognizeWithSignIndex(t *testing.T) {
+	index := NewSignIndex("latin", []Sample{
+		{Text: "L", Image: renderText("L")},
+		{Text: "T", Image: renderText("T")},
+		{Text: "O", Image: renderText("O")},
+	}, nil)
+
+	result, err := NewRecognizer(0.8, nil, index).Recognize(renderText("LOT TO"), utils.LeftToRight)
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	if result.Text != "LOT TO" {
+		t.Errorf("Recognize() text = %q, want %q", result.Text, "LOT TO")
+	}
+	for i, glyph := range result.Glyphs {
+		if glyph.Confidence < 0.95 {
+			t.Errorf("glyph %d %q confidence = %.2f, want at least 0.95", i, glyph.Text, glyph.Confidence)
+		}
+	}
+}
+
+func TestRecognizeWithTemplatesAndSignIndex(t *testing.T) {
+	// The templates know L and T and the sign index O; each glyph takes the better match
+	index := NewSignIndex("latin", []Sample{{Text: "O", Image: renderText("O")}}, nil)
+	recognizer := NewRecognizer(0.8, nil, loadTemplates(t, "L", "T"), index)
+
+	result, err := recognizer.Recognize(renderText("LOT"), utils.LeftToRight)
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	if result.Text != "LOT" {
+		t.Errorf("Recognize() text = %q, want %q", result.Text, "LOT")
+	}
+}
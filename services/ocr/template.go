@@ -0,0 +1,243 @@
+package ocr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ancient-script-decoder/utils"
+)
+
+// ErrNoTemplates is returned when neither glyph templates nor sign samples are loaded for a script
+var ErrNoTemplates = errors.New("no glyph templates or sign samples loaded")
+
+// gridSize is the side of the square grid glyphs are scaled into for matching
+const gridSize = 16
+
+// samplesPerCell is the number of points sampled along each side of a grid cell
+const samplesPerCell = 4
+
+// Template is the shape of a character, scaled into the matching grid
+type Template struct {
+	Text     string
+	features []float64
+}
+
+// TemplateSet holds the glyph templates of a script; a character may have several templates
+type TemplateSet struct {
+	Script    string
+	Templates []Template
+}
+
+// match returns the characters of the template closest to a glyph and the confidence
+// of the match, which is lowered when a template of another character comes close
+func (s *TemplateSet) match(glyph []float64) (string, float64) {
+	scores := make(map[string]float64)
+	for _, template := range s.Templates {
+		if score := similarity(glyph, template.features); score > scores[template.Text] {
+			scores[template.Text] = score
+		}
+	}
+	return bestMatch(scores)
+}
+
+// LoadTemplateDir loads a template set from every .txt file of a directory, keyed by
+// script; the file name without extension names the script, e.g. latin.txt
+func LoadTemplateDir(dir string) (map[string]*TemplateSet, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glyph template directory: %v", err)
+	}
+
+	sets := make(map[string]*TemplateSet)
+	for _, file := range files {
+		if file.IsDir() || strings.ToLower(filepath.Ext(file.Name())) != ".txt" {
+			continue
+		}
+		script := strings.ToLower(strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())))
+		set, err := LoadTemplates(filepath.Join(dir, file.Name()), script)
+		if err != nil {
+			return nil, err
+		}
+		sets[script] = set
+	}
+	return sets, nil
+}
+
+// LoadTemplates reads the glyph templates of a script from a text file. Each template
+// starts with a line "= <text>" naming the characters it stands for, followed by rows
+// drawing the glyph with X for ink and . for background. Lines starting with # are comments.
+func LoadTemplates(path, script string) (*TemplateSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open glyph templates: %v", err)
+	}
+	defer file.Close()
+
+	set := &TemplateSet{Script: script}
+	var text string
+	var rows []string
+	finish := func(lineNumber int) error {
+		if text == "" {
+			return nil
+		}
+		if len(rows) == 0 {
+			return fmt.Errorf("%s:%d: template %q has no rows", path, lineNumber, text)
+		}
+		bitmap := utils.NewBitmap(len(rows[0]), len(rows))
+		for y, row := range rows {
+			if len(row) != bitmap.Width {
+				return fmt.Errorf("%s:%d: rows of template %q differ in width", path, lineNumber, text)
+			}
+			for x, c := range row {
+				bitmap.Set(x, y, c == 'X')
+			}
+		}
+		box := inkBounds(bitmap, image.Rect(0, 0, bitmap.Width, bitmap.Height))
+		if box.Empty() {
+			return fmt.Errorf("%s:%d: template %q has no ink", path, lineNumber, text)
+		}
+		set.Templates = append(set.Templates, Template{Text: text, features: features(bitmap, box)})
+		text, rows = "", nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "="):
+			if err := finish(lineNumber); err != nil {
+				return nil, err
+			}
+			text = strings.TrimSpace(strings.TrimPrefix(line, "="))
+			if text == "" {
+				return nil, fmt.Errorf("%s:%d: template without text", path, lineNumber)
+			}
+		case strings.Trim(line, "X.") == "":
+			if text == "" {
+				return nil, fmt.Errorf("%s:%d: glyph rows before a template header", path, lineNumber)
+			}
+			rows = append(rows, line)
+		default:
+			return nil, fmt.Errorf("%s:%d: unexpected line %q", path, lineNumber, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read glyph templates: %v", err)
+	}
+	if err := finish(lineNumber); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// inkBounds returns the smallest rectangle within box containing all of its ink
+func inkBounds(b *utils.Bitmap, box image.Rectangle) image.Rectangle {
+	bounds := image.Rectangle{}
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			if b.At(x, y) {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return bounds
+}
+
+// features scales the ink of a glyph box into the matching grid, keeping its aspect ratio
+// and centring it. Each cell holds the share of ink sampled in it; the grid is then
+// smoothed so that small shifts and differences in stroke width weigh less.
+func features(b *utils.Bitmap, box image.Rectangle) []float64 {
+	scale := float64(maxInt(box.Dx(), box.Dy())) / gridSize
+	offsetX := (gridSize - float64(box.Dx())/scale) / 2
+	offsetY := (gridSize - float64(box.Dy())/scale) / 2
+
+	grid := make([]float64, gridSize*gridSize)
+	for gy := 0; gy < gridSize; gy++ {
+		for gx := 0; gx < gridSize; gx++ {
+			hits := 0
+			for sy := 0; sy < samplesPerCell; sy++ {
+				for sx := 0; sx < samplesPerCell; sx++ {
+					fx := (float64(gx) + (float64(sx)+0.5)/samplesPerCell - offsetX) * scale
+					fy := (float64(gy) + (float64(sy)+0.5)/samplesPerCell - offsetY) * scale
+					if fx < 0 || fy < 0 || fx >= float64(box.Dx()) || fy >= float64(box.Dy()) {
+						continue
+					}
+					if b.At(box.Min.X+int(fx), box.Min.Y+int(fy)) {
+						hits++
+					}
+				}
+			}
+			grid[gy*gridSize+gx] = float64(hits) / (samplesPerCell * samplesPerCell)
+		}
+	}
+
+	smoothed := make([]float64, len(grid))
+	for gy := 0; gy < gridSize; gy++ {
+		for gx := 0; gx < gridSize; gx++ {
+			sum, weight := 0.0, 0.0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					x, y := gx+dx, gy+dy
+					if x < 0 || y < 0 || x >= gridSize || y >= gridSize {
+						continue
+					}
+					w := 1.0
+					if dx == 0 && dy == 0 {
+						w = 2
+					}
+					sum += grid[y*gridSize+x] * w
+					weight += w
+				}
+			}
+			smoothed[gy*gridSize+gx] = sum / weight
+		}
+	}
+	return smoothed
+}
+
+// mirror flips a feature grid from left to right, turning a glyph written in a
+// right-to-left line into the form of the templates
+func mirror(grid []float64) []float64 {
+	mirrored := make([]float64, len(grid))
+	for gy := 0; gy < gridSize; gy++ {
+		for gx := 0; gx < gridSize; gx++ {
+			mirrored[gy*gridSize+gx] = grid[gy*gridSize+gridSize-1-gx]
+		}
+	}
+	return mirrored
+}
+
+// similarity compares two feature grids by the overlap of their ink (the Dice coefficient),
+// from 0 for disjoint shapes to 1 for identical ones
+func similarity(a, b []float64) float64 {
+	var overlap, total float64
+	for i := range a {
+		if a[i] < b[i] {
+			overlap += a[i]
+		} else {
+			overlap += b[i]
+		}
+		total += a[i] + b[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	return 2 * overlap / total
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
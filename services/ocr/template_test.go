@@ -0,0 +1,143 @@
+package ocr
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// glyphRows draws the letters the tests recognize, with X for ink
+var glyphRows = map[string][]string{
+	"L": {
+		"X......",
+		"X......",
+		"X......",
+		"X......",
+		"X......",
+		"X......",
+		"XXXXXXX",
+	},
+	"T": {
+		"XXXXXXX",
+		"...X...",
+		"...X...",
+		"...X...",
+		"...X...",
+		"...X...",
+		"...X...",
+	},
+	"O": {
+		".XXXXX.",
+		"X.....X",
+		"X.....X",
+		"X.....X",
+		"X.....X",
+		"X.....X",
+		".XXXXX.",
+	},
+	"E": {
+		"XXXXXXX",
+		"X......",
+		"X......",
+		"XXXXXX.",
+		"X......",
+		"X......",
+		"XXXXXXX",
+	},
+	"F": {
+		"XXXXXXX",
+		"X......",
+		"X......",
+		"XXXXXX.",
+		"X......",
+		"X......",
+		"X......",
+	},
+}
+
+// templateFile writes the glyphs as a template file and returns its path
+func templateFile(t *testing.T, letters ...string) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("# Test letters\n")
+	for _, letter := range letters {
+		b.WriteString("= " + letter + "\n")
+		b.WriteString(strings.Join(glyphRows[letter], "\n") + "\n\n")
+	}
+	path := filepath.Join(t.TempDir(), "latin.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	return path
+}
+
+// loadTemplates loads a template set of the glyphs
+func loadTemplates(t *testing.T, letters ...string) *TemplateSet {
+	t.Helper()
+	set, err := LoadTemplates(templateFile(t, letters...), "latin")
+	if err != nil {
+		t.Fatalf("LoadTemplates() error = %v", err)
+	}
+	return set
+}
+
+func TestLoadTemplates(t *testing.T) {
+	set := loadTemplates(t, "L", "T", "O")
+	var texts []string
+	for _, template := range set.Templates {
+		texts = append(texts, template.Text)
+	}
+	if got := strings.Join(texts, ","); got != "L,T,O" || set.Script != "latin" {
+		t.Errorf("LoadTemplates() = %s templates %s, want latin templates L,T,O", set.Script, got)
+	}
+	if score := similarity(set.Templates[0].features, set.Templates[0].features); score != 1 {
+		t.Errorf("similarity() of a template with itself = %v, want 1", score)
+	}
+	if score := similarity(set.Templates[0].features, set.Templates[1].features); score > 0.5 {
+		t.Errorf("similarity() of L and T = %v, want at most 0.5", score)
+	}
+}
+
+func TestLoadTemplatesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "rows of different width", content: "= I\nXX\nX\n", wantErr: `rows of template "I" differ in width`},
+		{name: "template without ink", content: "= I\n...\n...\n", wantErr: `template "I" has no ink`},
+		{name: "template without rows", content: "= I\n= J\nX\n", wantErr: `template "I" has no rows`},
+		{name: "rows before a header", content: "XX\n", wantErr: "glyph rows before a template header"},
+		{name: "header without text", content: "=\nXX\n", wantErr: "template without text"},
+		{name: "unexpected line", content: "= I\nX#X\n", wantErr: `unexpected line "X#X"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "latin.txt")
+			if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+				t.Fatalf("os.WriteFile() error = %v", err)
+			}
+			_, err := LoadTemplates(path, "latin")
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("LoadTemplates() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadTemplateDir(t *testing.T) {
+	dir := filepath.Dir(templateFile(t, "L"))
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a template"), 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	sets, err := LoadTemplateDir(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplateDir() error = %v", err)
+	}
+	if len(sets) != 1 || sets["latin"] == nil || len(sets["latin"].Templates) != 1 {
+		t.Errorf("LoadTemplateDir() = %v, want the latin templates only", sets)
+	}
+}
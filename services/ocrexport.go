@@ -0,0 +1,408 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"ancient-script-decoder/models"
+)
+
+// Formats the text read from a manuscript image can be exported in
+const (
+	// OCRFormatHOCR is hOCR, OCR results embedded in XHTML
+	OCRFormatHOCR = "hocr"
+	// OCRFormatALTO is ALTO XML version 4, as ingested by digital library systems
+	OCRFormatALTO = "alto"
+)
+
+// ocrSoftware names the system that produced the exported OCR
+const ocrSoftware = "ancient-script-decoder"
+
+// hocrDoctype declares exported hOCR documents as XHTML
+const hocrDoctype = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">` + "\n"
+
+// hocrCapabilities lists the hOCR elements exported documents use
+const hocrCapabilities = "ocr_page ocr_carea ocr_par ocr_line ocrx_word ocrx_cinfo"
+
+// altoNamespace and altoSchema identify ALTO version 4
+const (
+	altoNamespace = "http://www.loc.gov/standards/alto/ns-v4#"
+	altoSchema    = "http://www.loc.gov/standards/alto/v4/alto-4-2.xsd"
+)
+
+// xsiNamespace is the namespace of the xsi:schemaLocation attribute
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// OCRDocument is the text read from a manuscript image, ready to be exported
+type OCRDocument struct {
+	Layout *models.PageLayout
+	// Script is the script the text was read in; its language is declared in the document
+	Script string
+	// Image is the file name of the image, empty if it is not known
+	Image string
+}
+
+// ocrWord is a word of a line of the layout: a run of glyphs with the same word index
+type ocrWord struct {
+	Box        models.BoundingBox
+	Glyphs     []models.GlyphLayout
+	Confidence float64
+}
+
+// text returns the characters of the word
+func (w ocrWord) text() string {
+	var b strings.Builder
+	for _, glyph := range w.Glyphs {
+		b.WriteString(glyph.Text)
+	}
+	return b.String()
+}
+
+// ocrWords groups the glyphs of a line into words in reading order, each with the box
+// enclosing its glyphs and their mean confidence
+func ocrWords(line models.LineLayout) []ocrWord {
+	var words []ocrWord
+	for i, glyph := range line.Glyphs {
+		if i == 0 || glyph.Word != line.Glyphs[i-1].Word {
+			words = append(words, ocrWord{Box: glyph.Box})
+		}
+		word := &words[len(words)-1]
+		word.Box = unionBox(word.Box, glyph.Box)
+		word.Glyphs = append(word.Glyphs, glyph)
+		word.Confidence += glyph.Confidence
+	}
+	for i := range words {
+		words[i].Confidence /= float64(len(words[i].Glyphs))
+	}
+	return words
+}
+
+// unionBox returns the smallest box enclosing two boxes
+func unionBox(a, b models.BoundingBox) models.BoundingBox {
+	x0, y0 := min(a.X, b.X), min(a.Y, b.Y)
+	x1, y1 := max(a.X+a.Width, b.X+b.Width), max(a.Y+a.Height, b.Y+b.Height)
+	return models.BoundingBox{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+}
+
+// textBox returns the box enclosing the lines of a layout, the whole page if it has none
+func textBox(layout *models.PageLayout) models.BoundingBox {
+	if len(layout.Lines) == 0 {
+		return models.BoundingBox{Width: layout.Width, Height: layout.Height}
+	}
+	box := layout.Lines[0].Box
+	for _, line := range layout.Lines[1:] {
+		box = unionBox(box, line.Box)
+	}
+	return box
+}
+
+// hocrDocument is the root of an exported hOCR document
+type hocrDocument struct {
+	XMLName xml.Name   `xml:"http://www.w3.org/1999/xhtml html"`
+	XMLLang string     `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Lang    string     `xml:"lang,attr,omitempty"`
+	Title   string     `xml:"head>title"`
+	Meta    []hocrMeta `xml:"head>meta"`
+	Page    hocrNode   `xml:"body>div"`
+}
+
+// hocrMeta is a meta element of the head of an hOCR document
+type hocrMeta struct {
+	HTTPEquiv string `xml:"http-equiv,attr,omitempty"`
+	Name      string `xml:"name,attr,omitempty"`
+	Content   string `xml:"content,attr"`
+}
+
+// hocrNode is an element of the body of an hOCR document; the class names the kind of
+// element and the title holds its properties, such as its bounding box
+type hocrNode struct {
+	XMLName  xml.Name
+	Class    string     `xml:"class,attr"`
+	ID       string     `xml:"id,attr"`
+	Dir      string     `xml:"dir,attr,omitempty"`
+	Title    string     `xml:"title,attr"`
+	Text     string     `xml:",chardata"`
+	Children []hocrNode `xml:",any"`
+}
+
+// WriteHOCR writes the text read from an image as an hOCR document: the page holds one
+// content area and paragraph with the lines of text, each line its words and each word
+// its characters, with their bounding boxes in pixels and their confidences in percent
+func WriteHOCR(w io.Writer, doc OCRDocument) error {
+	layout := doc.Layout
+	if layout == nil {
+		return fmt.Errorf("no layout to export as hOCR")
+	}
+	lang := scriptLanguageCodes[doc.Script]
+
+	pageTitle := hocrBox(models.BoundingBox{Width: layout.Width, Height: layout.Height}) + "; ppageno 0"
+	if doc.Image != "" {
+		pageTitle = fmt.Sprintf("image %s; %s", strconv.Quote(doc.Image), pageTitle)
+	}
+	box := textBox(layout)
+	par := hocrNode{XMLName: xml.Name{Local: "p"}, Class: "ocr_par", ID: "par_1_1", Title: hocrBox(box)}
+	if layout.Direction == "rtl" {
+		par.Dir = "rtl"
+	}
+
+	for i, line := range layout.Lines {
+		if len(line.Glyphs) == 0 {
+			continue
+		}
+		lineNode := hocrNode{
+			XMLName: xml.Name{Local: "span"},
+			Class:   "ocr_line",
+			ID:      fmt.Sprintf("line_1_%d", i+1),
+			Dir:     line.Direction,
+			Title:   hocrBox(line.Box),
+		}
+		for j, word := range ocrWords(line) {
+			wordNode := hocrNode{
+				XMLName: xml.Name{Local: "span"},
+				Class:   "ocrx_word",
+				ID:      fmt.Sprintf("word_1_%d_%d", i+1, j+1),
+				Title:   fmt.Sprintf("%s; x_wconf %d", hocrBox(word.Box), int(word.Confidence*100+0.5)),
+			}
+			for _, glyph := range word.Glyphs {
+				wordNode.Children = append(wordNode.Children, hocrNode{
+					XMLName: xml.Name{Local: "span"},
+					Class:   "ocrx_cinfo",
+					ID:      fmt.Sprintf("%s_%d", wordNode.ID, len(wordNode.Children)+1),
+					Title:   fmt.Sprintf("%s; x_conf %.2f", hocrBox(glyph.Box), glyph.Confidence*100),
+					Text:    glyph.Text,
+				})
+			}
+			lineNode.Children = append(lineNode.Children, wordNode)
+		}
+		par.Children = append(par.Children, lineNode)
+	}
+
+	html := hocrDocument{
+		XMLLang: lang,
+		Lang:    lang,
+		Meta: []hocrMeta{
+			{HTTPEquiv: "Content-Type", Content: "text/html;charset=utf-8"},
+			{Name: "ocr-system", Content: ocrSoftware},
+			{Name: "ocr-capabilities", Content: hocrCapabilities},
+		},
+		Page: hocrNode{
+			Class: "ocr_page",
+			ID:    "page_1",
+			Title: pageTitle,
+			Children: []hocrNode{{
+				XMLName:  xml.Name{Local: "div"},
+				Class:    "ocr_carea",
+				ID:       "block_1_1",
+				Title:    hocrBox(box),
+				Children: []hocrNode{par},
+			}},
+		},
+	}
+	return writeOCRXML(w, "hOCR", hocrDoctype, html)
+}
+
+// hocrBox formats a box as an hOCR bbox property, from its top left to its bottom right corner
+func hocrBox(box models.BoundingBox) string {
+	return fmt.Sprintf("bbox %d %d %d %d", box.X, box.Y, box.X+box.Width, box.Y+box.Height)
+}
+
+// altoDocument is the root of an exported ALTO document
+type altoDocument struct {
+	XMLName        xml.Name `xml:"http://www.loc.gov/standards/alto/ns-v4# alto"`
+	XSI            string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	Unit           string   `xml:"Description>MeasurementUnit"`
+	FileName       string   `xml:"Description>sourceImageInformation>fileName,omitempty"`
+	Processing     altoOCR  `xml:"Description>OCRProcessing"`
+	Page           altoPage `xml:"Layout>Page"`
+}
+
+// altoOCR records the software that read the text
+type altoOCR struct {
+	ID       string `xml:"ID,attr"`
+	Software string `xml:"ocrProcessingStep>processingSoftware>softwareName"`
+}
+
+// altoPage is the page of the image with the block of text it holds
+type altoPage struct {
+	ID         string    `xml:"ID,attr"`
+	ImageNr    int       `xml:"PHYSICAL_IMG_NR,attr"`
+	Width      int       `xml:"WIDTH,attr"`
+	Height     int       `xml:"HEIGHT,attr"`
+	Confidence string    `xml:"PC,attr,omitempty"`
+	PrintSpace altoBlock `xml:"PrintSpace"`
+}
+
+// altoBlock is the print space of a page or a block of text within it
+type altoBlock struct {
+	ID     string      `xml:"ID,attr,omitempty"`
+	Lang   string      `xml:"LANG,attr,omitempty"`
+	HPos   int         `xml:"HPOS,attr"`
+	VPos   int         `xml:"VPOS,attr"`
+	Width  int         `xml:"WIDTH,attr"`
+	Height int         `xml:"HEIGHT,attr"`
+	Blocks []altoBlock `xml:"TextBlock,omitempty"`
+	Lines  []altoLine  `xml:"TextLine,omitempty"`
+}
+
+// altoLine is a line of text; its content alternates words and the spaces between them
+type altoLine struct {
+	ID      string        `xml:"ID,attr"`
+	HPos    int           `xml:"HPOS,attr"`
+	VPos    int           `xml:"VPOS,attr"`
+	Width   int           `xml:"WIDTH,attr"`
+	Height  int           `xml:"HEIGHT,attr"`
+	Content []interface{} `xml:",any"`
+}
+
+// altoString is a word with its word confidence from 0 to 1
+type altoString struct {
+	XMLName    xml.Name    `xml:"String"`
+	ID         string      `xml:"ID,attr"`
+	HPos       int         `xml:"HPOS,attr"`
+	VPos       int         `xml:"VPOS,attr"`
+	Width      int         `xml:"WIDTH,attr"`
+	Height     int         `xml:"HEIGHT,attr"`
+	Content    string      `xml:"CONTENT,attr"`
+	Confidence string      `xml:"WC,attr"`
+	Glyphs     []altoGlyph `xml:"Glyph"`
+}
+
+// altoGlyph is a character of a word with its glyph confidence from 0 to 1
+type altoGlyph struct {
+	ID         string `xml:"ID,attr"`
+	HPos       int    `xml:"HPOS,attr"`
+	VPos       int    `xml:"VPOS,attr"`
+	Width      int    `xml:"WIDTH,attr"`
+	Height     int    `xml:"HEIGHT,attr"`
+	Content    string `xml:"CONTENT,attr"`
+	Confidence string `xml:"GC,attr"`
+}
+
+// altoSpace is the gap between two words of a line
+type altoSpace struct {
+	XMLName xml.Name `xml:"SP"`
+	HPos    int      `xml:"HPOS,attr"`
+	VPos    int      `xml:"VPOS,attr"`
+	Width   int      `xml:"WIDTH,attr"`
+}
+
+// WriteALTO writes the text read from an image as an ALTO version 4 document: the print
+// space of the page holds one text block with the lines of text, each line its words and
+// the spaces between them, and each word its glyphs, with their positions in pixels and
+// their confidences from 0 to 1. The words of a line are in reading order.
+func WriteALTO(w io.Writer, doc OCRDocument) error {
+	layout := doc.Layout
+	if layout == nil {
+		return fmt.Errorf("no layout to export as ALTO")
+	}
+
+	box := textBox(layout)
+	block := altoBlock{ID: "block_1", Lang: scriptLanguageCodes[doc.Script], HPos: box.X, VPos: box.Y, Width: box.Width, Height: box.Height}
+	var confidence float64
+	var glyphs int
+	for i, line := range layout.Lines {
+		if len(line.Glyphs) == 0 {
+			continue
+		}
+		altoLine := altoLine{
+			ID:     fmt.Sprintf("line_%d", i+1),
+			HPos:   line.Box.X,
+			VPos:   line.Box.Y,
+			Width:  line.Box.Width,
+			Height: line.Box.Height,
+		}
+		words := ocrWords(line)
+		for j, word := range words {
+			if j > 0 {
+				altoLine.Content = append(altoLine.Content, altoGap(words[j-1].Box, word.Box))
+			}
+			s := altoString{
+				ID:         fmt.Sprintf("string_%d_%d", i+1, j+1),
+				HPos:       word.Box.X,
+				VPos:       word.Box.Y,
+				Width:      word.Box.Width,
+				Height:     word.Box.Height,
+				Content:    word.text(),
+				Confidence: altoConfidence(word.Confidence),
+			}
+			for k, glyph := range word.Glyphs {
+				s.Glyphs = append(s.Glyphs, altoGlyph{
+					ID:         fmt.Sprintf("%s_%d", s.ID, k+1),
+					HPos:       glyph.Box.X,
+					VPos:       glyph.Box.Y,
+					Width:      glyph.Box.Width,
+					Height:     glyph.Box.Height,
+					Content:    glyph.Text,
+					Confidence: altoConfidence(glyph.Confidence),
+				})
+				confidence += glyph.Confidence
+				glyphs++
+			}
+			altoLine.Content = append(altoLine.Content, s)
+		}
+		block.Lines = append(block.Lines, altoLine)
+	}
+
+	alto := altoDocument{
+		XSI:            xsiNamespace,
+		SchemaLocation: altoNamespace + " " + altoSchema,
+		Unit:           "pixel",
+		FileName:       doc.Image,
+		Processing:     altoOCR{ID: "OCR_1", Software: ocrSoftware},
+		Page: altoPage{
+			ID:      "page_1",
+			ImageNr: 1,
+			Width:   layout.Width,
+			Height:  layout.Height,
+			PrintSpace: altoBlock{
+				HPos:   box.X,
+				VPos:   box.Y,
+				Width:  box.Width,
+				Height: box.Height,
+			},
+		},
+	}
+	if len(block.Lines) > 0 {
+		alto.Page.Confidence = altoConfidence(confidence / float64(glyphs))
+		alto.Page.PrintSpace.Blocks = []altoBlock{block}
+	}
+	return writeOCRXML(w, "ALTO", "", alto)
+}
+
+// altoGap returns the space between two neighbouring words, whichever side of the first the second lies on
+func altoGap(previous, next models.BoundingBox) altoSpace {
+	left, right := previous, next
+	if next.X < previous.X {
+		left, right = next, previous
+	}
+	return altoSpace{
+		HPos:  left.X + left.Width,
+		VPos:  min(left.Y, right.Y),
+		Width: max(0, right.X-left.X-left.Width),
+	}
+}
+
+// altoConfidence formats a confidence from 0 to 1 as an ALTO word or glyph confidence
+func altoConfidence(confidence float64) string {
+	return strconv.FormatFloat(confidence, 'f', 3, 64)
+}
+
+// writeOCRXML writes an exported OCR document after the XML declaration and a doctype, if any
+func writeOCRXML(w io.Writer, format string, doctype string, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header+doctype); err != nil {
+		return fmt.Errorf("failed to write %s: %v", format, err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write %s: %v", format, err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write %s: %v", format, err)
+	}
+	return nil
+}
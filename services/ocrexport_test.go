@@ -0,0 +1,219 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"ancient-script-decoder/models"
+)
+
+// glyph builds a glyph of a layout
+func glyph(text string, x, y, width, height, word int, confidence float64) models.GlyphLayout {
+	return models.GlyphLayout{
+		Box:        models.BoundingBox{X: x, Y: y, Width: width, Height: height},
+		Text:       text,
+		Confidence: confidence,
+		Word:       word,
+	}
+}
+
+// line builds a line of a layout enclosing its glyphs
+func line(direction string, glyphs ...models.GlyphLayout) models.LineLayout {
+	box := glyphs[0].Box
+	for _, g := range glyphs[1:] {
+		box = unionBox(box, g.Box)
+	}
+	return models.LineLayout{Box: box, Direction: direction, Glyphs: glyphs}
+}
+
+// ocrExportLayouts are the layouts the exports are tested with; confidences are exact in both formats
+var ocrExportLayouts = []struct {
+	name   string
+	script string
+	layout models.PageLayout
+}{
+	{
+		name:   "one word",
+		script: "latin",
+		layout: models.PageLayout{Width: 100, Height: 40, Direction: "ltr", Lines: []models.LineLayout{
+			line("ltr", glyph("R", 10, 10, 8, 12, 0, 0.875), glyph("E", 19, 10, 8, 12, 0, 0.5), glyph("X", 28, 11, 8, 11, 0, 0.625)),
+		}},
+	},
+	{
+		name:   "several lines and words",
+		script: "greek",
+		layout: models.PageLayout{Width: 200, Height: 100, Direction: "ltr", Lines: []models.LineLayout{
+			line("ltr", glyph("Β", 10, 10, 9, 12, 0, 0.75), glyph("Α", 20, 10, 9, 12, 0, 0.25), glyph("Σ", 40, 10, 9, 12, 1, 1)),
+			line("ltr", glyph("Ω", 10, 40, 9, 12, 0, 0.5)),
+		}},
+	},
+	{
+		name:   "right to left with markup characters",
+		script: "runic",
+		layout: models.PageLayout{Width: 120, Height: 30, Direction: "rtl", Lines: []models.LineLayout{
+			line("rtl", glyph("<", 80, 5, 9, 12, 0, 0.5), glyph("&", 70, 5, 9, 12, 0, 0.5), glyph("ᚱ", 40, 5, 9, 12, 1, 0.125)),
+		}},
+	},
+}
+
+// hocrTitle reads the bounding box and the confidence property of an hOCR title
+func hocrTitle(t *testing.T, title, confidence string) (models.BoundingBox, float64) {
+	t.Helper()
+	var box models.BoundingBox
+	var value float64
+	for _, property := range strings.Split(title, ";") {
+		fields := strings.Fields(property)
+		switch {
+		case len(fields) == 5 && fields[0] == "bbox":
+			var x0, y0, x1, y1 int
+			fmt.Sscan(strings.Join(fields[1:], " "), &x0, &y0, &x1, &y1)
+			box = models.BoundingBox{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+		case len(fields) == 2 && fields[0] == confidence:
+			v, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				t.Fatalf("invalid %s in title %q", confidence, title)
+			}
+			value = v / 100
+		}
+	}
+	return box, value
+}
+
+func TestHOCRRoundTrip(t *testing.T) {
+	for _, test := range ocrExportLayouts {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteHOCR(&buf, OCRDocument{Layout: &test.layout, Script: test.script, Image: "stele.png"}); err != nil {
+				t.Fatalf("WriteHOCR() error = %v", err)
+			}
+
+			var doc hocrDocument
+			if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+				t.Fatalf("exported hOCR does not parse: %v", err)
+			}
+			if doc.Lang != scriptLanguageCodes[test.script] {
+				t.Errorf("lang = %q, want %q", doc.Lang, scriptLanguageCodes[test.script])
+			}
+			pageBox, _ := hocrTitle(t, doc.Page.Title, "")
+			if pageBox.Width != test.layout.Width || pageBox.Height != test.layout.Height {
+				t.Errorf("page box = %+v, want %dx%d", pageBox, test.layout.Width, test.layout.Height)
+			}
+			if !strings.Contains(doc.Page.Title, `image "stele.png"`) {
+				t.Errorf("page title = %q, want the image named", doc.Page.Title)
+			}
+
+			// Read the lines back from the paragraph of the content area
+			par := doc.Page.Children[0].Children[0]
+			var lines []models.LineLayout
+			for _, lineNode := range par.Children {
+				lineBox, _ := hocrTitle(t, lineNode.Title, "")
+				readLine := models.LineLayout{Box: lineBox, Direction: lineNode.Dir}
+				for word, wordNode := range lineNode.Children {
+					for _, charNode := range wordNode.Children {
+						box, confidence := hocrTitle(t, charNode.Title, "x_conf")
+						readLine.Glyphs = append(readLine.Glyphs, glyph(charNode.Text, box.X, box.Y, box.Width, box.Height, word, confidence))
+					}
+				}
+				lines = append(lines, readLine)
+			}
+			if !reflect.DeepEqual(lines, test.layout.Lines) {
+				t.Errorf("lines = %+v, want %+v", lines, test.layout.Lines)
+			}
+		})
+	}
+}
+
+// altoInput is the part of an ALTO document the round trip reads back
+type altoInput struct {
+	Page struct {
+		Width  int `xml:"WIDTH,attr"`
+		Height int `xml:"HEIGHT,attr"`
+		Blocks []struct {
+			Lang  string `xml:"LANG,attr"`
+			Lines []struct {
+				HPos    int `xml:"HPOS,attr"`
+				VPos    int `xml:"VPOS,attr"`
+				Width   int `xml:"WIDTH,attr"`
+				Height  int `xml:"HEIGHT,attr"`
+				Strings []struct {
+					Content string      `xml:"CONTENT,attr"`
+					Glyphs  []altoGlyph `xml:"Glyph"`
+				} `xml:"String"`
+				Spaces []altoSpace `xml:"SP"`
+			} `xml:"TextLine"`
+		} `xml:"PrintSpace>TextBlock"`
+	} `xml:"Layout>Page"`
+	FileName string `xml:"Description>sourceImageInformation>fileName"`
+}
+
+func TestALTORoundTrip(t *testing.T) {
+	for _, test := range ocrExportLayouts {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteALTO(&buf, OCRDocument{Layout: &test.layout, Script: test.script, Image: "stele.png"}); err != nil {
+				t.Fatalf("WriteALTO() error = %v", err)
+			}
+
+			var doc altoInput
+			if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+				t.Fatalf("exported ALTO does not parse: %v", err)
+			}
+			if doc.Page.Width != test.layout.Width || doc.Page.Height != test.layout.Height {
+				t.Errorf("page = %dx%d, want %dx%d", doc.Page.Width, doc.Page.Height, test.layout.Width, test.layout.Height)
+			}
+			if doc.FileName != "stele.png" {
+				t.Errorf("fileName = %q, want %q", doc.FileName, "stele.png")
+			}
+			if len(doc.Page.Blocks) != 1 {
+				t.Fatalf("text blocks = %d, want 1", len(doc.Page.Blocks))
+			}
+			if doc.Page.Blocks[0].Lang != scriptLanguageCodes[test.script] {
+				t.Errorf("LANG = %q, want %q", doc.Page.Blocks[0].Lang, scriptLanguageCodes[test.script])
+			}
+
+			var lines []models.LineLayout
+			for i, altoLine := range doc.Page.Blocks[0].Lines {
+				readLine := models.LineLayout{
+					Box:       models.BoundingBox{X: altoLine.HPos, Y: altoLine.VPos, Width: altoLine.Width, Height: altoLine.Height},
+					Direction: test.layout.Lines[i].Direction,
+				}
+				if len(altoLine.Spaces) != len(altoLine.Strings)-1 {
+					t.Errorf("line %d has %d spaces between %d words", i+1, len(altoLine.Spaces), len(altoLine.Strings))
+				}
+				for word, s := range altoLine.Strings {
+					var content strings.Builder
+					for _, g := range s.Glyphs {
+						confidence, err := strconv.ParseFloat(g.Confidence, 64)
+						if err != nil {
+							t.Fatalf("invalid GC %q", g.Confidence)
+						}
+						readLine.Glyphs = append(readLine.Glyphs, glyph(g.Content, g.HPos, g.VPos, g.Width, g.Height, word, confidence))
+						content.WriteString(g.Content)
+					}
+					if s.Content != content.String() {
+						t.Errorf("CONTENT = %q, want the glyphs %q", s.Content, content.String())
+					}
+				}
+				lines = append(lines, readLine)
+			}
+			if !reflect.DeepEqual(lines, test.layout.Lines) {
+				t.Errorf("lines = %+v, want %+v", lines, test.layout.Lines)
+			}
+		})
+	}
+}
+
+func TestOCRExportWithoutLayout(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHOCR(&buf, OCRDocument{}); err == nil {
+		t.Error("WriteHOCR() without a layout succeeded, want an error")
+	}
+	if err := WriteALTO(&buf, OCRDocument{}); err == nil {
+		t.Error("WriteALTO() without a layout succeeded, want an error")
+	}
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ancient-script-decoder/models"
+)
+
+// ErrReviewNotFound is returned when no review queue item has the requested ID
+var ErrReviewNotFound = errors.New("review item not found")
+
+// ErrAlreadyReviewed is returned when an item of the review queue is no longer pending
+var ErrAlreadyReviewed = errors.New("review item was already reviewed")
+
+// Statuses of the items of the review queue
+const (
+	ReviewPending   = "pending"
+	ReviewAccepted  = "accepted"
+	ReviewCorrected = "corrected"
+	ReviewDismissed = "dismissed"
+)
+
+// reviewIndexFile is the file of a review queue directory listing its items
+const reviewIndexFile = "queue.json"
+
+// ReviewQueue collects the glyphs the OCR read with low confidence across manuscripts until a
+// reviewer accepts, corrects or dismisses them. Reviewed items are kept as a record of the
+// decision. The queue is persisted as a directory holding a JSON list of the items and a PNG
+// image of each glyph.
+type ReviewQueue struct {
+	mu sync.RWMutex
+	// dir is the directory the queue is persisted to, empty for an in-memory queue
+	dir    string
+	items  map[string]models.ReviewItem
+	images map[string]image.Image
+}
+
+// NewReviewQueue creates an empty review queue persisted to dir
+func NewReviewQueue(dir string) *ReviewQueue {
+	return &ReviewQueue{
+		dir:    dir,
+		items:  make(map[string]models.ReviewItem),
+		images: make(map[string]image.Image),
+	}
+}
+
+// LoadReviewQueue creates a review queue from the directory at dir.
+// A missing directory yields an empty queue that will be created on the first change.
+func LoadReviewQueue(dir string) (*ReviewQueue, error) {
+	queue := NewReviewQueue(dir)
+	if dir == "" {
+		return queue, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Clean(dir), reviewIndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return queue, nil
+	}
+	if err != nil {
+		return queue, fmt.Errorf("failed to read review queue: %v", err)
+	}
+
+	var items []models.ReviewItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return queue, fmt.Errorf("failed to parse review queue %s: %v", dir, err)
+	}
+	for _, item := range items {
+		img, err := queue.readImage(item.ID)
+		if err != nil {
+			return queue, err
+		}
+		queue.items[item.ID] = item
+		queue.images[item.ID] = img
+	}
+	return queue, nil
+}
+
+// List returns the items with a status, or with any status when status is empty, of a script,
+// or of all scripts when script is empty. Items are ordered from the least confident.
+func (q *ReviewQueue) List(status, script string) []models.ReviewItem {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := make([]models.ReviewItem, 0, len(q.items))
+	for _, item := range q.items {
+		if (status == "" || item.Status == status) && (script == "" || strings.EqualFold(item.Script, script)) {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Confidence != items[j].Confidence {
+			return items[i].Confidence < items[j].Confidence
+		}
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.Before(items[j].CreatedAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+// Get returns the item with the given ID
+func (q *ReviewQueue) Get(id string) (models.ReviewItem, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	item, ok := q.items[id]
+	if !ok {
+		return models.ReviewItem{}, ErrReviewNotFound
+	}
+	return item, nil
+}
+
+// Image returns the image of the glyph of the item with the given ID
+func (q *ReviewQueue) Image(id string) (image.Image, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	img, ok := q.images[id]
+	if !ok {
+		return nil, ErrReviewNotFound
+	}
+	return img, nil
+}
+
+// Enqueue adds glyphs with their images to the queue as pending items, assigning them their
+// IDs. A glyph already queued from the same place of the same manuscript keeps its item,
+// whatever its status, so that reading a manuscript again does not queue its glyphs twice.
+func (q *ReviewQueue) Enqueue(items []models.ReviewItem, images []image.Image) ([]models.ReviewItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queued := make(map[string]string, len(q.items))
+	for id, item := range q.items {
+		queued[reviewKey(item)] = id
+	}
+
+	var added []string
+	now := time.Now().UTC()
+	result := make([]models.ReviewItem, len(items))
+	for i, item := range items {
+		if id, ok := queued[reviewKey(item)]; ok {
+			result[i] = q.items[id]
+			continue
+		}
+
+		id, err := newReviewID()
+		if err != nil {
+			q.discard(added)
+			return nil, err
+		}
+		item.ID = id
+		item.Status = ReviewPending
+		item.CreatedAt = now
+		if err := q.writeImage(id, images[i]); err != nil {
+			q.discard(added)
+			return nil, err
+		}
+		q.items[id] = item
+		q.images[id] = images[i]
+		queued[reviewKey(item)] = id
+		added = append(added, id)
+		result[i] = item
+	}
+
+	if len(added) > 0 {
+		if err := q.save(); err != nil {
+			q.discard(added)
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Resolve records the decision on a pending item: its status, the corrected reading and the
+// sign sample the glyph was added to the inventory as, if any
+func (q *ReviewQueue) Resolve(id, status, correction, signID string) (models.ReviewItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	previous, ok := q.items[id]
+	if !ok {
+		return models.ReviewItem{}, ErrReviewNotFound
+	}
+	if previous.Status != ReviewPending {
+		return models.ReviewItem{}, ErrAlreadyReviewed
+	}
+
+	item := previous
+	reviewedAt := time.Now().UTC()
+	item.Status = status
+	item.Correction = correction
+	item.SignID = signID
+	item.ReviewedAt = &reviewedAt
+
+	q.items[id] = item
+	if err := q.save(); err != nil {
+		q.items[id] = previous
+		return models.ReviewItem{}, err
+	}
+	return item, nil
+}
+
+// Pending returns the number of items awaiting review
+func (q *ReviewQueue) Pending() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	pending := 0
+	for _, item := range q.items {
+		if item.Status == ReviewPending {
+			pending++
+		}
+	}
+	return pending
+}
+
+// discard removes items added by a failed change; the caller must hold the write lock
+func (q *ReviewQueue) discard(ids []string) {
+	for _, id := range ids {
+		delete(q.items, id)
+		delete(q.images, id)
+		q.removeImage(id)
+	}
+}
+
+// reviewKey identifies the place of a glyph in a manuscript read in a script
+func reviewKey(item models.ReviewItem) string {
+	box := item.Box
+	return fmt.Sprintf("%s/%s/%d,%d,%d,%d", item.Manuscript, item.Script, box.X, box.Y, box.Width, box.Height)
+}
+
+// imagePath returns the file the image of an item is stored in
+func (q *ReviewQueue) imagePath(id string) string {
+	return filepath.Join(q.dir, id+".png")
+}
+
+// readImage loads the image of an item from the queue directory
+func (q *ReviewQueue) readImage(id string) (image.Image, error) {
+	data, err := os.ReadFile(q.imagePath(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review image: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode review image %s: %v", id, err)
+	}
+	return img, nil
+}
+
+// writeImage stores the image of an item as PNG; the caller must hold the write lock
+func (q *ReviewQueue) writeImage(id string, img image.Image) error {
+	if q.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(q.dir, 0755); err != nil {
+		return fmt.Errorf("failed to save review image: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("failed to encode review image: %v", err)
+	}
+	if err := os.WriteFile(q.imagePath(id), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to save review image: %v", err)
+	}
+	return nil
+}
+
+// removeImage deletes the image file of an item; a file left behind does no harm
+func (q *ReviewQueue) removeImage(id string) {
+	if q.dir != "" {
+		os.Remove(q.imagePath(id))
+	}
+}
+
+// save writes the list of items to the queue directory; the caller must hold the write lock
+func (q *ReviewQueue) save() error {
+	if q.dir == "" {
+		return nil
+	}
+
+	items := make([]models.ReviewItem, 0, len(q.items))
+	for _, item := range q.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.Before(items[j].CreatedAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode review queue: %v", err)
+	}
+	if err := os.MkdirAll(q.dir, 0755); err != nil {
+		return fmt.Errorf("failed to save review queue: %v", err)
+	}
+
+	// Write to a temporary file first so a failed save does not truncate the queue
+	path := filepath.Join(q.dir, reviewIndexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save review queue: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save review queue: %v", err)
+	}
+	return nil
+}
+
+// newReviewID generates a random review item identifier
+func newReviewID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate review item ID: %v", err)
+	}
+	return hex.EncodeToString(id), nil
+}
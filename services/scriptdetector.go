@@ -0,0 +1,151 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"ancient-script-decoder/models"
+)
+
+// Unicode ranges of the native scripts
+var (
+	greekTable = &unicode.RangeTable{R16: []unicode.Range16{
+		{Lo: 0x0370, Hi: 0x03FF, Stride: 1},
+		{Lo: 0x1F00, Hi: 0x1FFF, Stride: 1},
+	}}
+	runicTable = &unicode.RangeTable{R16: []unicode.Range16{
+		{Lo: 0x16A0, Hi: 0x16FF, Stride: 1},
+	}}
+	cuneiformTable = &unicode.RangeTable{R32: []unicode.Range32{
+		{Lo: 0x12000, Hi: 0x123FF, Stride: 1},
+		{Lo: 0x12400, Hi: 0x1247F, Stride: 1},
+		{Lo: 0x12480, Hi: 0x1254F, Stride: 1},
+	}}
+	hieroglyphTable = &unicode.RangeTable{R32: []unicode.Range32{
+		{Lo: 0x13000, Hi: 0x1342F, Stride: 1},
+	}}
+)
+
+// Patterns of the Latin-script transliteration conventions
+var (
+	// Beta Code marks breathings and accents with ) ( / \ = | and capitals with *,
+	// whose marks come before the letter as in *)/andra
+	betaCodePattern = regexp.MustCompile(`(^|\s)(\*?[()/\\=|]*[a-z]+[()/\\=|+]+[a-z]*|\*[()/\\=|]+[a-z]+)`)
+	// Assyriological readings join syllables with hyphens and use sign indices or determinatives
+	assyriologyPattern = regexp.MustCompile(`[a-zšṣṭḫĝ]+[0-9₀-₉]*(-[a-zšṣṭḫĝ]+[0-9₀-₉]*)+|\{[a-z]+\}|\b[A-Z]{3,}(\.[A-Z]+)+\b|[šṣṭḫĝ]`)
+	// Manuel de Codage uses Gardiner sign codes grouped with : * and -
+	mdcPattern = regexp.MustCompile(`\b(Aa|[A-Z])[0-9]{1,3}[A-Za-z]?\b|[A-Za-z0-9]+[:*][A-Za-z0-9]+`)
+	// Runic romanization uses thorn, yr and nasal vowels
+	runicRomanPattern = regexp.MustCompile(`[þʀąǫ]`)
+)
+
+// DetectScript ranks the supported scripts by how likely the text is written in them.
+// Native Unicode blocks are counted first; plain Latin letters are then checked against
+// the transliteration conventions and the Latin lexicon.
+func (t *Translator) DetectScript(text string) []models.ScriptCandidate {
+	scores := make(map[string]float64)
+
+	var letters, latinLetters int
+	for _, r := range text {
+		switch {
+		case unicode.Is(greekTable, r):
+			scores["greek"]++
+			letters++
+		case unicode.Is(runicTable, r):
+			scores["runic"]++
+			letters++
+		case unicode.Is(cuneiformTable, r):
+			scores["cuneiform"]++
+			letters++
+		case unicode.Is(hieroglyphTable, r):
+			scores["hieroglyphic"]++
+			letters++
+		case unicode.IsLetter(r) && unicode.Is(unicode.Latin, r):
+			latinLetters++
+			letters++
+		}
+	}
+
+	if latinLetters > 0 {
+		// Share the Latin-alphabet portion between the transliteration conventions
+		for script, weight := range t.transliterationScores(text) {
+			scores[script] += weight * float64(latinLetters)
+		}
+	}
+
+	return t.rankCandidates(scores, letters)
+}
+
+// transliterationScores weighs the Latin-alphabet text against each convention, returning weights that sum to 1
+func (t *Translator) transliterationScores(text string) map[string]float64 {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+	total := float64(len(words))
+
+	weights := map[string]float64{
+		"greek":        float64(len(betaCodePattern.FindAllString(text, -1))) / total,
+		"cuneiform":    float64(len(assyriologyPattern.FindAllString(text, -1))) / total,
+		"hieroglyphic": float64(len(mdcPattern.FindAllString(text, -1))) / total,
+		"runic":        float64(len(runicRomanPattern.FindAllString(text, -1))) / total,
+	}
+
+	// Latin gains weight from lexicon hits and from a small prior for plain alphabetic text
+	latinWeight := 0.2
+	if lexicon, ok := t.lexicons[t.config.DefaultTargetLanguage]["latin"]; ok {
+		known := 0
+		for _, word := range tokenizeSource(text, "latin") {
+			if _, ok := parseRomanNumeral(word.text); ok {
+				known++
+			} else if _, _, ok := lemmatize(word.normalized, lexicon); ok {
+				known++
+			}
+		}
+		latinWeight += float64(known) / total
+	}
+	weights["latin"] = latinWeight
+
+	sum := 0.0
+	for script, weight := range weights {
+		if weight > 1 {
+			weight = 1
+			weights[script] = weight
+		}
+		sum += weight
+	}
+	for script := range weights {
+		weights[script] /= sum
+	}
+
+	return weights
+}
+
+// rankCandidates turns raw scores into confidences for the supported scripts, best first
+func (t *Translator) rankCandidates(scores map[string]float64, letters int) []models.ScriptCandidate {
+	var candidates []models.ScriptCandidate
+	if letters == 0 {
+		return candidates
+	}
+
+	for script, score := range scores {
+		if score <= 0 || !t.isScriptSupported(script) {
+			continue
+		}
+		candidates = append(candidates, models.ScriptCandidate{
+			Script:     script,
+			Confidence: score / float64(letters),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Confidence == candidates[j].Confidence {
+			return candidates[i].Script < candidates[j].Script
+		}
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+
+	return candidates
+}
@@ -0,0 +1,57 @@
+package services
+
+import "testing"
+
+func TestDetectScript(t *testing.T) {
+	translator := NewTranslator(TranslationConfig{
+		SupportedScripts: []string{"latin", "greek", "cuneiform", "hieroglyphic", "runic"},
+	})
+
+	tests := []struct {
+		name string
+		text string
+		want string
+		// wantNative is set for text in a native Unicode block, which no other script shares
+		wantNative bool
+	}{
+		{name: "polytonic Greek", text: "μῆνιν ἄειδε θεὰ", want: "greek", wantNative: true},
+		{name: "Beta Code", text: "mh=nin a)/eide qea/", want: "greek"},
+		{name: "Beta Code capital with breathing and accent", text: "*)/andra moi", want: "greek"},
+		{name: "Beta Code capital alone", text: "*(/ektwr", want: "greek"},
+		{name: "cuneiform signs", text: "𒀀𒈾 𒈗", want: "cuneiform", wantNative: true},
+		{name: "Assyriological readings", text: "a-na be-li-ia qi2-bi-ma", want: "cuneiform"},
+		{name: "Assyriological determinative", text: "{d}en-lil2 lugal", want: "cuneiform"},
+		{name: "hieroglyphs", text: "𓅓𓈖𓊹", want: "hieroglyphic", wantNative: true},
+		{name: "Manuel de Codage", text: "G17-N35 M17:N35*Q3", want: "hieroglyphic"},
+		{name: "runes", text: "ᚠᚢᚦᚨᚱᚲ", want: "runic", wantNative: true},
+		{name: "runic romanization", text: "þorgrímʀ ristiʀ runaʀ", want: "runic"},
+		{name: "plain Latin", text: "gallia est omnis divisa", want: "latin"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			candidates := translator.DetectScript(test.text)
+			if len(candidates) == 0 {
+				t.Fatalf("DetectScript() = no candidates, want %s", test.want)
+			}
+			if candidates[0].Script != test.want {
+				t.Errorf("DetectScript() ranks %s first, want %s: %+v", candidates[0].Script, test.want, candidates)
+			}
+			for i := 1; i < len(candidates); i++ {
+				if candidates[i].Confidence > candidates[i-1].Confidence {
+					t.Errorf("DetectScript() is not ranked by confidence: %+v", candidates)
+				}
+			}
+			if test.wantNative && (len(candidates) != 1 || candidates[0].Confidence != 1) {
+				t.Errorf("DetectScript() = %+v, want only %s with confidence 1", candidates, test.want)
+			}
+		})
+	}
+}
+
+func TestDetectScriptWithoutLetters(t *testing.T) {
+	translator := NewTranslator(TranslationConfig{SupportedScripts: []string{"latin", "greek"}})
+	if candidates := translator.DetectScript("12 — ?"); len(candidates) != 0 {
+		t.Errorf("DetectScript() = %+v, want no candidates", candidates)
+	}
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"ancient-script-decoder/models"
+	"ancient-script-decoder/services/ocr"
+)
+
+// ErrSignNotFound is returned when no sign sample has the requested ID
+var ErrSignNotFound = errors.New("sign sample not found")
+
+// signIndexFile is the file of a sign inventory directory listing its samples
+const signIndexFile = "signs.json"
+
+// SignInventory keeps the labelled glyph samples the OCR learns new signs from. It is
+// persisted as a directory holding a JSON list of the samples and a PNG image of each.
+type SignInventory struct {
+	mu sync.RWMutex
+	// dir is the directory the inventory is persisted to, empty for an in-memory inventory
+	dir     string
+	samples map[string]models.SignSample
+	images  map[string]image.Image
+}
+
+// NewSignInventory creates an empty sign inventory persisted to dir
+func NewSignInventory(dir string) *SignInventory {
+	return &SignInventory{
+		dir:     dir,
+		samples: make(map[string]models.SignSample),
+		images:  make(map[string]image.Image),
+	}
+}
+
+// LoadSignInventory creates a sign inventory from the directory at dir.
+// A missing directory yields an empty inventory that will be created on the first change.
+func LoadSignInventory(dir string) (*SignInventory, error) {
+	inventory := NewSignInventory(dir)
+	if dir == "" {
+		return inventory, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Clean(dir), signIndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return inventory, nil
+	}
+	if err != nil {
+		return inventory, fmt.Errorf("failed to read sign inventory: %v", err)
+	}
+
+	var samples []models.SignSample
+	if err := json.Unmarshal(data, &samples); err != nil {
+		return inventory, fmt.Errorf("failed to parse sign inventory %s: %v", dir, err)
+	}
+	for _, sample := range samples {
+		img, err := inventory.readImage(sample.ID)
+		if err != nil {
+			return inventory, err
+		}
+		inventory.samples[sample.ID] = sample
+		inventory.images[sample.ID] = img
+	}
+	return inventory, nil
+}
+
+// List returns the samples of a script, or of all scripts when script is empty
+func (s *SignInventory) List(script string) []models.SignSample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	samples := make([]models.SignSample, 0, len(s.samples))
+	for _, sample := range s.samples {
+		if script == "" || strings.EqualFold(sample.Script, script) {
+			samples = append(samples, sample)
+		}
+	}
+	sortSignSamples(samples)
+	return samples
+}
+
+// Get returns the sample with the given ID
+func (s *SignInventory) Get(id string) (models.SignSample, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sample, ok := s.samples[id]
+	if !ok {
+		return models.SignSample{}, ErrSignNotFound
+	}
+	return sample, nil
+}
+
+// Add validates and stores a labelled glyph image, assigning the sample its ID
+func (s *SignInventory) Add(sample models.SignSample, imageData []byte) (models.SignSample, error) {
+	sample, err := normalizeSignSample(sample)
+	if err != nil {
+		return models.SignSample{}, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return models.SignSample{}, fmt.Errorf("failed to decode sign image: %v", err)
+	}
+	if err := ocr.ValidateSample(img); err != nil {
+		return models.SignSample{}, err
+	}
+
+	id, err := newSignID()
+	if err != nil {
+		return models.SignSample{}, err
+	}
+	sample.ID = id
+	sample.Width = img.Bounds().Dx()
+	sample.Height = img.Bounds().Dy()
+	sample.CreatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writeImage(id, img); err != nil {
+		return models.SignSample{}, err
+	}
+	s.samples[id] = sample
+	s.images[id] = img
+	if err := s.save(); err != nil {
+		delete(s.samples, id)
+		delete(s.images, id)
+		s.removeImage(id)
+		return models.SignSample{}, err
+	}
+	return sample, nil
+}
+
+// Delete removes the sample with the given ID
+func (s *SignInventory) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.samples[id]
+	if !ok {
+		return ErrSignNotFound
+	}
+	img := s.images[id]
+
+	delete(s.samples, id)
+	delete(s.images, id)
+	if err := s.save(); err != nil {
+		s.samples[id] = previous
+		s.images[id] = img
+		return err
+	}
+	s.removeImage(id)
+	return nil
+}
+
+// Size returns the number of stored samples
+func (s *SignInventory) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.samples)
+}
+
+// indexSamples groups the samples by script for indexing, each labelled with the text it is read as
+func (s *SignInventory) indexSamples() map[string][]ocr.Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	samples := make([]models.SignSample, 0, len(s.samples))
+	for _, sample := range s.samples {
+		samples = append(samples, sample)
+	}
+	sortSignSamples(samples)
+
+	scripts := make(map[string][]ocr.Sample)
+	for _, sample := range samples {
+		text := sample.Text
+		if text == "" {
+			text = sample.Name
+		}
+		scripts[sample.Script] = append(scripts[sample.Script], ocr.Sample{Text: text, Image: s.images[sample.ID]})
+	}
+	return scripts
+}
+
+// imagePath returns the file the image of a sample is stored in
+func (s *SignInventory) imagePath(id string) string {
+	return filepath.Join(s.dir, id+".png")
+}
+
+// readImage loads the image of a sample from the inventory directory
+func (s *SignInventory) readImage(id string) (image.Image, error) {
+	data, err := os.ReadFile(s.imagePath(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sign image: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode sign image %s: %v", id, err)
+	}
+	return img, nil
+}
+
+// writeImage stores the image of a sample as PNG; the caller must hold the write lock
+func (s *SignInventory) writeImage(id string, img image.Image) error {
+	if s.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to save sign image: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("failed to encode sign image: %v", err)
+	}
+	if err := os.WriteFile(s.imagePath(id), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to save sign image: %v", err)
+	}
+	return nil
+}
+
+// removeImage deletes the image file of a sample; a file left behind does no harm
+func (s *SignInventory) removeImage(id string) {
+	if s.dir != "" {
+		os.Remove(s.imagePath(id))
+	}
+}
+
+// save writes the list of samples to the inventory directory; the caller must hold the write lock
+func (s *SignInventory) save() error {
+	if s.dir == "" {
+		return nil
+	}
+
+	samples := make([]models.SignSample, 0, len(s.samples))
+	for _, sample := range s.samples {
+		samples = append(samples, sample)
+	}
+	sortSignSamples(samples)
+
+	data, err := json.MarshalIndent(samples, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sign inventory: %v", err)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to save sign inventory: %v", err)
+	}
+
+	// Write to a temporary file first so a failed save does not truncate the inventory
+	path := filepath.Join(s.dir, signIndexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save sign inventory: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save sign inventory: %v", err)
+	}
+	return nil
+}
+
+// sortSignSamples orders samples by script, then by the order they were added in
+func sortSignSamples(samples []models.SignSample) {
+	sort.SliceStable(samples, func(i, j int) bool {
+		if samples[i].Script != samples[j].Script {
+			return samples[i].Script < samples[j].Script
+		}
+		if !samples[i].CreatedAt.Equal(samples[j].CreatedAt) {
+			return samples[i].CreatedAt.Before(samples[j].CreatedAt)
+		}
+		return samples[i].ID < samples[j].ID
+	})
+}
+
+// normalizeSignSample trims the labels of a sample, lowercases its script and resolves a
+// text given as a code point such as U+1202D to its character
+func normalizeSignSample(sample models.SignSample) (models.SignSample, error) {
+	sample.Script = strings.ToLower(strings.TrimSpace(sample.Script))
+	sample.Text = strings.TrimSpace(sample.Text)
+	sample.Name = strings.TrimSpace(sample.Name)
+
+	if sample.Script == "" {
+		return models.SignSample{}, fmt.Errorf("sign sample has no script")
+	}
+	if sample.Text == "" && sample.Name == "" {
+		return models.SignSample{}, fmt.Errorf("sign sample needs a text, code point or name")
+	}
+
+	if upper := strings.ToUpper(sample.Text); strings.HasPrefix(upper, "U+") {
+		code, err := strconv.ParseUint(upper[2:], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return models.SignSample{}, fmt.Errorf("invalid code point %s", sample.Text)
+		}
+		sample.Text = string(rune(code))
+	}
+	return sample, nil
+}
+
+// newSignID generates a random sign sample identifier
+func newSignID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate sign sample ID: %v", err)
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -903,6 +903,7 @@ func (s *Summarizer) refineExtractiveWithAbstractive(extractiveSummary string, c
 // splitIntoSentences splits text into sentences
 func splitIntoSentences(text string) []string {
         // Simple sentence splitting - in a real implementation, this would be more sophisticated
+        text = protectEditorialMarks(text)
         text = strings.ReplaceAll(text, "...", "###ELLIPSIS###")
         text = strings.ReplaceAll(text, "Mr.", "Mr###DOT###")
         text = strings.ReplaceAll(text, "Mrs.", "Mrs###DOT###")
@@ -927,6 +928,8 @@ func splitIntoSentences(text string) []string {
                 // Restore special cases
                 part = strings.ReplaceAll(part, "###ELLIPSIS###", "...")
                 part = strings.ReplaceAll(part, "###DOT###", ".")
+                part = strings.ReplaceAll(part, "###QUESTION###", "?")
+                part = strings.ReplaceAll(part, "###EXCLAMATION###", "!")
                 
                 // Add back the terminal punctuation (assuming . for simplicity)
                 sentences = append(sentences, part+".")
@@ -935,6 +938,63 @@ func splitIntoSentences(text string) []string {
         return sentences
 }
 
+// editorialClosers maps the brackets of Leiden notation to their closing brackets. Round
+// brackets are left out, as they enclose ordinary parentheticals in translations as well.
+var editorialClosers = map[rune]rune{
+        '[': ']',
+        '⟨': '⟩',
+        '⟦': '⟧',
+}
+
+// vacatPattern matches the abbreviation vac. as a word of its own
+var vacatPattern = regexp.MustCompile(`(^|[^\pL\pN])vac\.`)
+
+// protectEditorialMarks hides the punctuation inside editorial brackets, such as the lacunae
+// [...] and [.3.], in the query (?) and in the abbreviation vac. so that it does not end a sentence
+func protectEditorialMarks(text string) string {
+        text = vacatPattern.ReplaceAllString(text, "${1}vac###DOT###")
+        text = strings.ReplaceAll(text, "(?)", "(###QUESTION###)")
+
+        // Match the brackets with a stack; brackets left unclosed protect nothing
+        runes := []rune(text)
+        type opening struct {
+                closer   rune
+                position int
+        }
+        var open []opening
+        depth := make([]int, len(runes)+1)
+        for i, r := range runes {
+                if len(open) > 0 && r == open[len(open)-1].closer {
+                        depth[open[len(open)-1].position]++
+                        depth[i]--
+                        open = open[:len(open)-1]
+                } else if closer, ok := editorialClosers[r]; ok {
+                        open = append(open, opening{closer: closer, position: i})
+                }
+        }
+
+        var b strings.Builder
+        inside := 0
+        for i, r := range runes {
+                inside += depth[i]
+                if inside == 0 {
+                        b.WriteRune(r)
+                        continue
+                }
+                switch r {
+                case '.':
+                        b.WriteString("###DOT###")
+                case '?':
+                        b.WriteString("###QUESTION###")
+                case '!':
+                        b.WriteString("###EXCLAMATION###")
+                default:
+                        b.WriteRune(r)
+                }
+        }
+        return b.String()
+}
+
 // buildStopwordsMap builds a map of common stopwords
 func buildStopwordsMap() map[string]bool {
         stopwordsList := []string{
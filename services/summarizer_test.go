@@ -0,0 +1,78 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitIntoSentencesLeiden(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "lacuna of unknown extent",
+			text: "The king [...] built the temple. He died.",
+			want: []string{"The king [...] built the temple.", "He died."},
+		},
+		{
+			name: "lacuna of counted letters",
+			text: "The king [.3.] built the temple. He died.",
+			want: []string{"The king [.3.] built the temple.", "He died."},
+		},
+		{
+			name: "restoration spanning a full stop",
+			text: "The king built [the temple. He] died.",
+			want: []string{"The king built [the temple. He] died."},
+		},
+		{
+			name: "nested brackets",
+			text: "The king [built ⟨the. temple⟩ here.] at last. He died.",
+			want: []string{"The king [built ⟨the. temple⟩ here.] at last.", "He died."},
+		},
+		{
+			name: "queried word",
+			text: "The king(?) died. He was old.",
+			want: []string{"The king(?) died.", "He was old."},
+		},
+		{
+			name: "vacat",
+			text: "The stone vac. ends here. Next line.",
+			want: []string{"The stone vac. ends here.", "Next line."},
+		},
+		{
+			name: "vac inside a word",
+			text: "It was Ivac. He left.",
+			want: []string{"It was Ivac.", "He left."},
+		},
+		{
+			name: "ordinary parenthetical",
+			text: "He fought (and won. Then he rested) at home.",
+			want: []string{"He fought (and won.", "Then he rested) at home."},
+		},
+		{
+			name: "unclosed bracket",
+			text: "The king [built. He died.",
+			want: []string{"The king [built.", "He died."},
+		},
+		{
+			name: "stray closing bracket",
+			text: "The king built] the temple. He died.",
+			want: []string{"The king built] the temple.", "He died."},
+		},
+		{
+			name: "mismatched brackets",
+			text: "The king ⟨built] the temple. He died.",
+			want: []string{"The king ⟨built] the temple.", "He died."},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := splitIntoSentences(test.text); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("splitIntoSentences(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
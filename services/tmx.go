@@ -0,0 +1,187 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// scriptLanguageCodes maps the supported scripts to the language tags used for them in TMX files
+var scriptLanguageCodes = map[string]string{
+	"latin":        "la",
+	"greek":        "grc",
+	"cuneiform":    "akk",
+	"hieroglyphic": "egy",
+	"runic":        "non",
+}
+
+// tmxScriptProp is the TMX property recording the script of a translation unit's source
+const tmxScriptProp = "x-script"
+
+// tmxDateLayout is the basic ISO 8601 format TMX uses for dates
+const tmxDateLayout = "20060102T150405Z"
+
+// tmxDocument is the root of a TMX 1.4 file
+type tmxDocument struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Units   []tmxUnit `xml:"body>tu"`
+}
+
+// tmxHeader contains the TMX header attributes
+type tmxHeader struct {
+	CreationTool        string `xml:"creationtool,attr"`
+	CreationToolVersion string `xml:"creationtoolversion,attr"`
+	SegType             string `xml:"segtype,attr"`
+	DataType            string `xml:"datatype,attr"`
+	OTmf                string `xml:"o-tmf,attr"`
+	AdminLang           string `xml:"adminlang,attr"`
+	SrcLang             string `xml:"srclang,attr"`
+}
+
+// tmxUnit is a translation unit holding one variant per language
+type tmxUnit struct {
+	CreationDate string       `xml:"creationdate,attr,omitempty"`
+	Props        []tmxProp    `xml:"prop"`
+	Variants     []tmxVariant `xml:"tuv"`
+}
+
+// tmxProp is a typed property of a translation unit
+type tmxProp struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// tmxVariant is the segment of a translation unit in one language.
+// TMX 1.4 uses xml:lang; older files use a plain lang attribute.
+type tmxVariant struct {
+	XMLLang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Lang    string `xml:"lang,attr,omitempty"`
+	Segment string `xml:"seg"`
+}
+
+// language returns the primary subtag of the variant's language, lowercased
+func (v tmxVariant) language() string {
+	lang := v.XMLLang
+	if lang == "" {
+		lang = v.Lang
+	}
+	lang = strings.ToLower(lang)
+	if idx := strings.IndexAny(lang, "-_"); idx > 0 {
+		lang = lang[:idx]
+	}
+	return lang
+}
+
+// ReadTMX parses the translation units of a TMX document into memory entries.
+// The source variant is identified by the x-script property or, failing that, by the
+// language tag of a supported script; every other variant becomes an entry's target.
+// It also returns the number of units that could not be mapped to a supported script.
+func ReadTMX(r io.Reader) ([]MemoryEntry, int, error) {
+	var doc tmxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse TMX: %v", err)
+	}
+
+	scriptsByCode := make(map[string]string, len(scriptLanguageCodes))
+	for script, code := range scriptLanguageCodes {
+		scriptsByCode[code] = script
+	}
+
+	var entries []MemoryEntry
+	skipped := 0
+	for _, unit := range doc.Units {
+		script := ""
+		for _, prop := range unit.Props {
+			if prop.Type == tmxScriptProp {
+				script = strings.ToLower(strings.TrimSpace(prop.Value))
+			}
+		}
+
+		// Find the source variant
+		source := -1
+		for i, variant := range unit.Variants {
+			code := variant.language()
+			if script != "" && code == scriptLanguageCodes[script] {
+				source = i
+				break
+			}
+			if script == "" && scriptsByCode[code] != "" {
+				script = scriptsByCode[code]
+				source = i
+				break
+			}
+		}
+		if source < 0 {
+			skipped++
+			continue
+		}
+
+		createdAt, _ := time.Parse(tmxDateLayout, unit.CreationDate)
+		for i, variant := range unit.Variants {
+			if i == source || strings.TrimSpace(variant.Segment) == "" {
+				continue
+			}
+			entries = append(entries, MemoryEntry{
+				Source:         strings.TrimSpace(unit.Variants[source].Segment),
+				Target:         strings.TrimSpace(variant.Segment),
+				ScriptType:     script,
+				TargetLanguage: variant.language(),
+				CreatedAt:      createdAt,
+			})
+		}
+	}
+
+	return entries, skipped, nil
+}
+
+// WriteTMX writes memory entries as a TMX 1.4 document, one translation unit per entry
+func WriteTMX(w io.Writer, entries []MemoryEntry) error {
+	doc := tmxDocument{
+		Version: "1.4",
+		Header: tmxHeader{
+			CreationTool:        "ancient-script-decoder",
+			CreationToolVersion: "1.0",
+			SegType:             "sentence",
+			DataType:            "plaintext",
+			OTmf:                "ancient-script-decoder",
+			AdminLang:           "en",
+			SrcLang:             "*all*",
+		},
+	}
+
+	for _, entry := range entries {
+		sourceLang, ok := scriptLanguageCodes[entry.ScriptType]
+		if !ok {
+			sourceLang = entry.ScriptType
+		}
+
+		unit := tmxUnit{
+			Props: []tmxProp{{Type: tmxScriptProp, Value: entry.ScriptType}},
+			Variants: []tmxVariant{
+				{XMLLang: sourceLang, Segment: entry.Source},
+				{XMLLang: entry.TargetLanguage, Segment: entry.Target},
+			},
+		}
+		if !entry.CreatedAt.IsZero() {
+			unit.CreationDate = entry.CreatedAt.UTC().Format(tmxDateLayout)
+		}
+		doc.Units = append(doc.Units, unit)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write TMX: %v", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write TMX: %v", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write TMX: %v", err)
+	}
+	return nil
+}
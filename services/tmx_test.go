@@ -0,0 +1,126 @@
+package services
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTMXRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 15, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		entries []MemoryEntry
+	}{
+		{
+			name: "latin into english",
+			entries: []MemoryEntry{
+				{Source: "imperator caesar", Target: "Imperator Caesar", ScriptType: "latin", TargetLanguage: "en", CreatedAt: created},
+			},
+		},
+		{
+			name: "several scripts and languages",
+			entries: []MemoryEntry{
+				{Source: "βασιλεύς", Target: "king", ScriptType: "greek", TargetLanguage: "en", CreatedAt: created},
+				{Source: "rex", Target: "roi", ScriptType: "latin", TargetLanguage: "fr", CreatedAt: created},
+				{Source: "𒈗", Target: "king", ScriptType: "cuneiform", TargetLanguage: "en", CreatedAt: created},
+			},
+		},
+		{
+			name: "markup characters are escaped",
+			entries: []MemoryEntry{
+				{Source: "fec⟨i⟩t <rex> & [---]", Target: "made <the king> & ...", ScriptType: "latin", TargetLanguage: "en", CreatedAt: created},
+			},
+		},
+		{
+			name: "without creation date",
+			entries: []MemoryEntry{
+				{Source: "rex", Target: "king", ScriptType: "latin", TargetLanguage: "en"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteTMX(&buf, test.entries); err != nil {
+				t.Fatalf("WriteTMX() error = %v", err)
+			}
+			entries, skipped, err := ReadTMX(&buf)
+			if err != nil {
+				t.Fatalf("ReadTMX() error = %v", err)
+			}
+			if skipped != 0 {
+				t.Errorf("ReadTMX() skipped %d units, want 0", skipped)
+			}
+			if !reflect.DeepEqual(entries, test.entries) {
+				t.Errorf("ReadTMX(WriteTMX()) = %+v, want %+v", entries, test.entries)
+			}
+		})
+	}
+}
+
+func TestReadTMX(t *testing.T) {
+	tests := []struct {
+		name        string
+		tmx         string
+		want        []MemoryEntry
+		wantSkipped int
+		wantErr     bool
+	}{
+		{
+			name: "script from language tag with region",
+			tmx: `<tmx version="1.4"><header/><body>
+				<tu><tuv xml:lang="grc-GR"><seg>βασιλεύς</seg></tuv><tuv xml:lang="EN-GB"><seg>king</seg></tuv></tu>
+			</body></tmx>`,
+			want: []MemoryEntry{{Source: "βασιλεύς", Target: "king", ScriptType: "greek", TargetLanguage: "en"}},
+		},
+		{
+			name: "older lang attribute and several targets",
+			tmx: `<tmx version="1.1"><header/><body>
+				<tu><tuv lang="la"><seg> rex </seg></tuv><tuv lang="en"><seg>king</seg></tuv><tuv lang="de"><seg>König</seg></tuv></tu>
+			</body></tmx>`,
+			want: []MemoryEntry{
+				{Source: "rex", Target: "king", ScriptType: "latin", TargetLanguage: "en"},
+				{Source: "rex", Target: "König", ScriptType: "latin", TargetLanguage: "de"},
+			},
+		},
+		{
+			name: "script property picks the source",
+			tmx: `<tmx version="1.4"><header/><body>
+				<tu><prop type="x-script">Runic</prop><tuv xml:lang="en"><seg>king</seg></tuv><tuv xml:lang="non"><seg>konungr</seg></tuv></tu>
+			</body></tmx>`,
+			want: []MemoryEntry{{Source: "konungr", Target: "king", ScriptType: "runic", TargetLanguage: "en"}},
+		},
+		{
+			name: "unknown source language is skipped",
+			tmx: `<tmx version="1.4"><header/><body>
+				<tu><tuv xml:lang="fr"><seg>roi</seg></tuv><tuv xml:lang="en"><seg>king</seg></tuv></tu>
+				<tu><tuv xml:lang="la"><seg>rex</seg></tuv><tuv xml:lang="en"><seg></seg></tuv></tu>
+			</body></tmx>`,
+			wantSkipped: 1,
+		},
+		{
+			name:    "malformed document",
+			tmx:     `<tmx><body><tu>`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, skipped, err := ReadTMX(strings.NewReader(test.tmx))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ReadTMX() error = %v, want error %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(entries, test.want) {
+				t.Errorf("ReadTMX() = %+v, want %+v", entries, test.want)
+			}
+			if skipped != test.wantSkipped {
+				t.Errorf("ReadTMX() skipped = %d, want %d", skipped, test.wantSkipped)
+			}
+		})
+	}
+}
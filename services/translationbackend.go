@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"ancient-script-decoder/models"
+)
+
+// TranslationBackend is implemented by every translation engine the Translator can route to.
+// Rule-based engines, dictionaries, external HTTP services and local models all plug in here.
+type TranslationBackend interface {
+	// Name returns the identifier used to reference the backend in configuration
+	Name() string
+	// Supports reports whether the backend can translate the script into the target language
+	Supports(scriptType, targetLanguage string) bool
+	// Translate translates the request text, returning an error if the backend cannot handle it
+	Translate(req BackendRequest) (BackendResult, error)
+}
+
+// BackendRequest contains the input passed to a translation backend.
+// Text is in the form the script's lexicons use; OriginalText is the input as submitted.
+type BackendRequest struct {
+	Text           string
+	OriginalText   string
+	ScriptType     string
+	TargetLanguage string
+	// Terms lists the glossary terms the translation must use
+	Terms []models.GlossaryTerm
+	// MaxCandidates is the number of readings wanted; backends may return fewer
+	MaxCandidates int
+}
+
+// BackendResult contains the output produced by a translation backend.
+// Alignment is optional and relates spans of the request Text to spans of the result Text.
+// Score rates the translation between 0 and 1, or is 0 if the backend does not score its output.
+type BackendResult struct {
+	Text      string
+	Backend   string
+	Alignment []models.AlignmentPair
+	Score     float64
+	// Alternatives holds other readings of the request, best first, when more than one was asked for
+	Alternatives []BackendResult
+}
+
+// readings flattens the result and its alternatives into one list, naming the backend where it is missing
+func (r BackendResult) readings(backend string) []BackendResult {
+	readings := make([]BackendResult, 0, 1+len(r.Alternatives))
+	alternatives := r.Alternatives
+	r.Alternatives = nil
+	for _, reading := range append([]BackendResult{r}, alternatives...) {
+		if reading.Backend == "" {
+			reading.Backend = backend
+		}
+		readings = append(readings, reading)
+	}
+	return readings
+}
+
+// Names of the backends registered by default
+const (
+	BackendInternal = "internal"
+	BackendExternal = "external"
+)
+
+// backendRegistry stores the available backends by name
+type backendRegistry struct {
+	mu       sync.RWMutex
+	backends map[string]TranslationBackend
+}
+
+// newBackendRegistry creates an empty backend registry
+func newBackendRegistry() *backendRegistry {
+	return &backendRegistry{
+		backends: make(map[string]TranslationBackend),
+	}
+}
+
+// register adds or replaces a backend
+func (r *backendRegistry) register(backend TranslationBackend) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backends[strings.ToLower(backend.Name())] = backend
+}
+
+// get looks up a backend by name
+func (r *backendRegistry) get(name string) (TranslationBackend, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	backend, ok := r.backends[strings.ToLower(name)]
+	return backend, ok
+}
+
+// funcBackend adapts plain functions to the TranslationBackend interface
+type funcBackend struct {
+	name     string
+	fn       func(text, scriptType, targetLanguage string) (string, float64, error)
+	supports func(scriptType, targetLanguage string) bool
+}
+
+// Name implements the TranslationBackend interface
+func (b *funcBackend) Name() string {
+	return b.name
+}
+
+// Supports implements the TranslationBackend interface
+func (b *funcBackend) Supports(scriptType, targetLanguage string) bool {
+	return b.supports(scriptType, targetLanguage)
+}
+
+// Translate implements the TranslationBackend interface
+func (b *funcBackend) Translate(req BackendRequest) (BackendResult, error) {
+	text, score, err := b.fn(req.Text, req.ScriptType, req.TargetLanguage)
+	if err != nil {
+		return BackendResult{}, err
+	}
+	return BackendResult{Text: text, Backend: b.name, Score: score}, nil
+}
+
+// internalBackend exposes the lexicon-driven gloss engine, which also reports word alignment
+type internalBackend struct {
+	translator *Translator
+}
+
+// Name implements the TranslationBackend interface
+func (b *internalBackend) Name() string {
+	return BackendInternal
+}
+
+// Supports implements the TranslationBackend interface
+func (b *internalBackend) Supports(scriptType, targetLanguage string) bool {
+	return b.translator.internalSupports(scriptType, targetLanguage)
+}
+
+// Translate implements the TranslationBackend interface
+func (b *internalBackend) Translate(req BackendRequest) (BackendResult, error) {
+	glosses := b.translator.glossWithAlignment(req.Text, req.ScriptType, req.TargetLanguage, req.Terms, req.MaxCandidates)
+	result := glosses[0]
+	result.Alternatives = glosses[1:]
+	return result, nil
+}
+
+// BackendChainError is returned when every backend in a fallback chain failed
+type BackendChainError struct {
+	ScriptType string
+	Failures   map[string]error
+	Order      []string
+}
+
+// Error implements the error interface
+func (e *BackendChainError) Error() string {
+	if len(e.Order) == 0 {
+		return fmt.Sprintf("no translation backend configured for script %s", e.ScriptType)
+	}
+
+	parts := make([]string, 0, len(e.Order))
+	for _, name := range e.Order {
+		parts = append(parts, fmt.Sprintf("%s: %v", name, e.Failures[name]))
+	}
+	return fmt.Sprintf("all translation backends failed for script %s (%s)", e.ScriptType, strings.Join(parts, "; "))
+}
+
+// UnsupportedLanguagePairError is returned when no backend of the chain supports a script and target language
+type UnsupportedLanguagePairError struct {
+	ScriptType     string
+	TargetLanguage string
+	Backends       []string
+}
+
+// Error implements the error interface
+func (e *UnsupportedLanguagePairError) Error() string {
+	return fmt.Sprintf("translation from %s script into %q is not supported by any configured backend (tried: %s)",
+		e.ScriptType, e.TargetLanguage, strings.Join(e.Backends, ", "))
+}
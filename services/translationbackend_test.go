@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// stubBackend is a translation backend returning a fixed result or error for the target languages it serves
+type stubBackend struct {
+	name      string
+	languages []string
+	result    BackendResult
+	err       error
+	calls     int
+}
+
+// Name implements the TranslationBackend interface
+func (b *stubBackend) Name() string {
+	return b.name
+}
+
+// Supports implements the TranslationBackend interface
+func (b *stubBackend) Supports(scriptType, targetLanguage string) bool {
+	for _, language := range b.languages {
+		if language == targetLanguage {
+			return true
+		}
+	}
+	return false
+}
+
+// Translate implements the TranslationBackend interface
+func (b *stubBackend) Translate(req BackendRequest) (BackendResult, error) {
+	b.calls++
+	return b.result, b.err
+}
+
+// chainTranslator creates a translator trying the backends for Latin in the given chain order
+func chainTranslator(chain []string, backends ...*stubBackend) *Translator {
+	translator := NewTranslator(TranslationConfig{
+		SupportedScripts: []string{"latin"},
+		ScriptBackends:   map[string][]string{"Latin": chain},
+	})
+	for _, backend := range backends {
+		translator.RegisterBackend(backend)
+	}
+	return translator
+}
+
+// textsOf returns the texts and backends of the results as "text@backend"
+func textsOf(results []BackendResult) []string {
+	texts := make([]string, 0, len(results))
+	for _, result := range results {
+		texts = append(texts, result.Text+"@"+result.Backend)
+	}
+	return texts
+}
+
+func TestTranslateWithChainFallback(t *testing.T) {
+	failing := &stubBackend{name: "failing", languages: []string{"en"}, err: errors.New("unavailable")}
+	french := &stubBackend{name: "french", languages: []string{"fr"}, result: BackendResult{Text: "roi"}}
+	working := &stubBackend{name: "working", languages: []string{"en"}, result: BackendResult{Text: "king"}}
+	later := &stubBackend{name: "later", languages: []string{"en"}, result: BackendResult{Text: "ruler"}}
+	translator := chainTranslator([]string{"failing", "missing", "french", "working", "later"}, failing, french, working, later)
+
+	results, err := translator.translateWithChain(BackendRequest{Text: "rex", ScriptType: "latin", TargetLanguage: "en"})
+	if err != nil {
+		t.Fatalf("translateWithChain() error = %v", err)
+	}
+	if got, want := textsOf(results), []string{"king@working"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("translateWithChain() = %q, want %q", got, want)
+	}
+	// The unsupported backend is skipped and the chain stops at the first translation
+	if calls := []int{failing.calls, french.calls, working.calls, later.calls}; !reflect.DeepEqual(calls, []int{1, 0, 1, 0}) {
+		t.Errorf("backend calls = %v, want [1 0 1 0]", calls)
+	}
+}
+
+func TestTranslateWithChainErrors(t *testing.T) {
+	t.Run("unsupported language pair", func(t *testing.T) {
+		first := &stubBackend{name: "first", languages: []string{"en"}, result: BackendResult{Text: "king"}}
+		second := &stubBackend{name: "second", languages: []string{"de"}, result: BackendResult{Text: "König"}}
+		translator := chainTranslator([]string{"first", "second"}, first, second)
+
+		_, err := translator.translateWithChain(BackendRequest{Text: "rex", ScriptType: "latin", TargetLanguage: "fr"})
+		var pairErr *UnsupportedLanguagePairError
+		if !errors.As(err, &pairErr) {
+			t.Fatalf("translateWithChain() error = %v, want an UnsupportedLanguagePairError", err)
+		}
+		if want := []string{"first", "second"}; pairErr.TargetLanguage != "fr" || !reflect.DeepEqual(pairErr.Backends, want) {
+			t.Errorf("translateWithChain() error = %+v, want target fr and backends %q", pairErr, want)
+		}
+	})
+
+	t.Run("every backend fails", func(t *testing.T) {
+		first := &stubBackend{name: "first", languages: []string{"en"}, err: errors.New("timeout")}
+		second := &stubBackend{name: "second", languages: []string{"en"}, err: errors.New("quota exceeded")}
+		translator := chainTranslator([]string{"first", "missing", "second"}, first, second)
+
+		_, err := translator.translateWithChain(BackendRequest{Text: "rex", ScriptType: "latin", TargetLanguage: "en"})
+		var chainErr *BackendChainError
+		if !errors.As(err, &chainErr) {
+			t.Fatalf("translateWithChain() error = %v, want a BackendChainError", err)
+		}
+		if want := []string{"first", "missing", "second"}; !reflect.DeepEqual(chainErr.Order, want) {
+			t.Errorf("BackendChainError.Order = %q, want %q", chainErr.Order, want)
+		}
+		if chainErr.Failures["first"] != first.err || chainErr.Failures["second"] != second.err || chainErr.Failures["missing"] == nil {
+			t.Errorf("BackendChainError.Failures = %v, want the failure of every backend", chainErr.Failures)
+		}
+	})
+}
+
+func TestTranslateWithChainMergesAlternatives(t *testing.T) {
+	first := &stubBackend{name: "first", languages: []string{"en"}, result: BackendResult{
+		Text: "king", Score: 0.9,
+		Alternatives: []BackendResult{{Text: "ruler", Score: 0.5}},
+	}}
+	second := &stubBackend{name: "second", languages: []string{"en"}, result: BackendResult{
+		Text: "monarch", Backend: "second-model", Score: 0.8,
+		Alternatives: []BackendResult{{Text: "lord", Score: 0.7}, {Text: "chief", Score: 0.6}},
+	}}
+
+	tests := []struct {
+		name          string
+		maxCandidates int
+		want          []string
+		wantCalls     int
+	}{
+		{name: "one candidate", maxCandidates: 1, want: []string{"king@first"}, wantCalls: 0},
+		{
+			// The first translation stays on top and the alternatives of both backends are ranked by score
+			name:          "alternatives ranked across backends",
+			maxCandidates: 4,
+			want:          []string{"king@first", "monarch@second-model", "lord@second", "chief@second"},
+			wantCalls:     1,
+		},
+		{
+			name:          "fewer readings than wanted",
+			maxCandidates: 10,
+			want:          []string{"king@first", "monarch@second-model", "lord@second", "chief@second", "ruler@first"},
+			wantCalls:     1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			second.calls = 0
+			translator := chainTranslator([]string{"first", "second"}, first, second)
+			results, err := translator.translateWithChain(BackendRequest{
+				Text: "rex", ScriptType: "latin", TargetLanguage: "en", MaxCandidates: test.maxCandidates,
+			})
+			if err != nil {
+				t.Fatalf("translateWithChain() error = %v", err)
+			}
+			if got := textsOf(results); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("translateWithChain() = %q, want %q", got, test.want)
+			}
+			if second.calls != test.wantCalls {
+				t.Errorf("second backend calls = %d, want %d", second.calls, test.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"ancient-script-decoder/models"
+)
+
+// BackendMemory is reported as the backend of translations taken from the translation memory
+const BackendMemory = "memory"
+
+// MemoryEntry is an approved pair of source and target segments
+type MemoryEntry struct {
+	Source         string
+	Target         string
+	ScriptType     string
+	TargetLanguage string
+	CreatedAt      time.Time
+}
+
+// MemoryMatch is a memory entry found for a segment, with its similarity as a percentage
+type MemoryMatch struct {
+	Entry        MemoryEntry
+	MatchPercent int
+}
+
+// memoryKey identifies the entries of one language pair
+type memoryKey struct {
+	scriptType     string
+	targetLanguage string
+}
+
+// TranslationMemory stores approved segment pairs and finds exact and fuzzy matches.
+// Segments are compared in their lexicon-normalized form, so case, punctuation and
+// orthographic variants such as u/v do not lower the match percentage.
+type TranslationMemory struct {
+	mu sync.RWMutex
+	// path is the TMX file the memory is persisted to, empty for an in-memory store
+	path    string
+	entries map[memoryKey][]MemoryEntry
+	// normalized holds the comparison form of each entry, parallel to entries
+	normalized map[memoryKey][]string
+	// trigrams maps each trigram of a normalized source to the entries containing it
+	trigrams map[memoryKey]map[string][]int
+	// exact maps normalized sources to their entry for exact lookups
+	exact map[memoryKey]map[string]int
+}
+
+// NewTranslationMemory creates an empty translation memory persisted to path
+func NewTranslationMemory(path string) *TranslationMemory {
+	return &TranslationMemory{
+		path:       path,
+		entries:    make(map[memoryKey][]MemoryEntry),
+		normalized: make(map[memoryKey][]string),
+		trigrams:   make(map[memoryKey]map[string][]int),
+		exact:      make(map[memoryKey]map[string]int),
+	}
+}
+
+// LoadTranslationMemory creates a translation memory from the TMX file at path.
+// A missing file yields an empty memory that will be created on the first save.
+func LoadTranslationMemory(path string) (*TranslationMemory, error) {
+	memory := NewTranslationMemory(path)
+	if path == "" {
+		return memory, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return memory, nil
+	}
+	if err != nil {
+		return memory, fmt.Errorf("failed to open translation memory: %v", err)
+	}
+	defer file.Close()
+
+	entries, _, err := ReadTMX(file)
+	if err != nil {
+		return memory, err
+	}
+	for _, entry := range entries {
+		memory.add(entry)
+	}
+	return memory, nil
+}
+
+// Add stores an approved segment pair, replacing the target of an identical source, and persists the memory
+func (m *TranslationMemory) Add(entry MemoryEntry) error {
+	if err := validateMemoryEntry(entry); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.add(entry)
+	return m.save()
+}
+
+// Import stores several segment pairs and persists the memory once, returning how many were stored
+func (m *TranslationMemory) Import(entries []MemoryEntry) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	imported := 0
+	for _, entry := range entries {
+		if validateMemoryEntry(entry) != nil {
+			continue
+		}
+		m.add(entry)
+		imported++
+	}
+	return imported, m.save()
+}
+
+// Entries returns a copy of all stored entries
+func (m *TranslationMemory) Entries() []MemoryEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sortedEntries()
+}
+
+// sortedEntries collects the entries of all language pairs, oldest first; the caller must hold the lock
+func (m *TranslationMemory) sortedEntries() []MemoryEntry {
+	var entries []MemoryEntry
+	for _, pairEntries := range m.entries {
+		entries = append(entries, pairEntries...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].Source < entries[j].Source
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries
+}
+
+// Size returns the number of stored entries
+func (m *TranslationMemory) Size() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	size := 0
+	for _, pairEntries := range m.entries {
+		size += len(pairEntries)
+	}
+	return size
+}
+
+// Lookup returns the entries whose source matches the segment by at least minPercent,
+// best first and at most limit of them. An exact match scores 100.
+func (m *TranslationMemory) Lookup(source, scriptType, targetLanguage string, minPercent, limit int) []MemoryMatch {
+	key := memoryKey{strings.ToLower(scriptType), strings.ToLower(targetLanguage)}
+	query := normalizeSegment(source, key.scriptType)
+	if query == "" {
+		return nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if idx, ok := m.exact[key][query]; ok {
+		return []MemoryMatch{{Entry: m.entries[key][idx], MatchPercent: 100}}
+	}
+
+	// Count the trigrams each entry shares with the query to find candidates
+	queryTrigrams := segmentTrigrams(query)
+	shared := make(map[int]int)
+	for _, trigram := range queryTrigrams {
+		for _, idx := range m.trigrams[key][trigram] {
+			shared[idx]++
+		}
+	}
+
+	var matches []MemoryMatch
+	for idx, count := range shared {
+		candidate := m.normalized[key][idx]
+
+		// The Dice coefficient bounds the similarity cheaply before the edit distance is computed
+		dice := 2 * float64(count) / float64(len(queryTrigrams)+len(segmentTrigrams(candidate)))
+		if dice*100 < float64(minPercent)/2 {
+			continue
+		}
+
+		percent := similarityPercent(query, candidate)
+		if percent < minPercent || percent == 100 {
+			// Identical normalized segments are found by the exact lookup
+			continue
+		}
+		matches = append(matches, MemoryMatch{Entry: m.entries[key][idx], MatchPercent: percent})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].MatchPercent == matches[j].MatchPercent {
+			return matches[i].Entry.Source < matches[j].Entry.Source
+		}
+		return matches[i].MatchPercent > matches[j].MatchPercent
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// add indexes an entry; the caller must hold the write lock
+func (m *TranslationMemory) add(entry MemoryEntry) {
+	entry.ScriptType = strings.ToLower(entry.ScriptType)
+	entry.TargetLanguage = strings.ToLower(entry.TargetLanguage)
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	key := memoryKey{entry.ScriptType, entry.TargetLanguage}
+	normalized := normalizeSegment(entry.Source, key.scriptType)
+	if normalized == "" {
+		return
+	}
+
+	if m.exact[key] == nil {
+		m.exact[key] = make(map[string]int)
+		m.trigrams[key] = make(map[string][]int)
+	}
+
+	// A newly approved translation replaces the previous one for the same source
+	if idx, ok := m.exact[key][normalized]; ok {
+		m.entries[key][idx] = entry
+		return
+	}
+
+	idx := len(m.entries[key])
+	m.entries[key] = append(m.entries[key], entry)
+	m.normalized[key] = append(m.normalized[key], normalized)
+	m.exact[key][normalized] = idx
+
+	seen := make(map[string]bool)
+	for _, trigram := range segmentTrigrams(normalized) {
+		if seen[trigram] {
+			continue
+		}
+		seen[trigram] = true
+		m.trigrams[key][trigram] = append(m.trigrams[key][trigram], idx)
+	}
+}
+
+// save writes the memory to its TMX file; the caller must hold the lock
+func (m *TranslationMemory) save() error {
+	if m.path == "" {
+		return nil
+	}
+	entries := m.sortedEntries()
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to save translation memory: %v", err)
+	}
+
+	// Write to a temporary file first so a failed save does not truncate the memory
+	tmp := m.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to save translation memory: %v", err)
+	}
+	if err := WriteTMX(file, entries); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save translation memory: %v", err)
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return fmt.Errorf("failed to save translation memory: %v", err)
+	}
+	return nil
+}
+
+// memoryAlignment aligns a whole segment with the translation taken from memory
+func memoryAlignment(source, target string) models.AlignmentPair {
+	return models.AlignmentPair{
+		Source:     models.TextSpan{Start: 0, End: utf8.RuneCountInString(source), Text: source},
+		Target:     models.TextSpan{Start: 0, End: utf8.RuneCountInString(target), Text: target},
+		Confidence: 1,
+	}
+}
+
+// validateMemoryEntry checks that an entry names both segments and the language pair
+func validateMemoryEntry(entry MemoryEntry) error {
+	switch {
+	case strings.TrimSpace(entry.Source) == "":
+		return fmt.Errorf("memory entry has no source segment")
+	case strings.TrimSpace(entry.Target) == "":
+		return fmt.Errorf("memory entry has no target segment")
+	case entry.ScriptType == "":
+		return fmt.Errorf("memory entry has no script type")
+	case entry.TargetLanguage == "":
+		return fmt.Errorf("memory entry has no target language")
+	}
+	return nil
+}
+
+// normalizeSegment reduces a segment to its normalized words separated by single spaces
+func normalizeSegment(text, scriptType string) string {
+	words := tokenizeSource(text, scriptType)
+	forms := make([]string, len(words))
+	for i, word := range words {
+		forms[i] = word.normalized
+	}
+	return strings.Join(forms, " ")
+}
+
+// segmentTrigrams returns the character trigrams of a segment padded with spaces
+func segmentTrigrams(segment string) []string {
+	runes := []rune(" " + segment + " ")
+	if len(runes) < 3 {
+		return nil
+	}
+	trigrams := make([]string, 0, len(runes)-2)
+	for i := 0; i+3 <= len(runes); i++ {
+		trigrams = append(trigrams, string(runes[i:i+3]))
+	}
+	return trigrams
+}
+
+// similarityPercent scores two segments by their character edit distance relative to the longer one.
+// The score is rounded down so that only identical segments reach 100.
+func similarityPercent(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 100
+	}
+	distance := levenshtein(ra, rb)
+	return 100 * (longest - distance) / longest
+}
+
+// levenshtein computes the edit distance between two rune sequences
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -2,7 +2,14 @@ package services
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
+
+	"ancient-script-decoder/models"
+	"ancient-script-decoder/services/leiden"
+	"ancient-script-decoder/services/transliteration"
+	"ancient-script-decoder/utils"
 )
 
 // TranslationConfig contains the configuration for translation
@@ -12,43 +19,454 @@ type TranslationConfig struct {
 	UseExternalAPI        bool     `yaml:"useExternalAPI"`
 	APIEndpoint           string   `yaml:"apiEndpoint"`
 	APIKey                string   `yaml:"apiKey"`
+	// Settings of the external API client
+	APITimeoutMs                  int     `yaml:"apiTimeoutMs"`
+	APIMaxRetries                 int     `yaml:"apiMaxRetries"`
+	APIRetryBackoffMs             int     `yaml:"apiRetryBackoffMs"`
+	APIRateLimitPerSecond         float64 `yaml:"apiRateLimitPerSecond"`
+	APICircuitBreakerThreshold    int     `yaml:"apiCircuitBreakerThreshold"`
+	APICircuitBreakerResetSeconds int     `yaml:"apiCircuitBreakerResetSeconds"`
+	// APITargetLanguages lists the target languages the external API serves; empty means any
+	APITargetLanguages []string `yaml:"apiTargetLanguages"`
+	// LexiconDir contains the per-script bilingual lexicons used by the internal engine.
+	// Lexicons into languages other than DefaultTargetLanguage live in subdirectories named after the language.
+	LexiconDir string `yaml:"lexiconDir"`
+	// DefaultBackends is the fallback chain used for scripts without their own entry
+	DefaultBackends []string `yaml:"defaultBackends"`
+	// ScriptBackends maps a script type to an ordered fallback chain of backend names
+	ScriptBackends map[string][]string `yaml:"scriptBackends"`
+	// MemoryPath is the TMX file the translation memory is loaded from and saved to; empty keeps it in memory
+	MemoryPath string `yaml:"memoryPath"`
+	// MemoryMinMatchPercent is the lowest similarity reported as a fuzzy match
+	MemoryMinMatchPercent int `yaml:"memoryMinMatchPercent"`
+	// MemoryMaxMatches limits the number of fuzzy matches returned with a translation
+	MemoryMaxMatches int `yaml:"memoryMaxMatches"`
+	// GlossaryPath is the JSON file the per-project glossaries are stored in
+	GlossaryPath string `yaml:"glossaryPath"`
+	// MaxCandidates caps the number of alternative translations a request may ask for
+	MaxCandidates int `yaml:"maxCandidates"`
 }
 
 // Translator handles the translation of ancient scripts
 type Translator struct {
-	config TranslationConfig
+	config    TranslationConfig
+	backends  *backendRegistry
+	apiClient *ExternalAPIClient
+	// lexicons holds the lexicons by target language, then by script
+	lexicons map[string]map[string]*Lexicon
+	// lexiconErr records why the lexicons could not be loaded
+	lexiconErr error
+	// memory holds approved translations consulted before the backends
+	memory *TranslationMemory
+	// memoryErr records why the translation memory could not be loaded
+	memoryErr error
+	// glossaries holds the per-project terminology enforced on translations
+	glossaries *GlossaryStore
+	// glossaryErr records why the glossaries could not be loaded
+	glossaryErr error
 }
 
 // NewTranslator creates a new translator
 func NewTranslator(config TranslationConfig) *Translator {
-	return &Translator{
-		config: config,
+	t := &Translator{
+		config:   config,
+		backends: newBackendRegistry(),
+		lexicons: make(map[string]map[string]*Lexicon),
+	}
+
+	if t.config.DefaultTargetLanguage == "" {
+		t.config.DefaultTargetLanguage = "en"
+	}
+	t.config.DefaultTargetLanguage = strings.ToLower(t.config.DefaultTargetLanguage)
+
+	// Load the lexicons of the internal engine
+	if config.LexiconDir != "" {
+		lexicons, err := LoadLexiconSet(config.LexiconDir, t.config.DefaultTargetLanguage)
+		if err != nil {
+			t.lexiconErr = err
+		} else {
+			t.lexicons = lexicons
+		}
+	}
+
+	// Load the translation memory; on failure it starts empty and is not saved over the unreadable file
+	memory, err := LoadTranslationMemory(config.MemoryPath)
+	if err != nil {
+		t.memoryErr = err
+		memory = NewTranslationMemory("")
+	}
+	t.memory = memory
+	if t.config.MemoryMinMatchPercent <= 0 {
+		t.config.MemoryMinMatchPercent = 75
+	}
+	if t.config.MemoryMaxMatches <= 0 {
+		t.config.MemoryMaxMatches = 3
+	}
+	if t.config.MaxCandidates <= 0 {
+		t.config.MaxCandidates = 5
+	}
+
+	// Load the glossaries; on failure the store starts empty and is not saved over the unreadable file
+	glossaries, err := LoadGlossaryStore(config.GlossaryPath)
+	if err != nil {
+		t.glossaryErr = err
+		glossaries = NewGlossaryStore("")
+	}
+	t.glossaries = glossaries
+
+	// Create the external API client when an endpoint is configured
+	if config.APIEndpoint != "" {
+		t.apiClient = NewExternalAPIClient(ExternalAPIClientConfig{
+			Endpoint:                config.APIEndpoint,
+			APIKey:                  config.APIKey,
+			Timeout:                 time.Duration(config.APITimeoutMs) * time.Millisecond,
+			MaxRetries:              config.APIMaxRetries,
+			RetryBackoff:            time.Duration(config.APIRetryBackoffMs) * time.Millisecond,
+			RateLimitPerSecond:      config.APIRateLimitPerSecond,
+			CircuitBreakerThreshold: config.APICircuitBreakerThreshold,
+			CircuitBreakerReset:     time.Duration(config.APICircuitBreakerResetSeconds) * time.Second,
+		})
+	}
+
+	// Register the built-in backends
+	t.RegisterBackend(&internalBackend{translator: t})
+	t.RegisterBackend(&funcBackend{
+		name:     BackendExternal,
+		fn:       t.translateWithExternalAPI,
+		supports: t.externalSupports,
+	})
+
+	return t
+}
+
+// LexiconError returns the error encountered while loading the lexicons, if any
+func (t *Translator) LexiconError() error {
+	return t.lexiconErr
+}
+
+// MemoryError returns the error encountered while loading the translation memory, if any
+func (t *Translator) MemoryError() error {
+	return t.memoryErr
+}
+
+// Memory returns the translation memory consulted before the backends
+func (t *Translator) Memory() *TranslationMemory {
+	return t.memory
+}
+
+// GlossaryError returns the error encountered while loading the glossaries, if any
+func (t *Translator) GlossaryError() error {
+	return t.glossaryErr
+}
+
+// Glossaries returns the store of per-project glossaries
+func (t *Translator) Glossaries() *GlossaryStore {
+	return t.glossaries
+}
+
+// AddToMemory stores an approved translation; an empty target language selects the default
+func (t *Translator) AddToMemory(entry MemoryEntry) error {
+	if !t.isScriptSupported(entry.ScriptType) {
+		return fmt.Errorf("unsupported script type: %s", entry.ScriptType)
 	}
+	if entry.TargetLanguage == "" {
+		entry.TargetLanguage = t.config.DefaultTargetLanguage
+	}
+	entry.Source = t.prepareInput(entry.Source, strings.ToLower(entry.ScriptType))
+	return t.memory.Add(entry)
+}
+
+// LexiconSizes returns the number of entries loaded for each script, keyed as "script>language"
+func (t *Translator) LexiconSizes() map[string]int {
+	sizes := make(map[string]int)
+	for language, lexicons := range t.lexicons {
+		for script, lexicon := range lexicons {
+			sizes[script+">"+language] = lexicon.Size()
+		}
+	}
+	return sizes
+}
+
+// DefaultTargetLanguage returns the language used when a request does not name one
+func (t *Translator) DefaultTargetLanguage() string {
+	return t.config.DefaultTargetLanguage
+}
+
+// RegisterBackend makes a backend available to the fallback chains, replacing any backend with the same name
+func (t *Translator) RegisterBackend(backend TranslationBackend) {
+	t.backends.register(backend)
+}
+
+// BackendChain returns the ordered backend names tried for a script type
+func (t *Translator) BackendChain(scriptType string) []string {
+	for script, chain := range t.config.ScriptBackends {
+		if strings.EqualFold(script, scriptType) && len(chain) > 0 {
+			return chain
+		}
+	}
+
+	if len(t.config.DefaultBackends) > 0 {
+		return t.config.DefaultBackends
+	}
+
+	// Without explicit configuration, keep the behaviour of the UseExternalAPI switch
+	if t.config.UseExternalAPI {
+		return []string{BackendExternal, BackendInternal}
+	}
+	return []string{BackendInternal}
+}
+
+// TranslationOutput contains a translation together with the script it was read as
+type TranslationOutput struct {
+	Text           string
+	ScriptType     string
+	TargetLanguage string
+	Backend        string
+	// ScriptCandidates lists the detected scripts when the script type was "auto"
+	ScriptCandidates []models.ScriptCandidate
+	// SourceText is the input as read by the backends, which the alignment source spans refer to
+	SourceText string
+	// SourceTokens is the word-by-word reading of input written in Leiden notation
+	SourceTokens []models.LeidenToken
+	// Alignment relates source and translated words when the backend reports it
+	Alignment []models.AlignmentPair
+	// MemoryMatches lists the translation memory entries similar to the input, best first
+	MemoryMatches []MemoryMatch
+	// GlossaryViolations lists the glossary terms the translation did not render as required
+	GlossaryViolations []models.GlossaryViolation
+	// Candidates lists the alternative translations with their scores, best first; the first is Text
+	Candidates []models.TranslationCandidate
+}
+
+// TranslationOptions contains the per-request settings of a translation
+type TranslationOptions struct {
+	// TargetLanguage is the language to translate into; empty selects the configured default
+	TargetLanguage string
+	// Project selects the glossaries enforced on the translation; empty applies none
+	Project string
+	// Candidates is the number of alternative translations wanted, capped by the configured maximum;
+	// values below 1 ask for the translation only
+	Candidates int
+	// AnalyzeAllCandidates makes the ServiceHandler summarize and extract metadata for every candidate,
+	// not only for the top one
+	AnalyzeAllCandidates bool
+	// ReadingDirection is the direction the lines of a manuscript image are read in; empty selects
+	// the configured default. It only applies to text recognized from an image
+	ReadingDirection utils.ReadingDirection
+	// Pipeline names the preset a manuscript image is preprocessed with before OCR, and
+	// PipelineSteps gives the steps instead; neither selects the default pipeline. They only
+	// apply to text recognized from an image
+	Pipeline      string
+	PipelineSteps []utils.PipelineStep
 }
 
 // TranslateText translates the extracted text to the target language
-func (t *Translator) TranslateText(text, scriptType string) (string, error) {
+// An empty target language selects the configured default
+func (t *Translator) TranslateText(text, scriptType, targetLanguage string) (string, error) {
+	output, err := t.Translate(text, scriptType, TranslationOptions{TargetLanguage: targetLanguage})
+	if err != nil {
+		return "", err
+	}
+	return output.Text, nil
+}
+
+// Translate translates the text and reports the script and backend that were used
+func (t *Translator) Translate(text, scriptType string, options TranslationOptions) (TranslationOutput, error) {
 	// Validate script type
 	if scriptType != "auto" && !t.isScriptSupported(scriptType) {
-		return "", fmt.Errorf("unsupported script type: %s", scriptType)
+		return TranslationOutput{}, fmt.Errorf("unsupported script type: %s", scriptType)
+	}
+
+	targetLanguage := options.TargetLanguage
+	if targetLanguage == "" {
+		targetLanguage = t.config.DefaultTargetLanguage
+	}
+
+	output := TranslationOutput{
+		ScriptType:     strings.ToLower(scriptType),
+		TargetLanguage: strings.ToLower(targetLanguage),
+	}
+
+	// Text in Leiden notation is read into words of known certainty; the backends see the plain reading
+	var tokens []leiden.Token
+	plain := text
+	if leiden.HasMarkup(text) {
+		tokens = leiden.Parse(text)
+		plain = leiden.Plain(tokens)
 	}
 
 	// If script type is auto, attempt to detect it
 	if scriptType == "auto" {
-		var err error
-		scriptType, err = t.detectScriptType(text)
+		detected, candidates, err := t.detectScriptType(plain)
 		if err != nil {
-			return "", fmt.Errorf("failed to detect script type: %v", err)
+			return TranslationOutput{}, fmt.Errorf("failed to detect script type: %v", err)
 		}
+		output.ScriptType = detected
+		output.ScriptCandidates = candidates
 	}
 
-	// If external API is enabled, use it for translation
-	if t.config.UseExternalAPI {
-		return t.translateWithExternalAPI(text, scriptType)
+	// Accept both native script and romanized input
+	var prepared string
+	var edition *leidenEdition
+	if tokens != nil {
+		prepared, edition = t.prepareEdition(tokens, output.ScriptType)
+		output.SourceTokens = edition.tokens
+	} else {
+		prepared = t.prepareInput(text, output.ScriptType)
+	}
+	output.SourceText = prepared
+
+	wanted := min(max(options.Candidates, 1), t.config.MaxCandidates)
+
+	// An exact memory match is an approved translation and needs no backend;
+	// fuzzy matches are returned alongside the backend's translation
+	output.MemoryMatches = t.memory.Lookup(prepared, output.ScriptType, output.TargetLanguage,
+		t.config.MemoryMinMatchPercent, t.config.MemoryMaxMatches)
+	terms := t.glossaries.termsFor(options.Project, output.ScriptType, output.TargetLanguage)
+	var candidates []BackendResult
+	if len(output.MemoryMatches) > 0 && output.MemoryMatches[0].MatchPercent == 100 {
+		target := output.MemoryMatches[0].Entry.Target
+		candidates = append(candidates, BackendResult{
+			Text:      target,
+			Backend:   BackendMemory,
+			Alignment: []models.AlignmentPair{memoryAlignment(prepared, target)},
+			Score:     1,
+		})
+	}
+
+	// The backends supply the translation, or the alternatives to an approved one
+	if len(candidates) < wanted {
+		results, err := t.translateWithChain(BackendRequest{
+			Text:           prepared,
+			OriginalText:   text,
+			ScriptType:     output.ScriptType,
+			TargetLanguage: output.TargetLanguage,
+			Terms:          plainTerms(terms),
+			MaxCandidates:  wanted - len(candidates),
+		})
+		if err != nil && len(candidates) == 0 {
+			return TranslationOutput{}, err
+		}
+		candidates = append(candidates, results...)
+	}
+
+	output.Text = candidates[0].Text
+	output.Backend = candidates[0].Backend
+	output.Alignment = candidates[0].Alignment
+
+	// Post-edit the renderings of the project's glossary terms
+	output.Text, output.Alignment, output.GlossaryViolations = enforceGlossary(
+		prepared, output.Text, output.Alignment, terms, output.ScriptType)
+	if edition != nil {
+		output.Text, output.Alignment = edition.mark(output.Text, output.Alignment)
+	}
+
+	// The alternatives are post-edited too, but only the top candidate's violations are reported
+	seen := make(map[string]bool)
+	for i, candidate := range candidates {
+		text := output.Text
+		if i > 0 {
+			alignment := candidate.Alignment
+			text, alignment, _ = enforceGlossary(prepared, candidate.Text, alignment, terms, output.ScriptType)
+			if edition != nil {
+				text, _ = edition.mark(text, alignment)
+			}
+		}
+		if seen[text] {
+			continue
+		}
+		seen[text] = true
+		output.Candidates = append(output.Candidates, models.TranslationCandidate{
+			Text:    text,
+			Score:   candidate.Score,
+			Backend: candidate.Backend,
+		})
+	}
+	return output, nil
+}
+
+// romanizedScripts are translated from their romanization; the others from native Unicode
+var romanizedScripts = map[string]bool{
+	"cuneiform":    true,
+	"hieroglyphic": true,
+	"runic":        true,
+}
+
+// prepareInput transliterates text into the form used by the script's lexicons
+func (t *Translator) prepareInput(text, scriptType string) string {
+	translit, err := transliteration.Get(scriptType)
+	if err != nil {
+		// Scripts without a transliteration are passed through unchanged
+		return text
+	}
+
+	romanized := transliteration.IsRomanized(scriptType, text)
+	switch {
+	case romanizedScripts[scriptType] && !romanized:
+		return translit.ToRoman(text).Text
+	case !romanizedScripts[scriptType] && romanized:
+		return translit.ToUnicode(text).Text
+	default:
+		return text
+	}
+}
+
+// translateWithChain runs the request through the configured fallback chain.
+// The first backend that succeeds provides the translation. When more candidates are
+// wanted than it returned, the remaining backends of the chain are asked as well; their
+// readings rank below the translation by score. At most req.MaxCandidates are returned.
+func (t *Translator) translateWithChain(req BackendRequest) ([]BackendResult, error) {
+	chainErr := &BackendChainError{
+		ScriptType: req.ScriptType,
+		Failures:   make(map[string]error),
+	}
+
+	wanted := max(req.MaxCandidates, 1)
+	var candidates []BackendResult
+	chain := t.BackendChain(req.ScriptType)
+	supported := false
+	for _, name := range chain {
+		if len(candidates) >= wanted {
+			break
+		}
+
+		backend, ok := t.backends.get(name)
+		if !ok {
+			chainErr.Order = append(chainErr.Order, name)
+			chainErr.Failures[name] = fmt.Errorf("backend not registered")
+			continue
+		}
+
+		// Skip backends that do not serve this language pair
+		if !backend.Supports(req.ScriptType, req.TargetLanguage) {
+			continue
+		}
+		supported = true
+		chainErr.Order = append(chainErr.Order, name)
+
+		result, err := backend.Translate(req)
+		if err != nil {
+			chainErr.Failures[name] = err
+			continue
+		}
+		candidates = append(candidates, result.readings(backend.Name())...)
 	}
 
-	// Otherwise use internal translation logic
-	return t.translateWithInternalLogic(text, scriptType)
+	if len(candidates) > 0 {
+		alternatives := candidates[1:]
+		sort.SliceStable(alternatives, func(i, j int) bool {
+			return alternatives[i].Score > alternatives[j].Score
+		})
+		return candidates[:min(len(candidates), wanted)], nil
+	}
+	if !supported {
+		return nil, &UnsupportedLanguagePairError{
+			ScriptType:     req.ScriptType,
+			TargetLanguage: req.TargetLanguage,
+			Backends:       chain,
+		}
+	}
+	return nil, chainErr
 }
 
 // isScriptSupported checks if the script type is supported
@@ -61,26 +479,94 @@ func (t *Translator) isScriptSupported(scriptType string) bool {
 	return false
 }
 
-// detectScriptType attempts to automatically detect the script type
-func (t *Translator) detectScriptType(text string) (string, error) {
-	// In a real implementation, this would analyze the text and patterns
-	// to determine the most likely script type
-	// For this simulation, we'll return a default script type
-	return t.config.SupportedScripts[0], nil
+// detectScriptType returns the most likely script of the text along with all ranked candidates
+func (t *Translator) detectScriptType(text string) (string, []models.ScriptCandidate, error) {
+	candidates := t.DetectScript(text)
+	if len(candidates) == 0 {
+		return "", nil, fmt.Errorf("no supported script recognized in text")
+	}
+	return candidates[0].Script, candidates, nil
+}
+
+// ExternalAPIClient returns the client used for the external API, or nil if no endpoint is configured
+func (t *Translator) ExternalAPIClient() *ExternalAPIClient {
+	return t.apiClient
 }
 
-// translateWithExternalAPI translates the text using an external API
-func (t *Translator) translateWithExternalAPI(text, scriptType string) (string, error) {
-	// In a real implementation, this would make an API call to an external
-	// translation service with the text and script type
-	// For this simulation, we'll return a placeholder translated text
-	return fmt.Sprintf("Translated from %s script: Lorem ipsum dolor sit amet", scriptType), nil
+// externalSupports reports whether the external API serves the target language
+func (t *Translator) externalSupports(scriptType, targetLanguage string) bool {
+	if t.apiClient == nil {
+		return false
+	}
+	if len(t.config.APITargetLanguages) == 0 {
+		return true
+	}
+	for _, language := range t.config.APITargetLanguages {
+		if strings.EqualFold(language, targetLanguage) {
+			return true
+		}
+	}
+	return false
 }
 
-// translateWithInternalLogic translates the text using internal logic
-func (t *Translator) translateWithInternalLogic(text, scriptType string) (string, error) {
-	// In a real implementation, this would apply specific translation rules
-	// based on the script type and language patterns
-	// For this simulation, we'll return a placeholder translated text
-	return fmt.Sprintf("Translated from %s script: Lorem ipsum dolor sit amet", scriptType), nil
+// translateWithExternalAPI translates the text using an external API, scored by the confidence the API reports
+func (t *Translator) translateWithExternalAPI(text, scriptType, targetLanguage string) (string, float64, error) {
+	if t.apiClient == nil {
+		return "", 0, fmt.Errorf("external translation API endpoint is not configured")
+	}
+
+	resp, err := t.apiClient.Translate(ExternalTranslateRequest{
+		Text:           text,
+		SourceScript:   scriptType,
+		TargetLanguage: targetLanguage,
+	})
+	if err != nil {
+		// An open circuit fails fast, so that the next backend of the chain, by default the
+		// internal engine, translates until the service recovers
+		return "", 0, fmt.Errorf("external translation failed: %w", err)
+	}
+
+	return resp.TranslatedText, resp.Confidence, nil
+}
+
+// internalSupports reports whether the internal engine can gloss the script into the target language.
+// Any script can be glossed into the default language, where unknown words are flagged;
+// other languages need a lexicon.
+func (t *Translator) internalSupports(scriptType, targetLanguage string) bool {
+	if strings.EqualFold(targetLanguage, t.config.DefaultTargetLanguage) {
+		return true
+	}
+	_, ok := t.lexicons[strings.ToLower(targetLanguage)][strings.ToLower(scriptType)]
+	return ok
+}
+
+// glossWithAlignment produces up to n gloss-style translations from the script's lexicon, best
+// first, each with its word alignment and score. Glossary terms take precedence over the lexicon
+// entries; words missing from both are kept and flagged as [?word].
+func (t *Translator) glossWithAlignment(text, scriptType, targetLanguage string, terms []models.GlossaryTerm, n int) []BackendResult {
+	lexicon := t.lexiconFor(scriptType, targetLanguage)
+	if len(terms) > 0 {
+		lexicon = glossaryLexicon(terms, scriptType, lexicon)
+	}
+
+	candidates := glossCandidates(text, lexicon, n)
+	results := make([]BackendResult, len(candidates))
+	for i, candidate := range candidates {
+		translated, alignment := alignGloss(text, candidate.tokens)
+		results[i] = BackendResult{
+			Text:      translated,
+			Backend:   BackendInternal,
+			Alignment: alignment,
+			Score:     candidate.score,
+		}
+	}
+	return results
+}
+
+// lexiconFor returns the lexicon of a script and target language, or an empty one if none was loaded
+func (t *Translator) lexiconFor(scriptType, targetLanguage string) *Lexicon {
+	if lexicon, ok := t.lexicons[strings.ToLower(targetLanguage)][strings.ToLower(scriptType)]; ok {
+		return lexicon
+	}
+	return newLexicon(strings.ToLower(scriptType))
 }
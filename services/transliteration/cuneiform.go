@@ -0,0 +1,350 @@
+package transliteration
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// cuneiformSign maps a cuneiform sign to its Assyriological readings.
+// The first reading is used when converting signs back to readings.
+type cuneiformSign struct {
+	codePoint rune
+	readings  []string
+}
+
+// cuneiformSigns is the sign list used for conversions, keyed by the Unicode sign names in the comments
+var cuneiformSigns = []cuneiformSign{
+	{0x12000, []string{"a"}},                 // A
+	{0x1202D, []string{"an", "d", "dingir"}}, // AN
+	{0x1223E, []string{"na"}},                // NA
+	{0x1224C, []string{"ni", "i3"}},          // NI
+	{0x122D7, []string{"šu"}},                // SHU
+	{0x122AD, []string{"ša"}},                // SHA
+	{0x12217, []string{"lugal"}},             // LUGAL
+	{0x1208A, []string{"e"}},                 // E
+	{0x1208D, []string{"e2"}},                // E2
+	{0x121A0, []string{"ki"}},                // KI
+	{0x122EB, []string{"ta"}},                // TA
+	{0x12220, []string{"ma"}},                // MA
+	{0x1222C, []string{"mu"}},                // MU
+	{0x1228F, []string{"ra"}},                // RA
+	{0x12292, []string{"ru"}},                // RU
+	{0x12291, []string{"ri"}},                // RI
+	{0x122FE, []string{"ti"}},                // TI
+	{0x12305, []string{"tu"}},                // TU
+	{0x12049, []string{"bi"}},                // BI
+	{0x12040, []string{"ba"}},                // BA
+	{0x1204D, []string{"bu"}},                // BU
+	{0x12055, []string{"da"}},                // DA
+	{0x12072, []string{"di"}},                // DI
+	{0x1207A, []string{"du"}},                // DU
+	{0x120B5, []string{"ga"}},                // GA
+	{0x12100, []string{"gi"}},                // GI
+	{0x12116, []string{"gu"}},                // GU
+	{0x12157, []string{"ka"}},                // KA
+	{0x121AA, []string{"ku"}},                // KU
+	{0x121B7, []string{"la"}},                // LA
+	{0x121F7, []string{"li"}},                // LI
+	{0x121FB, []string{"lu"}},                // LU
+	{0x12228, []string{"me"}},                // ME
+	{0x1222A, []string{"mi"}},                // MI
+	{0x12261, []string{"nu"}},                // NU
+	{0x1227A, []string{"pa"}},                // PA
+	{0x1227F, []string{"pi"}},                // PI
+	{0x12293, []string{"sa"}},                // SA
+	{0x122DB, []string{"si"}},                // SI
+	{0x122E2, []string{"su"}},                // SU
+	{0x1235D, []string{"za"}},                // ZA
+	{0x12363, []string{"zi"}},                // ZI
+	{0x1236A, []string{"zu"}},                // ZU
+	{0x1213F, []string{"i"}},                 // I
+	{0x1230B, []string{"u"}},                 // U
+	{0x12313, []string{"ud", "u4", "utu"}},   // UD
+	{0x12309, []string{"dumu", "tur"}},       // TUR
+	{0x120F2, []string{"gal"}},               // GAL
+	{0x121B3, []string{"kur"}},               // KUR
+	{0x12337, []string{"uru"}},               // URU
+	{0x121FD, []string{"lu2"}},               // LU2
+	{0x12097, []string{"en"}},                // EN
+	{0x121F8, []string{"lil2"}},              // LIL
+	{0x12246, []string{"nam"}},               // NAM
+	{0x1201D, []string{"ak"}},                // AK
+	{0x1200A, []string{"ab"}},                // AB
+	{0x1201C, []string{"ad"}},                // AD
+	{0x12020, []string{"al"}},                // AL
+	{0x12038, []string{"aš"}},                // ASH
+	{0x12141, []string{"ib"}},                // IB
+	{0x1214B, []string{"il"}},                // IL
+	{0x1214E, []string{"im"}},                // IM
+	{0x12154, []string{"in"}},                // IN
+	{0x12155, []string{"ir"}},                // IR
+	{0x12312, []string{"ub"}},                // UB
+	{0x1231D, []string{"um"}},                // UM
+	{0x12326, []string{"un", "kalam"}},       // UN
+	{0x12328, []string{"ur"}},                // UR
+	{0x1238F, []string{"nin"}},               // NIN
+	{0x12252, []string{"ninda"}},             // NINDA2
+	{0x12295, []string{"sag"}},               // SAG
+	{0x122B9, []string{"šar2"}},              // SHAR2
+	{0x12129, []string{"ḫa"}},                // HA
+	{0x12137, []string{"ḫu"}},                // HU
+	{0x1212D, []string{"ḫi"}},                // HI
+	{0x121A5, []string{"qi", "kin"}},         // KIN
+	{0x12308, []string{"tum"}},               // TUM
+	{0x12145, []string{"ig", "iq"}},          // IG
+	{0x12248, []string{"ne"}},                // NE
+	{0x12041, []string{"bad"}},               // BAD
+	{0x122FB, []string{"tar"}},               // TAR
+	{0x122D3, []string{"šir"}},               // SHIR
+	{0x12156, []string{"iš"}},                // ISH
+	{0x1235D, []string{"ṣa"}},                // ZA
+	{0x121AC, []string{"ku3"}},               // KU3
+	{0x1238C, []string{"mes"}},               // MESH
+	{0x1238C, []string{"meš"}},               // MESH
+	{0x12079, []string{"diš"}},               // DISH
+	{0x122AE, []string{"ša3"}},               // SHA3
+	{0x12155, []string{"er"}},                // IR
+	{0x12351, []string{"uš"}},                // USH
+	{0x12223, []string{"ma2"}},               // MA2
+	{0x12197, []string{"kal"}},               // KAL
+	{0x1206E, []string{"dam"}},               // DAM
+}
+
+// cuneiformDeterminatives are the readings of signs written as determinatives where they
+// differ from the first reading of the sign
+var cuneiformDeterminatives = map[rune]string{
+	0x1202D: "d",   // AN, before divine names
+	0x12079: "m",   // DISH, before masculine personal names
+	0x1238C: "meš", // MESH, after plurals
+}
+
+// cuneiformTransliterator converts between Unicode cuneiform and Assyriological sign readings.
+// Readings within a word are joined with "-" or "." and determinatives are written in braces, e.g. {d}utu.
+type cuneiformTransliterator struct {
+	byReading       map[string]rune
+	bySign          map[rune]string
+	byDeterminative map[string]rune
+}
+
+// newCuneiformTransliterator builds the reading tables
+func newCuneiformTransliterator() *cuneiformTransliterator {
+	t := &cuneiformTransliterator{
+		byReading:       make(map[string]rune),
+		bySign:          make(map[rune]string),
+		byDeterminative: make(map[string]rune),
+	}
+	for _, sign := range cuneiformSigns {
+		for _, reading := range sign.readings {
+			if _, exists := t.byReading[reading]; !exists {
+				t.byReading[reading] = sign.codePoint
+			}
+		}
+		if _, exists := t.bySign[sign.codePoint]; !exists {
+			t.bySign[sign.codePoint] = sign.readings[0]
+		}
+	}
+	for sign, reading := range cuneiformDeterminatives {
+		t.byDeterminative[reading] = sign
+	}
+	return t
+}
+
+// Script implements the Transliterator interface
+func (t *cuneiformTransliterator) Script() string { return "cuneiform" }
+
+// Convention implements the Transliterator interface
+func (t *cuneiformTransliterator) Convention() string { return "Assyriological sign readings" }
+
+// IsNative implements the Transliterator interface
+func (t *cuneiformTransliterator) IsNative(r rune) bool {
+	return r >= 0x12000 && r <= 0x1254F
+}
+
+// ToUnicode implements the Transliterator interface.
+// Determinatives keep their braces, and readings without a sign keep the separators
+// around them so that ToRoman reads them back whole.
+func (t *cuneiformTransliterator) ToUnicode(text string) Result {
+	var unknown unknownCollector
+
+	words := strings.Fields(text)
+	converted := make([]string, 0, len(words))
+	for _, word := range words {
+		var out strings.Builder
+		// lastUnknown is set after a reading without a sign, whose separator to the next reading is kept
+		lastUnknown := false
+		for _, unit := range splitReadings(word) {
+			if unit.determinative {
+				sign, ok := t.determinativeSign(unit.reading)
+				if !ok {
+					unknown.add(unit.reading)
+				}
+				out.WriteString("{" + sign + "}")
+				lastUnknown = false
+				continue
+			}
+
+			sign, ok := t.byReading[normalizeReading(unit.reading)]
+			if !ok {
+				unknown.add(unit.reading)
+			}
+			if unit.separator != 0 && (lastUnknown || !ok) {
+				out.WriteRune(unit.separator)
+			}
+			if ok {
+				out.WriteRune(sign)
+			} else {
+				out.WriteString(unit.reading)
+			}
+			lastUnknown = !ok
+		}
+		converted = append(converted, out.String())
+	}
+
+	return Result{Text: strings.Join(converted, " "), Unknown: unknown.units}
+}
+
+// determinativeSign returns the sign of a determinative, or the reading itself when it has none
+func (t *cuneiformTransliterator) determinativeSign(reading string) (string, bool) {
+	normalized := normalizeReading(reading)
+	if sign, ok := t.byDeterminative[normalized]; ok {
+		return string(sign), true
+	}
+	if sign, ok := t.byReading[normalized]; ok {
+		return string(sign), true
+	}
+	return reading, false
+}
+
+// ToRoman implements the Transliterator interface.
+// Signs are joined with "-", while separators, braces and readings left in the
+// Latin alphabet by ToUnicode are copied through unchanged.
+func (t *cuneiformTransliterator) ToRoman(text string) Result {
+	var unknown unknownCollector
+
+	words := strings.Fields(text)
+	converted := make([]string, 0, len(words))
+	for _, word := range words {
+		var out strings.Builder
+		// needsSeparator is set after a reading so the next reading is preceded by "-"
+		needsSeparator := false
+		determinative := false
+		runes := []rune(word)
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			if isReadingDelimiter(r) {
+				out.WriteRune(r)
+				determinative = r == '{'
+				needsSeparator = false
+				continue
+			}
+
+			if needsSeparator {
+				out.WriteRune('-')
+			}
+			needsSeparator = true
+
+			if !t.IsNative(r) {
+				// A reading without a sign is copied whole up to the next delimiter or sign
+				j := i
+				for j+1 < len(runes) && !t.IsNative(runes[j+1]) && !isReadingDelimiter(runes[j+1]) {
+					j++
+				}
+				out.WriteString(string(runes[i : j+1]))
+				i = j
+				continue
+			}
+			if reading, ok := cuneiformDeterminatives[r]; ok && determinative {
+				out.WriteString(reading)
+				continue
+			}
+			if reading, ok := t.bySign[r]; ok {
+				out.WriteString(reading)
+				continue
+			}
+			unknown.add(string(r))
+			out.WriteRune(r)
+		}
+		converted = append(converted, out.String())
+	}
+
+	return Result{Text: strings.Join(converted, " "), Unknown: unknown.units}
+}
+
+// readingUnit is a sign reading of a transliterated word
+type readingUnit struct {
+	reading string
+	// separator is the "-" or "." joining the reading to the reading before it, 0 for none
+	separator     rune
+	determinative bool
+}
+
+// isReadingDelimiter reports whether r separates readings or encloses a determinative
+func isReadingDelimiter(r rune) bool {
+	return r == '-' || r == '.' || r == '{' || r == '}'
+}
+
+// splitReadings splits a transliterated word into sign readings, keeping determinatives as their own unit
+func splitReadings(word string) []readingUnit {
+	var readings []readingUnit
+	var current strings.Builder
+	var separator rune
+
+	flush := func(determinative bool) {
+		if current.Len() > 0 {
+			readings = append(readings, readingUnit{reading: current.String(), separator: separator, determinative: determinative})
+			current.Reset()
+			separator = 0
+		}
+	}
+
+	for _, r := range word {
+		switch r {
+		case '-', '.':
+			flush(false)
+			if len(readings) > 0 && !readings[len(readings)-1].determinative {
+				separator = r
+			}
+		case '{':
+			flush(false)
+			separator = 0
+		case '}':
+			flush(true)
+			separator = 0
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush(false)
+
+	return readings
+}
+
+// normalizeReading folds ASCII and accented conventions to the sign list form:
+// sz → š, s, → ṣ, t, → ṭ, h → ḫ, subscript or accent indices → trailing digits
+func normalizeReading(reading string) string {
+	reading = strings.ToLower(reading)
+	reading = strings.NewReplacer("sz", "š", "s,", "ṣ", "t,", "ṭ", "h", "ḫ", "c", "š").Replace(reading)
+
+	var out strings.Builder
+	index := ""
+	for _, r := range norm.NFD.String(reading) {
+		switch {
+		case r >= '₀' && r <= '₉':
+			index += string('0' + (r - '₀'))
+		case r == '́':
+			// Acute accent marks index 2
+			index = "2"
+		case r == '̀':
+			// Grave accent marks index 3
+			index = "3"
+		case unicode.IsDigit(r):
+			index += string(r)
+		default:
+			out.WriteRune(r)
+		}
+	}
+
+	return norm.NFC.String(out.String()) + index
+}
@@ -0,0 +1,179 @@
+package transliteration
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// greekTransliterator converts between polytonic Greek and TLG Beta Code
+type greekTransliterator struct {
+	letters    map[rune]rune
+	marks      map[rune]rune
+	reverse    map[rune]rune
+	reverseMks map[rune]rune
+}
+
+// newGreekTransliterator builds the Beta Code tables
+func newGreekTransliterator() *greekTransliterator {
+	t := &greekTransliterator{
+		letters: map[rune]rune{
+			'a': 'α', 'b': 'β', 'g': 'γ', 'd': 'δ', 'e': 'ε', 'v': 'ϝ', 'z': 'ζ', 'h': 'η',
+			'q': 'θ', 'i': 'ι', 'k': 'κ', 'l': 'λ', 'm': 'μ', 'n': 'ν', 'c': 'ξ', 'o': 'ο',
+			'p': 'π', 'r': 'ρ', 's': 'σ', 't': 'τ', 'u': 'υ', 'f': 'φ', 'x': 'χ', 'y': 'ψ',
+			'w': 'ω',
+		},
+		marks: map[rune]rune{
+			')':  '̓', // smooth breathing
+			'(':  '̔', // rough breathing
+			'/':  '́', // acute
+			'\\': '̀', // grave
+			'=':  '͂', // circumflex
+			'|':  'ͅ', // iota subscript
+			'+':  '̈', // diaeresis
+		},
+		reverse:    make(map[rune]rune),
+		reverseMks: make(map[rune]rune),
+	}
+	for beta, greek := range t.letters {
+		t.reverse[greek] = beta
+	}
+	t.reverse['ς'] = 's'
+	for beta, mark := range t.marks {
+		t.reverseMks[mark] = beta
+	}
+	// The tonos of monotonic text is read as an acute
+	t.reverseMks['́'] = '/'
+	return t
+}
+
+// Script implements the Transliterator interface
+func (t *greekTransliterator) Script() string { return "greek" }
+
+// Convention implements the Transliterator interface
+func (t *greekTransliterator) Convention() string { return "Beta Code" }
+
+// IsNative implements the Transliterator interface
+func (t *greekTransliterator) IsNative(r rune) bool {
+	return (r >= 0x0370 && r <= 0x03FF) || (r >= 0x1F00 && r <= 0x1FFF)
+}
+
+// ToUnicode implements the Transliterator interface
+func (t *greekTransliterator) ToUnicode(text string) Result {
+	var out strings.Builder
+	var unknown unknownCollector
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == '*':
+			// Capital: diacritics come between the asterisk and the letter
+			j := i + 1
+			var marks []rune
+			for j < len(runes) {
+				if mark, ok := t.marks[runes[j]]; ok {
+					marks = append(marks, mark)
+					j++
+					continue
+				}
+				break
+			}
+			if j < len(runes) {
+				if letter, ok := t.letters[unicode.ToLower(runes[j])]; ok {
+					out.WriteRune(unicode.ToUpper(letter))
+					for _, mark := range marks {
+						out.WriteRune(mark)
+					}
+					i = j
+					continue
+				}
+			}
+			unknown.add("*")
+			out.WriteRune(r)
+		case t.letters[unicode.ToLower(r)] != 0:
+			letter := t.letters[unicode.ToLower(r)]
+			// Sigma is final at the end of a word
+			if letter == 'σ' && t.isWordEnd(runes, i+1) {
+				letter = 'ς'
+			}
+			out.WriteRune(letter)
+		case t.marks[r] != 0:
+			out.WriteRune(t.marks[r])
+		case r == ':':
+			// Beta Code colon is the ano teleia, written as a middle dot after normalization
+			out.WriteRune('·')
+		default:
+			if unicode.IsLetter(r) {
+				unknown.add(string(r))
+			}
+			out.WriteRune(r)
+		}
+	}
+
+	return Result{Text: norm.NFC.String(out.String()), Unknown: unknown.units}
+}
+
+// isWordEnd reports whether no further letter follows position i, skipping diacritics
+func (t *greekTransliterator) isWordEnd(runes []rune, i int) bool {
+	for ; i < len(runes); i++ {
+		if _, ok := t.marks[runes[i]]; ok {
+			continue
+		}
+		return !unicode.IsLetter(runes[i]) && runes[i] != '*'
+	}
+	return true
+}
+
+// ToRoman implements the Transliterator interface
+func (t *greekTransliterator) ToRoman(text string) Result {
+	var out strings.Builder
+	var unknown unknownCollector
+
+	runes := []rune(norm.NFD.String(text))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if !t.IsNative(r) {
+			if r == '·' {
+				out.WriteRune(':')
+			} else {
+				out.WriteRune(r)
+			}
+			continue
+		}
+
+		beta, ok := t.reverse[unicode.ToLower(r)]
+		if !ok {
+			unknown.add(string(r))
+			out.WriteRune(r)
+			continue
+		}
+
+		// Collect the combining marks of this letter
+		var marks []rune
+		for i+1 < len(runes) && unicode.Is(unicode.Mn, runes[i+1]) {
+			if mark, ok := t.reverseMks[runes[i+1]]; ok {
+				marks = append(marks, mark)
+			}
+			i++
+		}
+
+		if unicode.IsUpper(r) {
+			out.WriteRune('*')
+			for _, mark := range marks {
+				out.WriteRune(mark)
+			}
+			out.WriteRune(beta)
+			continue
+		}
+		out.WriteRune(beta)
+		for _, mark := range marks {
+			out.WriteRune(mark)
+		}
+	}
+
+	return Result{Text: out.String(), Unknown: unknown.units}
+}
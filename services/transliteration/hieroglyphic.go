@@ -0,0 +1,275 @@
+package transliteration
+
+import (
+	"regexp"
+	"strings"
+)
+
+// hieroglyph is a sign of the Gardiner list with its Unicode code point and
+// the Manuel de Codage phonetic value it is commonly written with
+type hieroglyph struct {
+	gardiner  string
+	codePoint rune
+	phonetic  string
+}
+
+// hieroglyphs is the sign list used for conversions
+var hieroglyphs = []hieroglyph{
+	{"A1", 0x13000, ""},
+	{"A2", 0x13001, ""},
+	{"A40", 0x1302D, ""},
+	{"B1", 0x13050, ""},
+	{"C1", 0x1305A, ""},
+	{"D1", 0x13076, "tp"},
+	{"D2", 0x13077, "Hr"},
+	{"D4", 0x13079, "ir"},
+	{"D21", 0x1308B, "r"},
+	{"D28", 0x13093, "kA"},
+	{"D36", 0x1309D, "a"},
+	{"D37", 0x1309E, "di"},
+	{"D46", 0x130A7, "d"},
+	{"D54", 0x130BB, "iw"},
+	{"D58", 0x130C0, "b"},
+	{"E1", 0x130D2, ""},
+	{"E23", 0x130ED, "rw"},
+	{"E34", 0x130F9, "wn"},
+	{"F4", 0x13102, "HAt"},
+	{"F13", 0x1310B, "wp"},
+	{"F31", 0x1311F, "ms"},
+	{"F32", 0x13121, "X"},
+	{"F34", 0x13123, "ib"},
+	{"F35", 0x13124, "nfr"},
+	{"G1", 0x1313F, "A"},
+	{"G5", 0x13143, "Hr"},
+	{"G7", 0x13146, ""},
+	{"G17", 0x13153, "m"},
+	{"G25", 0x1315C, "Ax"},
+	{"G36", 0x13168, "wr"},
+	{"G39", 0x1316D, "sA"},
+	{"G43", 0x13171, "w"},
+	{"H6", 0x13184, "mAat"},
+	{"I9", 0x13191, "f"},
+	{"I10", 0x13193, "D"},
+	{"K1", 0x1319B, "in"},
+	{"L1", 0x131A3, "xpr"},
+	{"M17", 0x131CB, "i"},
+	{"M23", 0x131D3, "sw"},
+	{"N1", 0x131EF, "pt"},
+	{"N5", 0x131F3, "ra"},
+	{"N14", 0x131FC, "sbA"},
+	{"N16", 0x131FE, "tA"},
+	{"N25", 0x13209, "xAst"},
+	{"N29", 0x1320E, "q"},
+	{"N35", 0x13216, "n"},
+	{"N37", 0x13219, "S"},
+	{"O1", 0x13250, "pr"},
+	{"O4", 0x13254, "h"},
+	{"O28", 0x1327A, "iwn"},
+	{"O34", 0x13283, "z"},
+	{"O49", 0x13296, "niwt"},
+	{"P8", 0x132A4, "mAa"},
+	{"Q1", 0x132A8, "st"},
+	{"Q3", 0x132AA, "p"},
+	{"R4", 0x132B5, "Htp"},
+	{"R8", 0x132B9, "nTr"},
+	{"R11", 0x132BD, "Dd"},
+	{"S29", 0x132F4, "s"},
+	{"S34", 0x132F9, "anx"},
+	{"S38", 0x132FE, "HqA"},
+	{"S40", 0x13300, "wAs"},
+	{"T22", 0x13322, "sn"},
+	{"U1", 0x13333, "mA"},
+	{"V13", 0x1337F, "T"},
+	{"V28", 0x1339B, "H"},
+	{"V30", 0x1339F, "nb"},
+	{"V31", 0x133A1, "k"},
+	{"W11", 0x133BC, "g"},
+	{"W24", 0x133CC, "nw"},
+	{"X1", 0x133CF, "t"},
+	{"X8", 0x133D9, "di"},
+	{"Y1", 0x133DB, "mDAt"},
+	{"Y5", 0x133E0, "mn"},
+	{"Z1", 0x133E4, ""},
+	{"Z2", 0x133E5, ""},
+	{"Z4", 0x133ED, "y"},
+	{"Aa1", 0x1340D, "x"},
+	{"Aa11", 0x13419, "mAa"},
+}
+
+// Quadrat joiners of the Egyptian Hieroglyph Format Controls block
+const (
+	verticalJoiner   = '\U00013430'
+	horizontalJoiner = '\U00013431'
+)
+
+// gardinerPattern matches a Gardiner sign code such as G17 or Aa1
+var gardinerPattern = regexp.MustCompile(`^(Aa|[A-Z])[0-9]{1,3}[A-Za-z]?$`)
+
+// hieroglyphicTransliterator converts between Unicode hieroglyphs and Manuel de Codage.
+// Signs are written as Gardiner codes or phonetic values separated by "-",
+// with ":" stacking signs vertically and "*" placing them side by side.
+type hieroglyphicTransliterator struct {
+	byCode     map[string]rune
+	byPhonetic map[string]rune
+	bySign     map[rune]string
+}
+
+// newHieroglyphicTransliterator builds the sign tables
+func newHieroglyphicTransliterator() *hieroglyphicTransliterator {
+	t := &hieroglyphicTransliterator{
+		byCode:     make(map[string]rune),
+		byPhonetic: make(map[string]rune),
+		bySign:     make(map[rune]string),
+	}
+	for _, sign := range hieroglyphs {
+		t.byCode[sign.gardiner] = sign.codePoint
+		t.bySign[sign.codePoint] = sign.gardiner
+		if sign.phonetic != "" {
+			if _, exists := t.byPhonetic[sign.phonetic]; !exists {
+				t.byPhonetic[sign.phonetic] = sign.codePoint
+			}
+		}
+	}
+	return t
+}
+
+// Script implements the Transliterator interface
+func (t *hieroglyphicTransliterator) Script() string { return "hieroglyphic" }
+
+// Convention implements the Transliterator interface
+func (t *hieroglyphicTransliterator) Convention() string { return "Manuel de Codage" }
+
+// IsNative implements the Transliterator interface
+func (t *hieroglyphicTransliterator) IsNative(r rune) bool {
+	return r >= 0x13000 && r <= 0x1343F
+}
+
+// ToUnicode implements the Transliterator interface
+func (t *hieroglyphicTransliterator) ToUnicode(text string) Result {
+	var unknown unknownCollector
+
+	words := strings.Fields(text)
+	converted := make([]string, 0, len(words))
+	for _, word := range words {
+		var out strings.Builder
+		var unit strings.Builder
+		// separated is set by a "-" after a unit; it is kept next to units without signs
+		// so that ToRoman reads them back whole
+		separated, lastUnknown := false, false
+
+		flush := func() {
+			if unit.Len() == 0 {
+				return
+			}
+			signs, ok := t.resolveUnit(unit.String())
+			if !ok {
+				unknown.add(unit.String())
+				signs = unit.String()
+			}
+			if separated && (lastUnknown || !ok) {
+				out.WriteRune('-')
+			}
+			out.WriteString(signs)
+			separated, lastUnknown = false, !ok
+			unit.Reset()
+		}
+
+		for _, r := range word {
+			switch r {
+			case '-':
+				flush()
+				separated = true
+			case ':':
+				flush()
+				out.WriteRune(verticalJoiner)
+				separated = false
+			case '*':
+				flush()
+				out.WriteRune(horizontalJoiner)
+				separated = false
+			default:
+				unit.WriteRune(r)
+			}
+		}
+		flush()
+
+		converted = append(converted, out.String())
+	}
+
+	return Result{Text: strings.Join(converted, " "), Unknown: unknown.units}
+}
+
+// resolveUnit converts a Gardiner code, a phonetic value, or a run of uniliterals to signs
+func (t *hieroglyphicTransliterator) resolveUnit(unit string) (string, bool) {
+	if gardinerPattern.MatchString(unit) {
+		sign, ok := t.byCode[unit]
+		return string(sign), ok
+	}
+	if sign, ok := t.byPhonetic[unit]; ok {
+		return string(sign), true
+	}
+
+	// Spell the unit out with uniliteral signs
+	var out strings.Builder
+	for _, r := range unit {
+		sign, ok := t.byPhonetic[string(r)]
+		if !ok {
+			return "", false
+		}
+		out.WriteRune(sign)
+	}
+	return out.String(), true
+}
+
+// ToRoman implements the Transliterator interface.
+// Units left in the Latin alphabet by ToUnicode are copied through whole.
+func (t *hieroglyphicTransliterator) ToRoman(text string) Result {
+	var unknown unknownCollector
+
+	words := strings.Fields(text)
+	converted := make([]string, 0, len(words))
+	for _, word := range words {
+		var out strings.Builder
+		// needsSeparator is set after a sign so the next sign is preceded by "-"
+		needsSeparator := false
+		runes := []rune(word)
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			switch {
+			case r == verticalJoiner:
+				out.WriteRune(':')
+				needsSeparator = false
+			case r == horizontalJoiner:
+				out.WriteRune('*')
+				needsSeparator = false
+			case r == '-':
+				out.WriteRune(r)
+				needsSeparator = false
+			default:
+				if needsSeparator {
+					out.WriteRune('-')
+				}
+				needsSeparator = true
+				if code, ok := t.bySign[r]; ok {
+					out.WriteString(code)
+					continue
+				}
+				if t.IsNative(r) {
+					unknown.add(string(r))
+					out.WriteRune(r)
+					continue
+				}
+				// A unit without a sign is copied whole up to the next separator or sign
+				j := i
+				for j+1 < len(runes) && !t.IsNative(runes[j+1]) && runes[j+1] != '-' {
+					j++
+				}
+				out.WriteString(string(runes[i : j+1]))
+				i = j
+			}
+		}
+		converted = append(converted, out.String())
+	}
+
+	return Result{Text: strings.Join(converted, " "), Unknown: unknown.units}
+}
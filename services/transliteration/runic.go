@@ -0,0 +1,125 @@
+package transliteration
+
+import (
+	"strings"
+	"unicode"
+)
+
+// runicTransliterator converts between Elder Futhark runes and the conventional
+// romanization, in which þ, ŋ, ï and ʀ stand for the runes without a Latin letter
+type runicTransliterator struct {
+	toRune  map[rune]rune
+	toRoman map[rune]string
+}
+
+// newRunicTransliterator builds the futhark tables
+func newRunicTransliterator() *runicTransliterator {
+	pairs := []struct {
+		roman string
+		rune  rune
+	}{
+		{"f", 'ᚠ'}, {"u", 'ᚢ'}, {"þ", 'ᚦ'}, {"a", 'ᚨ'}, {"r", 'ᚱ'}, {"k", 'ᚲ'},
+		{"g", 'ᚷ'}, {"w", 'ᚹ'}, {"h", 'ᚺ'}, {"n", 'ᚾ'}, {"i", 'ᛁ'}, {"j", 'ᛃ'},
+		{"ï", 'ᛇ'}, {"p", 'ᛈ'}, {"z", 'ᛉ'}, {"s", 'ᛊ'}, {"t", 'ᛏ'}, {"b", 'ᛒ'},
+		{"e", 'ᛖ'}, {"m", 'ᛗ'}, {"l", 'ᛚ'}, {"ŋ", 'ᛜ'}, {"d", 'ᛞ'}, {"o", 'ᛟ'},
+		{"ʀ", 'ᛦ'}, {"ą", 'ᚬ'}, {":", '᛬'}, {"·", '᛫'},
+	}
+
+	t := &runicTransliterator{
+		toRune:  make(map[rune]rune),
+		toRoman: make(map[rune]string),
+	}
+	for _, pair := range pairs {
+		t.toRune[[]rune(pair.roman)[0]] = pair.rune
+		t.toRoman[pair.rune] = pair.roman
+	}
+
+	// Younger Futhark and variant forms read back to the same letters
+	t.toRoman['ᛅ'] = "a"
+	t.toRoman['ᛆ'] = "a"
+	t.toRoman['ᚴ'] = "k"
+	t.toRoman['ᚼ'] = "h"
+	t.toRoman['ᚽ'] = "h"
+	t.toRoman['ᚿ'] = "n"
+	t.toRoman['ᛋ'] = "s"
+	t.toRoman['ᛌ'] = "s"
+	t.toRoman['ᛐ'] = "t"
+	t.toRoman['ᛓ'] = "b"
+	t.toRoman['ᛘ'] = "m"
+	t.toRoman['ᚯ'] = "ø"
+	t.toRoman['ᚭ'] = "o"
+
+	// Common substitutes for letters outside the futhark
+	t.toRune['c'] = 'ᚲ'
+	t.toRune['q'] = 'ᚲ'
+	t.toRune['v'] = 'ᚹ'
+	t.toRune['y'] = 'ᚢ'
+	t.toRune['ð'] = 'ᚦ'
+	return t
+}
+
+// Script implements the Transliterator interface
+func (t *runicTransliterator) Script() string { return "runic" }
+
+// Convention implements the Transliterator interface
+func (t *runicTransliterator) Convention() string { return "runic romanization" }
+
+// IsNative implements the Transliterator interface
+func (t *runicTransliterator) IsNative(r rune) bool {
+	return r >= 0x16A0 && r <= 0x16FF
+}
+
+// ToUnicode implements the Transliterator interface
+func (t *runicTransliterator) ToUnicode(text string) Result {
+	var out strings.Builder
+	var unknown unknownCollector
+
+	runes := []rune(strings.ToLower(text))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		// Digraphs written in plain ASCII
+		if i+1 < len(runes) {
+			switch string(runes[i : i+2]) {
+			case "th":
+				out.WriteRune('ᚦ')
+				i++
+				continue
+			case "ng":
+				out.WriteRune('ᛜ')
+				i++
+				continue
+			}
+		}
+
+		if rn, ok := t.toRune[r]; ok {
+			out.WriteRune(rn)
+			continue
+		}
+		if unicode.IsLetter(r) {
+			unknown.add(string(r))
+		}
+		out.WriteRune(r)
+	}
+
+	return Result{Text: out.String(), Unknown: unknown.units}
+}
+
+// ToRoman implements the Transliterator interface
+func (t *runicTransliterator) ToRoman(text string) Result {
+	var out strings.Builder
+	var unknown unknownCollector
+
+	for _, r := range text {
+		if roman, ok := t.toRoman[r]; ok {
+			out.WriteString(roman)
+			continue
+		}
+		if t.IsNative(r) {
+			unknown.add(string(r))
+		}
+		out.WriteRune(r)
+	}
+
+	return Result{Text: out.String(), Unknown: unknown.units}
+}
@@ -0,0 +1,145 @@
+// Package transliteration converts between the native Unicode form of the
+// supported ancient scripts and the Latin-alphabet romanizations scholars use:
+// Greek Beta Code, Assyriological sign readings, the Egyptological Manuel de
+// Codage and runic romanization.
+package transliteration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Directions of a conversion
+const (
+	DirectionToUnicode = "toUnicode"
+	DirectionToRoman   = "toRoman"
+)
+
+// Result contains the converted text and any input units that had no mapping.
+// Unmapped units are copied to the output unchanged.
+type Result struct {
+	Text    string   `json:"text"`
+	Unknown []string `json:"unknown,omitempty"`
+}
+
+// Transliterator converts text of one script in both directions
+type Transliterator interface {
+	// Script returns the script name as used in SupportedScripts
+	Script() string
+	// Convention names the romanization convention
+	Convention() string
+	// ToUnicode converts romanized text to native Unicode characters
+	ToUnicode(text string) Result
+	// ToRoman converts native Unicode characters to the romanization
+	ToRoman(text string) Result
+	// IsNative reports whether a rune belongs to the native script
+	IsNative(r rune) bool
+}
+
+var registry = map[string]Transliterator{}
+
+// register adds a transliterator to the registry
+func register(t Transliterator) {
+	registry[t.Script()] = t
+}
+
+func init() {
+	register(latinTransliterator{})
+	register(newGreekTransliterator())
+	register(newCuneiformTransliterator())
+	register(newHieroglyphicTransliterator())
+	register(newRunicTransliterator())
+}
+
+// Get returns the transliterator of a script
+func Get(script string) (Transliterator, error) {
+	t, ok := registry[strings.ToLower(script)]
+	if !ok {
+		return nil, fmt.Errorf("no transliteration available for script: %s", script)
+	}
+	return t, nil
+}
+
+// Scripts returns the names of all scripts with a transliterator
+func Scripts() []string {
+	scripts := make([]string, 0, len(registry))
+	for script := range registry {
+		scripts = append(scripts, script)
+	}
+	sort.Strings(scripts)
+	return scripts
+}
+
+// Convert converts text of a script in the given direction
+func Convert(script, text, direction string) (Result, error) {
+	t, err := Get(script)
+	if err != nil {
+		return Result{}, err
+	}
+
+	switch direction {
+	case DirectionToUnicode:
+		return t.ToUnicode(text), nil
+	case DirectionToRoman:
+		return t.ToRoman(text), nil
+	default:
+		return Result{}, fmt.Errorf("unsupported transliteration direction: %s", direction)
+	}
+}
+
+// IsRomanized reports whether text is written in the romanization rather than
+// the native script, judged by which of the two holds the majority of letters
+func IsRomanized(script, text string) bool {
+	t, err := Get(script)
+	if err != nil {
+		return false
+	}
+
+	var native, latin int
+	for _, r := range text {
+		switch {
+		case t.IsNative(r):
+			native++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	return latin > native
+}
+
+// unknownCollector gathers unmapped input units without duplicates
+type unknownCollector struct {
+	seen  map[string]bool
+	units []string
+}
+
+// add records an unmapped unit
+func (c *unknownCollector) add(unit string) {
+	if c.seen == nil {
+		c.seen = make(map[string]bool)
+	}
+	if !c.seen[unit] {
+		c.seen[unit] = true
+		c.units = append(c.units, unit)
+	}
+}
+
+// latinTransliterator is the identity conversion, Latin being written in the Latin alphabet
+type latinTransliterator struct{}
+
+// Script implements the Transliterator interface
+func (latinTransliterator) Script() string { return "latin" }
+
+// Convention implements the Transliterator interface
+func (latinTransliterator) Convention() string { return "Latin alphabet" }
+
+// ToUnicode implements the Transliterator interface
+func (latinTransliterator) ToUnicode(text string) Result { return Result{Text: text} }
+
+// ToRoman implements the Transliterator interface
+func (latinTransliterator) ToRoman(text string) Result { return Result{Text: text} }
+
+// IsNative implements the Transliterator interface
+func (latinTransliterator) IsNative(r rune) bool { return unicode.Is(unicode.Latin, r) }
@@ -0,0 +1,127 @@
+package transliteration
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		roman       string
+		native      string
+		wantUnknown []string
+	}{
+		{
+			name:   "Assyriological readings",
+			script: "cuneiform",
+			roman:  "a-na lugal kur-kur-ra",
+			native: "𒀀𒈾 𒈗 𒆳𒆳𒊏",
+		},
+		{
+			name:        "readings without a sign stay whole",
+			script:      "cuneiform",
+			roman:       "a-na be-li-ia qi2-bi-ma",
+			native:      "𒀀𒈾 be-𒇷-ia qi2-𒁉𒈠",
+			wantUnknown: []string{"be", "ia", "qi2"},
+		},
+		{
+			name:   "determinatives keep their braces",
+			script: "cuneiform",
+			roman:  "{d}en-lil2 lugal uru{ki}",
+			native: "{𒀭}𒂗𒇸 𒈗 𒌷{𒆠}",
+		},
+		{
+			name:        "determinative without a sign",
+			script:      "cuneiform",
+			roman:       "{gesz}tukul",
+			native:      "{gesz}tukul",
+			wantUnknown: []string{"gesz", "tukul"},
+		},
+		{
+			name:   "Manuel de Codage",
+			script: "hieroglyphic",
+			roman:  "G17-N35-R8 M17:N35*Q3",
+			native: "𓅓𓈖𓊹 𓇋\U00013430𓈖\U00013431𓊪",
+		},
+		{
+			name:        "unit without a sign stays whole",
+			script:      "hieroglyphic",
+			roman:       "G17-Q9-N35",
+			native:      "𓅓-Q9-𓈖",
+			wantUnknown: []string{"Q9"},
+		},
+		{
+			name:   "Beta Code",
+			script: "greek",
+			roman:  "*)/andra moi e)/nnepe, mou=sa",
+			native: "Ἄνδρα μοι ἔννεπε, μοῦσα",
+		},
+		{
+			name:   "Beta Code with final sigma",
+			script: "greek",
+			roman:  "lo/gos",
+			native: "λόγος",
+		},
+		{
+			name:   "runic romanization",
+			script: "runic",
+			roman:  "ekhlewagastiz holtijaz horna tawido",
+			native: "ᛖᚲᚺᛚᛖᚹᚨᚷᚨᛊᛏᛁᛉ ᚺᛟᛚᛏᛁᛃᚨᛉ ᚺᛟᚱᚾᚨ ᛏᚨᚹᛁᛞᛟ",
+		},
+		{
+			name:   "runic letters without a Latin equivalent",
+			script: "runic",
+			roman:  "fuþark:",
+			native: "ᚠᚢᚦᚨᚱᚲ᛬",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			translit, err := Get(test.script)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			native := translit.ToUnicode(test.roman)
+			if native.Text != test.native {
+				t.Errorf("ToUnicode() = %q, want %q", native.Text, test.native)
+			}
+			if !reflect.DeepEqual(native.Unknown, test.wantUnknown) {
+				t.Errorf("ToUnicode() unknown = %q, want %q", native.Unknown, test.wantUnknown)
+			}
+
+			roman := translit.ToRoman(native.Text)
+			if roman.Text != test.roman {
+				t.Errorf("ToRoman() = %q, want %q", roman.Text, test.roman)
+			}
+			if len(roman.Unknown) != 0 {
+				t.Errorf("ToRoman() unknown = %q, want none", roman.Unknown)
+			}
+		})
+	}
+}
+
+func TestIsRomanized(t *testing.T) {
+	tests := []struct {
+		script string
+		text   string
+		want   bool
+	}{
+		{script: "cuneiform", text: "a-na be-li-ia", want: true},
+		{script: "cuneiform", text: "𒀀𒈾 𒈗 be-𒇷", want: false},
+		{script: "greek", text: "mh=nin a)/eide", want: true},
+		{script: "greek", text: "μῆνιν ἄειδε", want: false},
+		{script: "hieroglyphic", text: "𓅓𓈖𓊹", want: false},
+		{script: "runic", text: "ᚠᚢᚦᚨᚱᚲ", want: false},
+		{script: "runic", text: "futhark", want: true},
+	}
+
+	for _, test := range tests {
+		if got := IsRomanized(test.script, test.text); got != test.want {
+			t.Errorf("IsRomanized(%q, %q) = %v, want %v", test.script, test.text, got, test.want)
+		}
+	}
+}
@@ -47,6 +47,19 @@ document.addEventListener('DOMContentLoaded', function() {
             document.getElementById('summary').textContent = data.summary;
             document.getElementById('processedAt').textContent = data.processedAt;
             
+            // Overlay the lines and glyphs found in the manuscript on the uploaded image
+            displayImageScripts(data.imageScripts);
+            displayLayout(document.getElementById('manuscriptFile').files[0], data.layout);
+            
+            // Link source and translated words when the alignment is available
+            displayAlignment(data.sourceText, data.translatedText, data.alignment);
+            
+            // Handle alternative readings display
+            displayCandidates(data.candidates);
+            
+            // Handle glossary violations display
+            displayGlossaryViolations(data.glossaryViolations);
+            
             // Handle metadata display
             displayMetadata(data.metadata);
             
@@ -122,6 +135,196 @@ document.addEventListener('DOMContentLoaded', function() {
         feather.replace();
     }
     
+    // Function to list the scripts the look of the writing suggested, likeliest first
+    function displayImageScripts(candidates) {
+        const element = document.getElementById('imageScripts');
+        if (!candidates || candidates.length === 0) {
+            element.textContent = '';
+            return;
+        }
+        element.textContent = 'Detected from the image: ' + candidates
+            .slice(0, 3)
+            .map(candidate => candidate.script + ' ' + Math.round(candidate.confidence * 100) + '%')
+            .join(', ');
+    }
+    
+    // Function to draw the boxes of the lines and glyphs over the uploaded image
+    // The boxes are in the image turned upright, so a turned image is drawn turned the same way
+    function displayLayout(file, layout) {
+        const section = document.getElementById('layoutSection');
+        const view = document.getElementById('layoutView');
+        view.innerHTML = '';
+        view.classList.remove('rotated');
+        
+        if (!file || !layout || !layout.lines || layout.lines.length === 0) {
+            section.classList.add('d-none');
+            return;
+        }
+        
+        const svgNS = 'http://www.w3.org/2000/svg';
+        const overlay = document.createElementNS(svgNS, 'svg');
+        overlay.setAttribute('viewBox', '0 0 ' + layout.width + ' ' + layout.height);
+        
+        const orientation = document.getElementById('layoutOrientation');
+        orientation.textContent = layout.rotation
+            ? 'Turned ' + layout.rotation.toFixed(1) + '\u00b0 clockwise (orientation ' + layout.orientation +
+                '\u00b0, skew ' + layout.skew.toFixed(1) + '\u00b0)'
+            : '';
+        
+        const imageURL = URL.createObjectURL(file);
+        if (layout.rotation) {
+            view.classList.add('rotated');
+            const image = document.createElementNS(svgNS, 'image');
+            image.setAttribute('href', imageURL);
+            const original = new Image();
+            original.onload = () => {
+                const w = original.naturalWidth;
+                const h = original.naturalHeight;
+                image.setAttribute('width', w);
+                image.setAttribute('height', h);
+                image.setAttribute('transform', 'translate(' + layout.width / 2 + ' ' + layout.height / 2 + ') ' +
+                    'rotate(' + layout.rotation + ') translate(' + (-w / 2) + ' ' + (-h / 2) + ')');
+            };
+            original.src = imageURL;
+            overlay.appendChild(image);
+        } else {
+            const image = document.createElement('img');
+            image.src = imageURL;
+            image.alt = 'Manuscript';
+            view.appendChild(image);
+            overlay.setAttribute('preserveAspectRatio', 'none');
+        }
+        
+        const addBox = (box, className, title) => {
+            const rect = document.createElementNS(svgNS, 'rect');
+            rect.setAttribute('x', box.x);
+            rect.setAttribute('y', box.y);
+            rect.setAttribute('width', box.width);
+            rect.setAttribute('height', box.height);
+            rect.setAttribute('class', className);
+            const tooltip = document.createElementNS(svgNS, 'title');
+            tooltip.textContent = title;
+            rect.appendChild(tooltip);
+            overlay.appendChild(rect);
+        };
+        
+        layout.lines.forEach((line, index) => {
+            addBox(line.box, 'layout-line', 'Line ' + (index + 1) + ' (' + line.direction + ')');
+            line.glyphs.forEach(glyph => {
+                const confidence = Math.round(glyph.confidence * 100) + '%';
+                const className = glyph.uncertain ? 'layout-glyph uncertain' : 'layout-glyph';
+                const title = glyph.text + ' (' + confidence + (glyph.reviewId ? ', queued for review' : '') + ')';
+                addBox(glyph.box, className, title);
+            });
+        });
+        
+        view.appendChild(overlay);
+        section.classList.remove('d-none');
+    }
+    
+    // Function to display the source text and highlight aligned spans on hover
+    function displayAlignment(sourceText, translatedText, alignment) {
+        const sourceSection = document.getElementById('sourceTextSection');
+        const sourceElement = document.getElementById('sourceText');
+        const translatedElement = document.getElementById('translatedText');
+        
+        if (!sourceText || !alignment || alignment.length === 0) {
+            sourceSection.classList.add('d-none');
+            return;
+        }
+        
+        renderSpans(sourceElement, sourceText, alignment.map(pair => pair.source), alignment);
+        renderSpans(translatedElement, translatedText, alignment.map(pair => pair.target), alignment);
+        sourceSection.classList.remove('d-none');
+    }
+    
+    // Function to split text into aligned spans; offsets count code points, as Array.from does
+    function renderSpans(element, text, spans, alignment) {
+        const chars = Array.from(text);
+        element.innerHTML = '';
+        
+        let pos = 0;
+        spans.forEach((span, index) => {
+            if (span.start > pos) {
+                element.appendChild(document.createTextNode(chars.slice(pos, span.start).join('')));
+            }
+            
+            const spanElement = document.createElement('span');
+            spanElement.className = 'aligned-span';
+            if (alignment[index].confidence === 0) {
+                spanElement.classList.add('unknown');
+            }
+            spanElement.dataset.pair = index;
+            spanElement.title = 'Confidence: ' + (alignment[index].confidence * 100).toFixed(0) + '%';
+            if (alignment[index].status) {
+                // Words read from a damaged text carry the status of their Leiden markup
+                spanElement.classList.add(alignment[index].status);
+                spanElement.title += ' (' + alignment[index].status + ')';
+            }
+            spanElement.textContent = chars.slice(span.start, span.end).join('');
+            spanElement.addEventListener('mouseenter', () => highlightPair(index, true));
+            spanElement.addEventListener('mouseleave', () => highlightPair(index, false));
+            element.appendChild(spanElement);
+            
+            pos = span.end;
+        });
+        
+        if (pos < chars.length) {
+            element.appendChild(document.createTextNode(chars.slice(pos).join('')));
+        }
+    }
+    
+    // Function to toggle the highlight of both sides of an alignment pair
+    function highlightPair(index, on) {
+        document.querySelectorAll(`.aligned-span[data-pair="${index}"]`).forEach(element => {
+            element.classList.toggle('highlight', on);
+        });
+    }
+    
+    // Function to display the alternative translations below the top one, with their scores
+    function displayCandidates(candidates) {
+        const candidatesElement = document.getElementById('translationCandidates');
+        const candidatesListElement = document.getElementById('translationCandidatesList');
+        
+        if (!candidates || candidates.length < 2) {
+            candidatesElement.classList.add('d-none');
+            return;
+        }
+        
+        candidatesListElement.innerHTML = '';
+        candidates.slice(1).forEach(candidate => {
+            const li = document.createElement('li');
+            li.textContent = candidate.text;
+            const details = document.createElement('span');
+            details.className = 'text-muted small ms-2';
+            details.textContent = `${(candidate.score * 100).toFixed(0)}% · ${candidate.backend}`;
+            li.appendChild(details);
+            candidatesListElement.appendChild(li);
+        });
+        candidatesElement.classList.remove('d-none');
+    }
+    
+    // Function to display the glossary terms the translation did not render as required
+    function displayGlossaryViolations(violations) {
+        const violationsElement = document.getElementById('glossaryViolations');
+        const violationsListElement = document.getElementById('glossaryViolationsList');
+        
+        if (!violations || violations.length === 0) {
+            violationsElement.classList.add('d-none');
+            return;
+        }
+        
+        violationsListElement.innerHTML = '';
+        violations.forEach(violation => {
+            const li = document.createElement('li');
+            const found = violation.found ? ` instead of "${violation.found}"` : ' missing';
+            li.textContent = `${violation.source}: expected "${violation.expected}"${found}` +
+                (violation.fixed ? ' (fixed)' : '');
+            violationsListElement.appendChild(li);
+        });
+        violationsElement.classList.remove('d-none');
+    }
+    
     // Function to display metadata
     function displayMetadata(metadata) {
         const noMetadataElement = document.getElementById('noMetadata');
@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+)
+
+// BinarizationMethod is the way a grayscale image is thresholded into ink and background
+type BinarizationMethod string
+
+// Binarization methods of the image processing pipeline
+const (
+	// NoBinarization leaves the image in shades of gray
+	NoBinarization BinarizationMethod = "none"
+	// OtsuBinarization thresholds the whole image at the gray level that best separates two classes
+	OtsuBinarization BinarizationMethod = "otsu"
+	// SauvolaBinarization thresholds each pixel by the mean and contrast of its window, which
+	// suits stained or unevenly lit surfaces such as papyrus
+	SauvolaBinarization BinarizationMethod = "sauvola"
+	// NiblackBinarization thresholds each pixel by the mean and standard deviation of its window
+	NiblackBinarization BinarizationMethod = "niblack"
+)
+
+// Defaults of the local thresholding methods
+const (
+	// DefaultBinarizationWindow is the side in pixels of the window local thresholds are taken over
+	DefaultBinarizationWindow = 25
+	// DefaultSauvolaK weighs the contrast of a window in Sauvola's threshold
+	DefaultSauvolaK = 0.34
+	// DefaultNiblackK weighs the standard deviation of a window in Niblack's threshold
+	DefaultNiblackK = -0.2
+	// sauvolaRange is the dynamic range of the standard deviation of 8-bit gray levels
+	sauvolaRange = 128
+)
+
+// ParseBinarizationMethod validates the name of a binarization method; an empty name means none
+func ParseBinarizationMethod(name string) (BinarizationMethod, error) {
+	if name == "" {
+		return NoBinarization, nil
+	}
+	switch method := BinarizationMethod(name); method {
+	case NoBinarization, OtsuBinarization, SauvolaBinarization, NiblackBinarization:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown binarization method %q: must be none, otsu, sauvola or niblack", name)
+}
+
+// NewBinarizationProcessor creates the processor of a binarization method, nil for none.
+// A window below 3 pixels and a k of 0 take the method's defaults.
+func NewBinarizationProcessor(method BinarizationMethod, window int, k float64, concurrency int, useParallel bool) ImageProcessingAlgorithm {
+	switch method {
+	case OtsuBinarization:
+		return NewOtsuProcessor(concurrency, useParallel)
+	case SauvolaBinarization:
+		return NewSauvolaProcessor(window, k, concurrency, useParallel)
+	case NiblackBinarization:
+		return NewNiblackProcessor(window, k, concurrency, useParallel)
+	}
+	return nil
+}
+
+// OtsuProcessor binarizes an image with a global threshold chosen by Otsu's method. The
+// smaller of the two classes becomes black ink and the other white background, so that
+// light strokes on a dark surface, such as a stone rubbing, are not inverted.
+type OtsuProcessor struct {
+	ImageProcessor
+}
+
+func NewOtsuProcessor(concurrency int, useParallel bool) *OtsuProcessor {
+	return &OtsuProcessor{
+		ImageProcessor: NewImageProcessor("Otsu Binarization", concurrency, useParallel),
+	}
+}
+
+func (p *OtsuProcessor) Process(img image.Image) image.Image {
+	bounds := img.Bounds()
+	gray := p.grayLevels(img)
+
+	var histogram [256]int
+	for _, value := range gray.Pix {
+		histogram[value]++
+	}
+	threshold, inkIsDark := otsuPolarity(histogram, len(gray.Pix))
+
+	result := image.NewGray(bounds)
+	p.forEachRows(bounds.Dy(), func(startY, endY int) {
+		for i := startY * gray.Stride; i < endY*gray.Stride; i++ {
+			result.Pix[i] = binaryLevel((int(gray.Pix[i]) <= threshold) == inkIsDark)
+		}
+	})
+	return result
+}
+
+// SauvolaProcessor binarizes an image with Sauvola's local threshold
+// T = m * (1 + k * (s/R - 1)), where m and s are the mean and standard deviation of the
+// window around a pixel and R is the dynamic range of the standard deviation. Stains that
+// darken a region lower its threshold with its mean, so they do not turn into ink.
+type SauvolaProcessor struct {
+	ImageProcessor
+	window int     // Side of the window in pixels (odd)
+	k      float64 // Weight of the window contrast
+}
+
+func NewSauvolaProcessor(window int, k float64, concurrency int, useParallel bool) *SauvolaProcessor {
+	if k == 0 {
+		k = DefaultSauvolaK
+	}
+	return &SauvolaProcessor{
+		ImageProcessor: NewImageProcessor("Sauvola Binarization", concurrency, useParallel),
+		window:         binarizationWindow(window),
+		k:              k,
+	}
+}
+
+func (p *SauvolaProcessor) Process(img image.Image) image.Image {
+	return p.threshold(img, p.window, func(mean, deviation float64) float64 {
+		return mean * (1 + p.k*(deviation/sauvolaRange-1))
+	})
+}
+
+// NiblackProcessor binarizes an image with Niblack's local threshold T = m + k * s, where m
+// and s are the mean and standard deviation of the window around a pixel. It finds faint
+// strokes but also turns the texture of blank background into noise.
+type NiblackProcessor struct {
+	ImageProcessor
+	window int     // Side of the window in pixels (odd)
+	k      float64 // Weight of the window standard deviation, usually negative for dark ink
+}
+
+func NewNiblackProcessor(window int, k float64, concurrency int, useParallel bool) *NiblackProcessor {
+	if k == 0 {
+		k = DefaultNiblackK
+	}
+	return &NiblackProcessor{
+		ImageProcessor: NewImageProcessor("Niblack Binarization", concurrency, useParallel),
+		window:         binarizationWindow(window),
+		k:              k,
+	}
+}
+
+func (p *NiblackProcessor) Process(img image.Image) image.Image {
+	return p.threshold(img, p.window, func(mean, deviation float64) float64 {
+		return mean + p.k*deviation
+	})
+}
+
+// binarizationWindow returns an odd window side of at least 3 pixels, the default for smaller ones
+func binarizationWindow(window int) int {
+	if window < 3 {
+		window = DefaultBinarizationWindow
+	}
+	if window%2 == 0 {
+		window++
+	}
+	return window
+}
+
+// binaryLevel returns black for ink and white for background
+func binaryLevel(ink bool) uint8 {
+	if ink {
+		return 0
+	}
+	return 255
+}
+
+// forEachRows calls process on chunks of the rows of an image of the given height, one per
+// goroutine when parallel processing is enabled, and returns once all chunks are done
+func (ip *ImageProcessor) forEachRows(height int, process func(startY, endY int)) {
+	if !ip.useParallel || ip.concurrency <= 1 {
+		process(0, height)
+		return
+	}
+
+	// Calculate the size of each chunk to process
+	chunkSize := height / ip.concurrency
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < ip.concurrency; i++ {
+		startY := i * chunkSize
+		endY := (i + 1) * chunkSize
+		if i == ip.concurrency-1 {
+			endY = height // Ensure we process all rows
+		}
+		if startY >= height {
+			break
+		}
+		wg.Add(1)
+		go func(startY, endY int) {
+			defer wg.Done()
+			process(startY, minInt(endY, height))
+		}(startY, endY)
+	}
+	wg.Wait()
+}
+
+// grayLevels converts an image to 8-bit luminance with its origin at 0, 0
+func (ip *ImageProcessor) grayLevels(img image.Image) *image.Gray {
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	ip.forEachRows(bounds.Dy(), func(startY, endY int) {
+		for y := startY; y < endY; y++ {
+			for x := 0; x < bounds.Dx(); x++ {
+				gray.Pix[y*gray.Stride+x] = color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y
+			}
+		}
+	})
+	return gray
+}
+
+// threshold binarizes an image by comparing each pixel with the threshold computed from the
+// mean and standard deviation of the window around it. The window statistics come from
+// integral images of the gray levels and their squares, so their cost does not grow with
+// the window; windows are clipped at the borders of the image.
+func (ip *ImageProcessor) threshold(img image.Image, window int, threshold func(mean, deviation float64) float64) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	gray := ip.grayLevels(img)
+
+	// sums[y*(width+1)+x] holds the sum of the pixels above and left of x, y
+	stride := width + 1
+	sums := make([]float64, stride*(height+1))
+	squares := make([]float64, stride*(height+1))
+	for y := 0; y < height; y++ {
+		var rowSum, rowSquares float64
+		for x := 0; x < width; x++ {
+			value := float64(gray.Pix[y*gray.Stride+x])
+			rowSum += value
+			rowSquares += value * value
+			sums[(y+1)*stride+x+1] = sums[y*stride+x+1] + rowSum
+			squares[(y+1)*stride+x+1] = squares[y*stride+x+1] + rowSquares
+		}
+	}
+
+	radius := window / 2
+	result := image.NewGray(bounds)
+	ip.forEachRows(height, func(startY, endY int) {
+		for y := startY; y < endY; y++ {
+			top, bottom := maxInt(y-radius, 0), minInt(y+radius+1, height)
+			for x := 0; x < width; x++ {
+				left, right := maxInt(x-radius, 0), minInt(x+radius+1, width)
+				count := float64((bottom - top) * (right - left))
+				sum := sums[bottom*stride+right] - sums[top*stride+right] - sums[bottom*stride+left] + sums[top*stride+left]
+				sumSquares := squares[bottom*stride+right] - squares[top*stride+right] - squares[bottom*stride+left] + squares[top*stride+left]
+
+				mean := sum / count
+				deviation := math.Sqrt(math.Max(sumSquares/count-mean*mean, 0))
+				value := float64(gray.Pix[y*gray.Stride+x])
+				result.Pix[y*result.Stride+x] = binaryLevel(value <= threshold(mean, deviation))
+			}
+		}
+	})
+	return result
+}
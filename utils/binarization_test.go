@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// strokeImage draws a vertical stroke of the ink shade across a surface of another shade
+func strokeImage(ink, surface uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 30, 30))
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 30; x++ {
+			shade := surface
+			if x >= 12 && x < 16 {
+				shade = ink
+			}
+			img.SetGray(x, y, color.Gray{Y: shade})
+		}
+	}
+	return img
+}
+
+func TestOtsuProcessorPolarity(t *testing.T) {
+	tests := []struct {
+		name string
+		img  *image.Gray
+	}{
+		{name: "dark ink on a light surface", img: strokeImage(40, 220)},
+		{name: "light strokes on a dark stone rubbing", img: strokeImage(220, 40)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			binary := NewOtsuProcessor(1, false).Process(test.img)
+			for _, point := range []image.Point{{X: 13, Y: 15}, {X: 2, Y: 2}} {
+				stroke := point.X >= 12 && point.X < 16
+				want := binaryLevel(stroke)
+				if got := color.GrayModel.Convert(binary.At(point.X, point.Y)).(color.Gray).Y; got != want {
+					t.Errorf("pixel %v = %d, want %d", point, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBinarizeWith(t *testing.T) {
+	methods := []BinarizationMethod{NoBinarization, OtsuBinarization, SauvolaBinarization, NiblackBinarization}
+	images := []struct {
+		name string
+		img  *image.Gray
+	}{
+		{name: "dark ink", img: strokeImage(40, 220)},
+		{name: "light strokes", img: strokeImage(220, 40)},
+	}
+
+	for _, method := range methods {
+		for _, test := range images {
+			t.Run(string(method)+" "+test.name, func(t *testing.T) {
+				bitmap := BinarizeWith(test.img, NewBinarizationProcessor(method, 0, 0, 1, false))
+				if !bitmap.At(13, 15) {
+					t.Errorf("stroke pixel is not ink")
+				}
+				if bitmap.At(2, 15) {
+					t.Errorf("surface pixel is ink")
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+)
+
+// Bitmap is a binarized image in which true marks ink
+type Bitmap struct {
+	Width  int
+	Height int
+	Ink    []bool
+}
+
+// NewBitmap creates a blank bitmap
+func NewBitmap(width, height int) *Bitmap {
+	return &Bitmap{Width: width, Height: height, Ink: make([]bool, width*height)}
+}
+
+// At reports whether the pixel at x, y is ink; pixels outside the bitmap are background
+func (b *Bitmap) At(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.Width || y >= b.Height {
+		return false
+	}
+	return b.Ink[y*b.Width+x]
+}
+
+// Set marks the pixel at x, y as ink or background
+func (b *Bitmap) Set(x, y int, ink bool) {
+	b.Ink[y*b.Width+x] = ink
+}
+
+// Binarize separates ink from background with Otsu's threshold on the luminance.
+// Ink is taken to be the smaller class, so that light strokes on a dark surface,
+// such as an edge-enhanced image, are found as well as dark ink on a light one.
+func Binarize(img image.Image) *Bitmap {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	gray := make([]uint8, width*height)
+	var histogram [256]int
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y
+			gray[y*width+x] = value
+			histogram[value]++
+		}
+	}
+
+	threshold, inkIsDark := otsuPolarity(histogram, width*height)
+	bitmap := NewBitmap(width, height)
+	for i, value := range gray {
+		if inkIsDark {
+			bitmap.Ink[i] = int(value) <= threshold
+		} else {
+			bitmap.Ink[i] = int(value) > threshold
+		}
+	}
+	return bitmap
+}
+
+// BinarizeWith separates ink from background with a binarization processor, taking its
+// black pixels as ink; a nil processor binarizes with Binarize. As with Binarize, ink is taken
+// to be the smaller class, so that light strokes on a dark surface are found as well.
+func BinarizeWith(img image.Image, binarizer ImageProcessingAlgorithm) *Bitmap {
+	if binarizer == nil {
+		return Binarize(img)
+	}
+
+	binary := binarizer.Process(img)
+	bounds := binary.Bounds()
+	bitmap := NewBitmap(bounds.Dx(), bounds.Dy())
+	black := 0
+	for y := 0; y < bitmap.Height; y++ {
+		for x := 0; x < bitmap.Width; x++ {
+			if color.GrayModel.Convert(binary.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y < 128 {
+				bitmap.Set(x, y, true)
+				black++
+			}
+		}
+	}
+	if black*2 > len(bitmap.Ink) {
+		for i := range bitmap.Ink {
+			bitmap.Ink[i] = !bitmap.Ink[i]
+		}
+	}
+	return bitmap
+}
+
+// otsuPolarity returns Otsu's threshold of a histogram and whether the ink lies at or below
+// it, taking the ink to be the smaller of the two classes
+func otsuPolarity(histogram [256]int, total int) (int, bool) {
+	threshold := otsuThreshold(histogram, total)
+	dark := 0
+	for value := 0; value <= threshold; value++ {
+		dark += histogram[value]
+	}
+	return threshold, dark*2 <= total
+}
+
+// otsuThreshold returns the gray level that best separates the histogram into two classes,
+// maximizing the variance between them; levels up to and including it form the dark class
+func otsuThreshold(histogram [256]int, total int) int {
+	if total == 0 {
+		return 127
+	}
+
+	var sum float64
+	for value, count := range histogram {
+		sum += float64(value * count)
+	}
+
+	var darkSum, bestVariance float64
+	darkCount, threshold := 0, 127
+	for value, count := range histogram {
+		darkCount += count
+		if darkCount == 0 {
+			continue
+		}
+		lightCount := total - darkCount
+		if lightCount == 0 {
+			break
+		}
+		darkSum += float64(value * count)
+
+		darkMean := darkSum / float64(darkCount)
+		lightMean := (sum - darkSum) / float64(lightCount)
+		variance := float64(darkCount) * float64(lightCount) * (darkMean - lightMean) * (darkMean - lightMean)
+		if variance > bestVariance {
+			bestVariance = variance
+			threshold = value
+		}
+	}
+	return threshold
+}
+
+// TurnClockwise returns the bitmap turned a quarter turn clockwise
+func (b *Bitmap) TurnClockwise() *Bitmap {
+	turned := NewBitmap(b.Height, b.Width)
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			if b.At(x, y) {
+				turned.Set(b.Height-1-y, x, true)
+			}
+		}
+	}
+	return turned
+}
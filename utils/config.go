@@ -17,17 +17,64 @@ type Config struct {
                 Port int `yaml:"port"`
         } `yaml:"grpc"`
         ImageProcessing struct {
-                EnhancementEnabled bool    `yaml:"enhancementEnabled"`
-                ContrastFactor     float64 `yaml:"contrastFactor"`
-                BrightnessAdjust   float64 `yaml:"brightnessAdjust"`
-                DenoiseLevel       int     `yaml:"denoiseLevel"`
+                EnhancementEnabled     bool    `yaml:"enhancementEnabled"`
+                ContrastFactor         float64 `yaml:"contrastFactor"`
+                BrightnessAdjust       float64 `yaml:"brightnessAdjust"`
+                DenoiseLevel           int     `yaml:"denoiseLevel"`
+                GaussianBlurSigma      float64 `yaml:"gaussianBlurSigma"`
+                GaussianBlurSize       int     `yaml:"gaussianBlurSize"`
+                BoxBlurSize            int     `yaml:"boxBlurSize"`
+                SobelThreshold         uint8   `yaml:"sobelThreshold"`
+                RotationAngle          float64 `yaml:"rotationAngle"`
+                ConcurrencyLevel       int     `yaml:"concurrencyLevel"`
+                UseParallelProcessing  bool    `yaml:"useParallelProcessing"`
+                GlyphTemplateDir       string  `yaml:"glyphTemplateDir"`
+                OCRUncertainConfidence float64 `yaml:"ocrUncertainConfidence"`
+                ReadingDirection       string  `yaml:"readingDirection"`
+                SignInventoryDir       string  `yaml:"signInventoryDir"`
+                ReviewQueueDir         string  `yaml:"reviewQueueDir"`
+                Deskew                 bool    `yaml:"deskew"`
+                MaxSkewAngle           float64 `yaml:"maxSkewAngle"`
+                DetectOrientation      bool    `yaml:"detectOrientation"`
+                BinarizationMethod     string  `yaml:"binarizationMethod"`
+                BinarizationWindow     int     `yaml:"binarizationWindow"`
+                BinarizationK          float64 `yaml:"binarizationK"`
+                ContrastClip           float64 `yaml:"contrastClip"`
+                Gamma                  float64 `yaml:"gamma"`
+                CLAHEClipLimit         float64 `yaml:"claheClipLimit"`
+                CLAHETiles             int     `yaml:"claheTiles"`
+                MorphologyOperation    string  `yaml:"morphologyOperation"`
+                MorphologyElement      string  `yaml:"morphologyElement"`
+                MorphologySize         int     `yaml:"morphologySize"`
+                DenoiseMethod          string  `yaml:"denoiseMethod"`
+                MedianSize             int     `yaml:"medianSize"`
+                BilateralSigmaSpace    float64 `yaml:"bilateralSigmaSpace"`
+                BilateralSigmaColor    float64 `yaml:"bilateralSigmaColor"`
+                NLMStrength            float64 `yaml:"nlmStrength"`
+                Pipelines              map[string][]PipelineStep `yaml:"pipelines"`
+                Pipeline               string  `yaml:"pipeline"`
         } `yaml:"imageProcessing"`
         Translation struct {
-                DefaultTargetLanguage string   `yaml:"defaultTargetLanguage"`
-                SupportedScripts      []string `yaml:"supportedScripts"`
-                UseExternalAPI        bool     `yaml:"useExternalAPI"`
-                APIEndpoint           string   `yaml:"apiEndpoint"`
-                APIKey                string   `yaml:"apiKey"`
+                DefaultTargetLanguage         string              `yaml:"defaultTargetLanguage"`
+                SupportedScripts              []string            `yaml:"supportedScripts"`
+                UseExternalAPI                bool                `yaml:"useExternalAPI"`
+                APIEndpoint                   string              `yaml:"apiEndpoint"`
+                APIKey                        string              `yaml:"apiKey"`
+                APITimeoutMs                  int                 `yaml:"apiTimeoutMs"`
+                APIMaxRetries                 int                 `yaml:"apiMaxRetries"`
+                APIRetryBackoffMs             int                 `yaml:"apiRetryBackoffMs"`
+                APIRateLimitPerSecond         float64             `yaml:"apiRateLimitPerSecond"`
+                APICircuitBreakerThreshold    int                 `yaml:"apiCircuitBreakerThreshold"`
+                APICircuitBreakerResetSeconds int                 `yaml:"apiCircuitBreakerResetSeconds"`
+                APITargetLanguages            []string            `yaml:"apiTargetLanguages"`
+                LexiconDir                    string              `yaml:"lexiconDir"`
+                DefaultBackends               []string            `yaml:"defaultBackends"`
+                ScriptBackends                map[string][]string `yaml:"scriptBackends"`
+                MemoryPath                    string              `yaml:"memoryPath"`
+                MemoryMinMatchPercent         int                 `yaml:"memoryMinMatchPercent"`
+                MemoryMaxMatches              int                 `yaml:"memoryMaxMatches"`
+                GlossaryPath                  string              `yaml:"glossaryPath"`
+                MaxCandidates                 int                 `yaml:"maxCandidates"`
         } `yaml:"translation"`
         Summarization struct {
                 MaxSummaryLength    int     `yaml:"maxSummaryLength"`
@@ -62,6 +109,33 @@ func LoadConfig(path string) (*Config, error) {
         config.ImageProcessing.ContrastFactor = 1.5
         config.ImageProcessing.BrightnessAdjust = 0.1
         config.ImageProcessing.DenoiseLevel = 2
+        config.ImageProcessing.GaussianBlurSigma = 1.5
+        config.ImageProcessing.GaussianBlurSize = 5
+        config.ImageProcessing.BoxBlurSize = 3
+        config.ImageProcessing.SobelThreshold = 30
+        config.ImageProcessing.ConcurrencyLevel = 4
+        config.ImageProcessing.UseParallelProcessing = true
+        config.ImageProcessing.GlyphTemplateDir = "glyphs"
+        config.ImageProcessing.OCRUncertainConfidence = 0.6
+        config.ImageProcessing.ReadingDirection = "ltr"
+        config.ImageProcessing.SignInventoryDir = "signs"
+        config.ImageProcessing.ReviewQueueDir = "review"
+        config.ImageProcessing.Deskew = true
+        config.ImageProcessing.MaxSkewAngle = 10
+        config.ImageProcessing.DetectOrientation = true
+        config.ImageProcessing.BinarizationMethod = "sauvola"
+        config.ImageProcessing.BinarizationWindow = 25
+        config.ImageProcessing.ContrastClip = 0.01
+        config.ImageProcessing.Gamma = 1
+        config.ImageProcessing.CLAHETiles = 8
+        config.ImageProcessing.MorphologyOperation = "none"
+        config.ImageProcessing.MorphologyElement = "rect"
+        config.ImageProcessing.MorphologySize = 3
+        config.ImageProcessing.DenoiseMethod = "median"
+        config.ImageProcessing.MedianSize = 3
+        config.ImageProcessing.BilateralSigmaSpace = 3
+        config.ImageProcessing.BilateralSigmaColor = 25
+        config.ImageProcessing.NLMStrength = 15
         
         // Default translation settings
         config.Translation.DefaultTargetLanguage = "en"
@@ -69,6 +143,18 @@ func LoadConfig(path string) (*Config, error) {
         config.Translation.UseExternalAPI = false
         config.Translation.APIEndpoint = os.Getenv("TRANSLATION_API_ENDPOINT")
         config.Translation.APIKey = os.Getenv("TRANSLATION_API_KEY")
+        config.Translation.LexiconDir = "lexicons"
+        config.Translation.APITimeoutMs = 10000
+        config.Translation.APIMaxRetries = 3
+        config.Translation.APIRetryBackoffMs = 200
+        config.Translation.APIRateLimitPerSecond = 5
+        config.Translation.APICircuitBreakerThreshold = 5
+        config.Translation.APICircuitBreakerResetSeconds = 30
+        config.Translation.MemoryPath = "memory/translations.tmx"
+        config.Translation.MemoryMinMatchPercent = 75
+        config.Translation.MemoryMaxMatches = 3
+        config.Translation.GlossaryPath = "glossaries/glossaries.json"
+        config.Translation.MaxCandidates = 5
         
         // Default summarization settings
         config.Summarization.MaxSummaryLength = 500
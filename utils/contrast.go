@@ -0,0 +1,264 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Defaults of the contrast processors
+const (
+	// DefaultContrastClip is the share of the darkest and of the lightest pixels a contrast stretch
+	// clips, so that a few specks of black or glare do not hold the stretch back
+	DefaultContrastClip = 0.01
+	// DefaultCLAHETiles is the number of tiles across and down CLAHE equalizes separately
+	DefaultCLAHETiles = 8
+	// DefaultCLAHEClipLimit caps the histogram of a CLAHE tile at this multiple of its mean count
+	DefaultCLAHEClipLimit = 2.0
+)
+
+// BrightnessProcessor lightens or darkens an image by adding a share of the full range to
+// every channel: 0.1 lightens by a tenth, -0.1 darkens by a tenth
+type BrightnessProcessor struct {
+	ImageProcessor
+	adjust float64 // Share of the full range added, from -1 to 1
+}
+
+func NewBrightnessProcessor(adjust float64, concurrency int, useParallel bool) *BrightnessProcessor {
+	return &BrightnessProcessor{
+		ImageProcessor: NewImageProcessor("Brightness", concurrency, useParallel),
+		adjust:         math.Max(-1, math.Min(1, adjust)),
+	}
+}
+
+func (p *BrightnessProcessor) Process(img image.Image) image.Image {
+	var levels [256]uint8
+	for value := range levels {
+		levels[value] = clampLevel(float64(value) + p.adjust*255)
+	}
+	return p.mapLevels(img, levels)
+}
+
+// GammaProcessor applies a gamma curve to every channel. A gamma above 1 darkens the middle
+// tones, deepening faded ink while leaving the white of the surface, below 1 lightens them.
+type GammaProcessor struct {
+	ImageProcessor
+	gamma float64 // Exponent of the curve; 1 leaves the image unchanged
+}
+
+func NewGammaProcessor(gamma float64, concurrency int, useParallel bool) *GammaProcessor {
+	if gamma <= 0 {
+		gamma = 1
+	}
+	return &GammaProcessor{
+		ImageProcessor: NewImageProcessor("Gamma", concurrency, useParallel),
+		gamma:          gamma,
+	}
+}
+
+func (p *GammaProcessor) Process(img image.Image) image.Image {
+	var levels [256]uint8
+	for value := range levels {
+		levels[value] = clampLevel(255 * math.Pow(float64(value)/255, p.gamma))
+	}
+	return p.mapLevels(img, levels)
+}
+
+// ContrastStretchProcessor spreads the luminance of an image over the full range: the levels
+// below which the clip share of the pixels lie become black, those above which it lies become
+// white, and the levels between are stretched linearly. The factor then scales the contrast
+// about the middle gray, so a factor above 1 pushes faded ink further from the surface.
+type ContrastStretchProcessor struct {
+	ImageProcessor
+	factor float64 // Contrast multiplier applied after the stretch; 1 only stretches
+	clip   float64 // Share of the darkest and of the lightest pixels clipped
+}
+
+func NewContrastStretchProcessor(factor, clip float64, concurrency int, useParallel bool) *ContrastStretchProcessor {
+	if factor <= 0 {
+		factor = 1
+	}
+	if clip < 0 || clip >= 0.5 {
+		clip = DefaultContrastClip
+	}
+	return &ContrastStretchProcessor{
+		ImageProcessor: NewImageProcessor("Contrast Stretch", concurrency, useParallel),
+		factor:         factor,
+		clip:           clip,
+	}
+}
+
+func (p *ContrastStretchProcessor) Process(img image.Image) image.Image {
+	gray := p.grayLevels(img)
+	var histogram [256]int
+	for _, value := range gray.Pix {
+		histogram[value]++
+	}
+
+	// Find the levels below and above which the clipped pixels lie
+	clipped := int(p.clip * float64(len(gray.Pix)))
+	low, high := 0, 255
+	for count := 0; low < 255 && count+histogram[low] <= clipped; low++ {
+		count += histogram[low]
+	}
+	for count := 0; high > 0 && count+histogram[high] <= clipped; high-- {
+		count += histogram[high]
+	}
+	if high <= low {
+		// A flat image has no contrast to stretch
+		low, high = 0, 255
+	}
+
+	var levels [256]uint8
+	for value := range levels {
+		stretched := float64(value-low) * 255 / float64(high-low)
+		levels[value] = clampLevel((stretched-127.5)*p.factor + 127.5)
+	}
+	return p.mapLevels(img, levels)
+}
+
+// CLAHEProcessor equalizes the luminance of an image with contrast-limited adaptive histogram
+// equalization. The image is divided into tiles whose histograms are equalized separately,
+// so faded ink in one corner is brought out as much as dark ink in another. Each histogram is
+// clipped at a multiple of its mean count before equalization, the excess spread evenly over
+// all levels, which keeps the texture of a blank surface from being amplified into noise.
+// The mappings of the four nearest tiles are blended to avoid seams between the tiles.
+type CLAHEProcessor struct {
+	ImageProcessor
+	tiles     int     // Number of tiles across and down
+	clipLimit float64 // Cap of a tile histogram as a multiple of its mean count
+}
+
+func NewCLAHEProcessor(tiles int, clipLimit float64, concurrency int, useParallel bool) *CLAHEProcessor {
+	if tiles <= 0 {
+		tiles = DefaultCLAHETiles
+	}
+	if clipLimit < 1 {
+		clipLimit = DefaultCLAHEClipLimit
+	}
+	return &CLAHEProcessor{
+		ImageProcessor: NewImageProcessor("CLAHE", concurrency, useParallel),
+		tiles:          tiles,
+		clipLimit:      clipLimit,
+	}
+}
+
+func (p *CLAHEProcessor) Process(img image.Image) image.Image {
+	gray := p.grayLevels(img)
+	width, height := gray.Rect.Dx(), gray.Rect.Dy()
+	result := image.NewGray(img.Bounds())
+	if width == 0 || height == 0 {
+		return result
+	}
+
+	tilesX, tilesY := minInt(p.tiles, width), minInt(p.tiles, height)
+	tileWidth := (width + tilesX - 1) / tilesX
+	tileHeight := (height + tilesY - 1) / tilesY
+
+	// Equalize the clipped histogram of each tile
+	mappings := make([][256]float64, tilesX*tilesY)
+	p.forEachRows(tilesY, func(startTile, endTile int) {
+		for ty := startTile; ty < endTile; ty++ {
+			for tx := 0; tx < tilesX; tx++ {
+				tile := image.Rect(tx*tileWidth, ty*tileHeight, (tx+1)*tileWidth, (ty+1)*tileHeight).Intersect(gray.Rect)
+				mappings[ty*tilesX+tx] = p.equalize(gray, tile)
+			}
+		}
+	})
+
+	// Blend the mappings of the tiles whose centres surround each pixel
+	p.forEachRows(height, func(startY, endY int) {
+		for y := startY; y < endY; y++ {
+			ty0, ty1, wy := tileNeighbours(y, tileHeight, tilesY)
+			for x := 0; x < width; x++ {
+				tx0, tx1, wx := tileNeighbours(x, tileWidth, tilesX)
+				value := gray.Pix[y*gray.Stride+x]
+				top := mappings[ty0*tilesX+tx0][value]*(1-wx) + mappings[ty0*tilesX+tx1][value]*wx
+				bottom := mappings[ty1*tilesX+tx0][value]*(1-wx) + mappings[ty1*tilesX+tx1][value]*wx
+				result.Pix[y*result.Stride+x] = clampLevel(top*(1-wy) + bottom*wy)
+			}
+		}
+	})
+	return result
+}
+
+// equalize returns the mapping of gray levels that equalizes the clipped histogram of a tile
+func (p *CLAHEProcessor) equalize(gray *image.Gray, tile image.Rectangle) [256]float64 {
+	var histogram [256]float64
+	for y := tile.Min.Y; y < tile.Max.Y; y++ {
+		for x := tile.Min.X; x < tile.Max.X; x++ {
+			histogram[gray.Pix[y*gray.Stride+x]]++
+		}
+	}
+
+	// Clip the histogram and spread the excess evenly over all levels
+	pixels := float64(tile.Dx() * tile.Dy())
+	limit := math.Max(p.clipLimit*pixels/256, 1)
+	var excess float64
+	for value, count := range histogram {
+		if count > limit {
+			excess += count - limit
+			histogram[value] = limit
+		}
+	}
+
+	var mapping [256]float64
+	var cumulative float64
+	for value, count := range histogram {
+		cumulative += count + excess/256
+		mapping[value] = cumulative * 255 / pixels
+	}
+	return mapping
+}
+
+// tileNeighbours returns the tiles whose centres lie before and after a pixel along one axis
+// and the weight of the latter; pixels beyond the outer centres take the outer tile alone
+func tileNeighbours(position, tileSize, tiles int) (int, int, float64) {
+	offset := (float64(position)+0.5)/float64(tileSize) - 0.5
+	if offset <= 0 {
+		return 0, 0, 0
+	}
+	first := int(offset)
+	if first >= tiles-1 {
+		return tiles - 1, tiles - 1, 0
+	}
+	return first, first + 1, offset - float64(first)
+}
+
+// mapLevels maps the red, green and blue levels of an image through a lookup table, keeping
+// alpha; gray images stay gray
+func (ip *ImageProcessor) mapLevels(img image.Image, levels [256]uint8) image.Image {
+	bounds := img.Bounds()
+	if gray, ok := img.(*image.Gray); ok {
+		result := image.NewGray(bounds)
+		ip.forEachRows(bounds.Dy(), func(startY, endY int) {
+			for y := startY; y < endY; y++ {
+				for x := 0; x < bounds.Dx(); x++ {
+					result.Pix[y*result.Stride+x] = levels[gray.Pix[y*gray.Stride+x]]
+				}
+			}
+		})
+		return result
+	}
+
+	result := image.NewRGBA(bounds)
+	ip.forEachRows(bounds.Dy(), func(startY, endY int) {
+		for y := startY; y < endY; y++ {
+			for x := 0; x < bounds.Dx(); x++ {
+				c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+				result.Set(bounds.Min.X+x, bounds.Min.Y+y, color.NRGBA{
+					R: levels[c.R],
+					G: levels[c.G],
+					B: levels[c.B],
+					A: c.A,
+				})
+			}
+		}
+	})
+	return result
+}
+
+// clampLevel rounds a gray level to the nearest 8-bit value
+func clampLevel(value float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(value))))
+}
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// levelsOf returns the gray levels a processor maps each of the given levels to
+func levelsOf(processor ImageProcessingAlgorithm, levels ...uint8) []uint8 {
+	img := grayImage(len(levels), 1, func(x, y int) uint8 { return levels[x] })
+	mapped := processor.Process(img).(*image.Gray)
+	return mapped.Pix[:len(levels)]
+}
+
+func TestLevelProcessors(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor ImageProcessingAlgorithm
+		levels    []uint8
+		want      []uint8
+	}{
+		{name: "lighten by a tenth", processor: NewBrightnessProcessor(0.1, 1, false), levels: []uint8{0, 100, 240}, want: []uint8{26, 126, 255}},
+		{name: "darken by a tenth", processor: NewBrightnessProcessor(-0.1, 1, false), levels: []uint8{0, 100, 255}, want: []uint8{0, 75, 230}},
+		{name: "brightness beyond the range", processor: NewBrightnessProcessor(-3, 1, false), levels: []uint8{100, 255}, want: []uint8{0, 0}},
+		{name: "gamma darkens middle tones", processor: NewGammaProcessor(2, 1, false), levels: []uint8{0, 128, 255}, want: []uint8{0, 64, 255}},
+		{name: "gamma lightens middle tones", processor: NewGammaProcessor(0.5, 1, false), levels: []uint8{0, 64, 255}, want: []uint8{0, 128, 255}},
+		{name: "gamma without exponent", processor: NewGammaProcessor(0, 1, false), levels: []uint8{0, 64, 255}, want: []uint8{0, 64, 255}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := levelsOf(test.processor, test.levels...); string(got) != string(test.want) {
+				t.Errorf("Process() levels = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLevelProcessorsKeepColorAndAlpha(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 100, G: 0, B: 240, A: 128})
+
+	got := color.NRGBAModel.Convert(NewBrightnessProcessor(0.1, 1, false).Process(img).At(0, 0)).(color.NRGBA)
+	// Stored premultiplied by the alpha, the color channels may round by a level
+	if want := (color.NRGBA{R: 126, G: 26, B: 255, A: 128}); absDiff(got.R, want.R) > 1 || absDiff(got.G, want.G) > 1 || got.B != want.B || got.A != want.A {
+		t.Errorf("Process() = %v, want %v", got, want)
+	}
+}
+
+// absDiff returns the difference between two levels
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// specksAndInk is a 10x10 image of faded ink at levels 100 and 150 with one black and one white speck
+func specksAndInk() *image.Gray {
+	return grayImage(10, 10, func(x, y int) uint8 {
+		switch {
+		case x == 0 && y == 0:
+			return 0
+		case x == 9 && y == 9:
+			return 255
+		case (x+y)%2 == 0:
+			return 100
+		}
+		return 150
+	})
+}
+
+func TestContrastStretchProcessor(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor ImageProcessingAlgorithm
+		img       *image.Gray
+		// want maps input levels to the levels expected for them
+		want map[uint8]uint8
+	}{
+		{
+			name:      "specks are clipped",
+			processor: NewContrastStretchProcessor(1, 0.01, 1, false),
+			img:       specksAndInk(),
+			want:      map[uint8]uint8{0: 0, 100: 0, 150: 255, 255: 255},
+		},
+		{
+			name:      "specks hold back the stretch without clipping",
+			processor: NewContrastStretchProcessor(1, 0, 1, false),
+			img:       specksAndInk(),
+			want:      map[uint8]uint8{0: 0, 100: 100, 150: 150, 255: 255},
+		},
+		{
+			name:      "stretch between the levels",
+			processor: NewContrastStretchProcessor(1, 0, 1, false),
+			img:       grayImage(3, 1, func(x, y int) uint8 { return []uint8{100, 130, 150}[x] }),
+			want:      map[uint8]uint8{100: 0, 130: 153, 150: 255},
+		},
+		{
+			name:      "factor scales the contrast about the middle gray",
+			processor: NewContrastStretchProcessor(2, 0, 1, false),
+			img:       grayImage(4, 1, func(x, y int) uint8 { return []uint8{100, 125, 130, 150}[x] }),
+			want:      map[uint8]uint8{100: 0, 125: 128, 130: 179, 150: 255},
+		},
+		{
+			name:      "flat image",
+			processor: NewContrastStretchProcessor(1, 0.01, 1, false),
+			img:       grayImage(10, 10, func(x, y int) uint8 { return 128 }),
+			want:      map[uint8]uint8{128: 128},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stretched := test.processor.Process(test.img).(*image.Gray)
+			for i, level := range test.img.Pix {
+				if got := stretched.Pix[i]; got != test.want[level] {
+					t.Errorf("level %d = %d, want %d", level, got, test.want[level])
+				}
+			}
+		})
+	}
+}
+
+// textureSpread returns the difference between the lightest and the darkest level of an image
+func textureSpread(img *image.Gray) int {
+	low, high := 255, 0
+	for _, level := range img.Pix {
+		low, high = minInt(low, int(level)), maxInt(high, int(level))
+	}
+	return high - low
+}
+
+func TestCLAHEProcessor(t *testing.T) {
+	// Faded ink at level 100 on a surface at 110, filling one 16x16 tile of 256 pixels equally
+	faded := grayImage(16, 16, func(x, y int) uint8 {
+		if x < 8 {
+			return 100
+		}
+		return 110
+	})
+
+	tests := []struct {
+		name      string
+		clipLimit float64
+		// wantInk and wantSurface are the levels expected for the ink and the surface
+		wantInk, wantSurface uint8
+	}{
+		// Clipped at twice the mean count, the 128 pixels of each level count as 2; the excess
+		// spread over all levels raises the contrast of the ten levels between them only slightly
+		{name: "clip limit of 2", clipLimit: 2, wantInk: 101, wantSurface: 113},
+		// Unclipped, the histogram is fully equalized and the ink and surface pulled apart
+		{name: "clip limit beyond the counts", clipLimit: 256, wantInk: 128, wantSurface: 255},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			equalized := NewCLAHEProcessor(1, test.clipLimit, 1, false).Process(faded).(*image.Gray)
+			if ink, surface := equalized.Pix[0], equalized.Pix[15]; ink != test.wantInk || surface != test.wantSurface {
+				t.Errorf("ink, surface = %d, %d, want %d, %d", ink, surface, test.wantInk, test.wantSurface)
+			}
+		})
+	}
+}
+
+func TestCLAHEProcessorLimitsBlankSurface(t *testing.T) {
+	// A blank surface with a faint texture of one level either side of 200
+	surface := grayImage(32, 32, func(x, y int) uint8 { return uint8(199 + (x*7+y*3)%3) })
+
+	limited := NewCLAHEProcessor(2, DefaultCLAHEClipLimit, 1, false).Process(surface).(*image.Gray)
+	if spread := textureSpread(limited); spread > 6 {
+		t.Errorf("texture spread with the clip limit = %d, want at most 6", spread)
+	}
+	unlimited := NewCLAHEProcessor(2, 1000, 1, false).Process(surface).(*image.Gray)
+	if spread := textureSpread(unlimited); spread < 100 {
+		t.Errorf("texture spread without a clip limit = %d, want the texture amplified", spread)
+	}
+}
+
+func TestCLAHEProcessorParallel(t *testing.T) {
+	img := noisyStepEdge(20)
+	serial := NewCLAHEProcessor(4, 3, 1, false).Process(img).(*image.Gray)
+	parallel := NewCLAHEProcessor(4, 3, 4, true).Process(img).(*image.Gray)
+	if string(serial.Pix) != string(parallel.Pix) {
+		t.Error("parallel CLAHE differs from serial")
+	}
+}
@@ -0,0 +1,306 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// DenoiseMethod is the filter the image processing pipeline smooths noise away with
+type DenoiseMethod string
+
+// Denoising methods of the image processing pipeline
+const (
+	// NoDenoise leaves the noise in the image
+	NoDenoise DenoiseMethod = "none"
+	// BoxDenoise averages the window around each pixel, blurring strokes with the noise
+	BoxDenoise DenoiseMethod = "box"
+	// GaussianDenoise averages the window around each pixel weighted by distance
+	GaussianDenoise DenoiseMethod = "gaussian"
+	// MedianDenoise takes the median of the window around each pixel, removing specks and
+	// salt-and-pepper noise while keeping the edges of strokes sharp
+	MedianDenoise DenoiseMethod = "median"
+	// BilateralDenoise averages the pixels of similar shade near each pixel, smoothing the
+	// grain of a surface without blurring the edges of strokes
+	BilateralDenoise DenoiseMethod = "bilateral"
+	// NonLocalMeansDenoise averages the pixels whose surroundings look alike, for heavily noisy scans
+	NonLocalMeansDenoise DenoiseMethod = "nlm"
+)
+
+// Defaults of the edge-preserving denoising filters
+const (
+	// DefaultMedianSize is the side in pixels of the window of the median filter
+	DefaultMedianSize = 3
+	// DefaultBilateralSigmaSpace is the spread in pixels of the distance weights of the bilateral filter
+	DefaultBilateralSigmaSpace = 3.0
+	// DefaultBilateralSigmaColor is the spread in gray levels of the shade weights of the bilateral filter
+	DefaultBilateralSigmaColor = 25.0
+	// MaxBilateralSigmaSpace is the largest spread in pixels pipeline steps may give the bilateral filter
+	MaxBilateralSigmaSpace = 10
+	// maxBilateralRadius caps the half side of the window of the bilateral filter, whose cost grows
+	// with its area; wider spreads are cut off at it
+	maxBilateralRadius = 10
+	// DefaultNLMStrength is the filtering strength h of non-local means, in gray levels;
+	// about the standard deviation of the noise removes it without washing out faint strokes
+	DefaultNLMStrength = 15.0
+	// nlmPatchRadius is the half side of the patches non-local means compares
+	nlmPatchRadius = 1
+	// nlmSearchRadius is the half side of the window non-local means searches for similar patches
+	nlmSearchRadius = 5
+)
+
+// ParseDenoiseMethod validates the name of a denoising method
+func ParseDenoiseMethod(name string) (DenoiseMethod, error) {
+	switch method := DenoiseMethod(name); method {
+	case NoDenoise, BoxDenoise, GaussianDenoise, MedianDenoise, BilateralDenoise, NonLocalMeansDenoise:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown denoise method %q: must be none, box, gaussian, median, bilateral or nlm", name)
+}
+
+// MedianProcessor replaces each pixel with the median luminance of the window around it.
+// The window histogram is updated as it slides along a row, so the cost grows with the side
+// of the window rather than its area. It returns a grayscale image.
+type MedianProcessor struct {
+	ImageProcessor
+	size int // Side of the window in pixels (odd)
+}
+
+func NewMedianProcessor(size int, concurrency int, useParallel bool) *MedianProcessor {
+	if size < 3 {
+		size = DefaultMedianSize
+	}
+	if size%2 == 0 {
+		size++
+	}
+	return &MedianProcessor{
+		ImageProcessor: NewImageProcessor("Median Filter", concurrency, useParallel),
+		size:           size,
+	}
+}
+
+func (p *MedianProcessor) Process(img image.Image) image.Image {
+	gray := p.grayLevels(img)
+	width, height := gray.Rect.Dx(), gray.Rect.Dy()
+	result := image.NewGray(img.Bounds())
+	radius := p.size / 2
+
+	p.forEachRows(height, func(startY, endY int) {
+		for y := startY; y < endY; y++ {
+			top, bottom := maxInt(y-radius, 0), minInt(y+radius+1, height)
+
+			// Fill the histogram of the window of the first pixel of the row
+			var histogram [256]int
+			count := 0
+			for wy := top; wy < bottom; wy++ {
+				for wx := 0; wx < minInt(radius+1, width); wx++ {
+					histogram[gray.Pix[wy*gray.Stride+wx]]++
+					count++
+				}
+			}
+
+			for x := 0; x < width; x++ {
+				if x > 0 {
+					// Slide the window: drop the column that left it and add the one that entered
+					if left := x - radius - 1; left >= 0 {
+						for wy := top; wy < bottom; wy++ {
+							histogram[gray.Pix[wy*gray.Stride+left]]--
+							count--
+						}
+					}
+					if right := x + radius; right < width {
+						for wy := top; wy < bottom; wy++ {
+							histogram[gray.Pix[wy*gray.Stride+right]]++
+							count++
+						}
+					}
+				}
+
+				// Find the level at which half of the window is reached
+				half, cumulative := count/2, 0
+				level := 0
+				for ; level < 255; level++ {
+					cumulative += histogram[level]
+					if cumulative > half {
+						break
+					}
+				}
+				result.Pix[y*result.Stride+x] = uint8(level)
+			}
+		}
+	})
+	return result
+}
+
+// BilateralProcessor replaces each pixel with the mean luminance of the window around it,
+// weighted both by distance and by closeness in shade. Pixels across the edge of a stroke
+// differ in shade and barely count, so the edge stays sharp while the grain on either side
+// is smoothed. It returns a grayscale image.
+type BilateralProcessor struct {
+	ImageProcessor
+	sigmaSpace float64 // Spread of the distance weights in pixels
+	sigmaColor float64 // Spread of the shade weights in gray levels
+}
+
+func NewBilateralProcessor(sigmaSpace, sigmaColor float64, concurrency int, useParallel bool) *BilateralProcessor {
+	if sigmaSpace <= 0 {
+		sigmaSpace = DefaultBilateralSigmaSpace
+	}
+	if sigmaColor <= 0 {
+		sigmaColor = DefaultBilateralSigmaColor
+	}
+	return &BilateralProcessor{
+		ImageProcessor: NewImageProcessor("Bilateral Filter", concurrency, useParallel),
+		sigmaSpace:     sigmaSpace,
+		sigmaColor:     sigmaColor,
+	}
+}
+
+func (p *BilateralProcessor) Process(img image.Image) image.Image {
+	gray := p.grayLevels(img)
+	width, height := gray.Rect.Dx(), gray.Rect.Dy()
+	result := image.NewGray(img.Bounds())
+
+	radius := p.radius()
+	side := 2*radius + 1
+	spaceWeights := make([]float64, side*side)
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			spaceWeights[(dy+radius)*side+dx+radius] = math.Exp(-float64(dx*dx+dy*dy) / (2 * p.sigmaSpace * p.sigmaSpace))
+		}
+	}
+	var colorWeights [256]float64
+	for difference := range colorWeights {
+		colorWeights[difference] = math.Exp(-float64(difference*difference) / (2 * p.sigmaColor * p.sigmaColor))
+	}
+
+	p.forEachRows(height, func(startY, endY int) {
+		for y := startY; y < endY; y++ {
+			for x := 0; x < width; x++ {
+				center := int(gray.Pix[y*gray.Stride+x])
+				var sum, weights float64
+				for dy := -radius; dy <= radius; dy++ {
+					ny := y + dy
+					if ny < 0 || ny >= height {
+						continue
+					}
+					for dx := -radius; dx <= radius; dx++ {
+						nx := x + dx
+						if nx < 0 || nx >= width {
+							continue
+						}
+						value := int(gray.Pix[ny*gray.Stride+nx])
+						difference := value - center
+						if difference < 0 {
+							difference = -difference
+						}
+						weight := spaceWeights[(dy+radius)*side+dx+radius] * colorWeights[difference]
+						sum += weight * float64(value)
+						weights += weight
+					}
+				}
+				result.Pix[y*result.Stride+x] = clampLevel(sum / weights)
+			}
+		}
+	})
+	return result
+}
+
+// radius returns the half side of the window: two spreads out, beyond which the distance
+// weights are negligible, up to maxBilateralRadius
+func (p *BilateralProcessor) radius() int {
+	return minInt(int(math.Ceil(2*p.sigmaSpace)), maxBilateralRadius)
+}
+
+// NonLocalMeansProcessor replaces each pixel with the mean luminance of the pixels in a search
+// window around it, weighted by how much the small patches around them look like the patch
+// around the pixel. Noise averages out over the many alike patches of a surface or a stroke
+// while edges, which have few alike patches across them, are kept. It is the slowest of the
+// filters and meant for heavily noisy scans. It returns a grayscale image.
+type NonLocalMeansProcessor struct {
+	ImageProcessor
+	strength float64 // Filtering strength h in gray levels
+}
+
+func NewNonLocalMeansProcessor(strength float64, concurrency int, useParallel bool) *NonLocalMeansProcessor {
+	if strength <= 0 {
+		strength = DefaultNLMStrength
+	}
+	return &NonLocalMeansProcessor{
+		ImageProcessor: NewImageProcessor("Non-Local Means", concurrency, useParallel),
+		strength:       strength,
+	}
+}
+
+func (p *NonLocalMeansProcessor) Process(img image.Image) image.Image {
+	gray := p.grayLevels(img)
+	width, height := gray.Rect.Dx(), gray.Rect.Dy()
+	result := image.NewGray(img.Bounds())
+
+	// Pixels beyond the edges of the image take the value of the nearest edge pixel
+	at := func(x, y int) float64 {
+		x = maxInt(0, minInt(x, width-1))
+		y = maxInt(0, minInt(y, height-1))
+		return float64(gray.Pix[y*gray.Stride+x])
+	}
+	patchSide := 2*nlmPatchRadius + 1
+	scale := float64(patchSide*patchSide) * p.strength * p.strength
+
+	// Each offset within the search window is taken in turn for all pixels: the squared
+	// differences between the image and the image shifted by the offset, summed over the
+	// patches with a sliding window, give the distance of every pixel's patch to that of the
+	// pixel at the offset from it, at a cost that grows with the side of the patch rather than its area
+	sums := make([]float64, width*height)
+	weights := make([]float64, width*height)
+	differences := make([]float64, width*height)
+	rowSums := make([]float64, width*height)
+	for dy := -nlmSearchRadius; dy <= nlmSearchRadius; dy++ {
+		for dx := -nlmSearchRadius; dx <= nlmSearchRadius; dx++ {
+			p.forEachRows(height, func(startY, endY int) {
+				for y := startY; y < endY; y++ {
+					for x := 0; x < width; x++ {
+						d := at(x, y) - at(x+dx, y+dy)
+						differences[y*width+x] = d * d
+					}
+				}
+			})
+			p.forEachRows(height, func(startY, endY int) {
+				for y := startY; y < endY; y++ {
+					row := differences[y*width : (y+1)*width]
+					for x := 0; x < width; x++ {
+						var sum float64
+						for px := -nlmPatchRadius; px <= nlmPatchRadius; px++ {
+							sum += row[maxInt(0, minInt(x+px, width-1))]
+						}
+						rowSums[y*width+x] = sum
+					}
+				}
+			})
+			p.forEachRows(height, func(startY, endY int) {
+				for y := startY; y < endY; y++ {
+					for x := 0; x < width; x++ {
+						// Candidates outside the image are not averaged in
+						sx, sy := x+dx, y+dy
+						if sx < 0 || sx >= width || sy < 0 || sy >= height {
+							continue
+						}
+						var distance float64
+						for py := -nlmPatchRadius; py <= nlmPatchRadius; py++ {
+							distance += rowSums[maxInt(0, minInt(y+py, height-1))*width+x]
+						}
+						weight := math.Exp(-distance / scale)
+						sums[y*width+x] += weight * at(sx, sy)
+						weights[y*width+x] += weight
+					}
+				}
+			})
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			result.Pix[y*result.Stride+x] = clampLevel(sums[y*width+x] / weights[y*width+x])
+		}
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// grayImage draws an image with the shade given for each pixel
+func grayImage(width, height int, shade func(x, y int) uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Pix[y*img.Stride+x] = shade(x, y)
+		}
+	}
+	return img
+}
+
+// stepEdge is dark left of column 10 and light from it on
+func stepEdge(x, y int) uint8 {
+	if x < 10 {
+		return 50
+	}
+	return 200
+}
+
+// noisyStepEdge is the step edge with Gaussian grain of the given deviation
+func noisyStepEdge(deviation float64) *image.Gray {
+	random := rand.New(rand.NewSource(1))
+	return grayImage(20, 16, func(x, y int) uint8 {
+		return clampLevel(float64(stepEdge(x, y)) + random.NormFloat64()*deviation)
+	})
+}
+
+// grainDeviation returns the standard deviation of the shades of the dark half of a step
+// edge away from the edge and the borders
+func grainDeviation(img image.Image) float64 {
+	gray := img.(*image.Gray)
+	var sum, squares, count float64
+	for y := 3; y < 13; y++ {
+		for x := 2; x < 7; x++ {
+			value := float64(gray.Pix[y*gray.Stride+x])
+			sum += value
+			squares += value * value
+			count++
+		}
+	}
+	mean := sum / count
+	return math.Sqrt(squares/count - mean*mean)
+}
+
+// edgeKept reports whether the pixels either side of the step edge keep their shades
+func edgeKept(t *testing.T, img image.Image) {
+	t.Helper()
+	gray := img.(*image.Gray)
+	for y := 0; y < 16; y++ {
+		if dark, light := gray.Pix[y*gray.Stride+9], gray.Pix[y*gray.Stride+10]; dark > 90 || light < 160 {
+			t.Errorf("row %d: edge pixels = %d, %d, want about 50 and 200", y, dark, light)
+		}
+	}
+}
+
+func TestMedianProcessor(t *testing.T) {
+	clean := grayImage(20, 16, stepEdge)
+	noisy := grayImage(20, 16, func(x, y int) uint8 {
+		switch {
+		case x == 3 && y == 4, x == 14 && y == 9, x == 6 && y == 12:
+			return 255 // salt
+		case x == 5 && y == 7, x == 16 && y == 3, x == 13 && y == 13:
+			return 0 // pepper
+		}
+		return stepEdge(x, y)
+	})
+
+	filtered := NewMedianProcessor(3, 1, false).Process(noisy).(*image.Gray)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 20; x++ {
+			if got, want := filtered.Pix[y*filtered.Stride+x], clean.Pix[y*clean.Stride+x]; got != want {
+				t.Errorf("pixel %d, %d = %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestMedianProcessorParallel(t *testing.T) {
+	noisy := noisyStepEdge(20)
+	serial := NewMedianProcessor(5, 1, false).Process(noisy).(*image.Gray)
+	parallel := NewMedianProcessor(5, 4, true).Process(noisy).(*image.Gray)
+	for i := range serial.Pix {
+		if serial.Pix[i] != parallel.Pix[i] {
+			t.Fatalf("parallel median differs from serial at %d: %d, want %d", i, parallel.Pix[i], serial.Pix[i])
+		}
+	}
+}
+
+func TestEdgePreservingFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		processor ImageProcessingAlgorithm
+	}{
+		{name: "median", processor: NewMedianProcessor(5, 1, false)},
+		{name: "bilateral", processor: NewBilateralProcessor(2, 40, 1, false)},
+		{name: "non-local means", processor: NewNonLocalMeansProcessor(20, 1, false)},
+	}
+
+	noisy := noisyStepEdge(12)
+	before := grainDeviation(noisy)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filtered := test.processor.Process(noisy)
+			if after := grainDeviation(filtered); after > before/2 {
+				t.Errorf("grain deviation = %.1f, want at most half of %.1f", after, before)
+			}
+			edgeKept(t, filtered)
+		})
+	}
+}
+
+func TestBilateralRadius(t *testing.T) {
+	tests := []struct {
+		sigmaSpace float64
+		want       int
+	}{
+		{sigmaSpace: 0, want: 6},
+		{sigmaSpace: 1.2, want: 3},
+		{sigmaSpace: 5, want: 10},
+		{sigmaSpace: 50, want: maxBilateralRadius},
+	}
+
+	for _, test := range tests {
+		if got := NewBilateralProcessor(test.sigmaSpace, 0, 1, false).radius(); got != test.want {
+			t.Errorf("radius() with sigmaSpace %v = %d, want %d", test.sigmaSpace, got, test.want)
+		}
+	}
+}
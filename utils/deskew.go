@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// skewStep is the step in degrees of the coarse search for the skew angle; the best coarse
+// angle is refined in steps of skewStep/5
+const skewStep = 0.5
+
+// maxSkewPoints is the number of ink pixels the skew is estimated from; larger images are sampled
+const maxSkewPoints = 50000
+
+// verticalMajority is how many times more glyphs must have their nearest neighbour above or
+// below them than beside them for the lines of a text to be taken to run vertically
+const verticalMajority = 1.5
+
+// maxOrientationGlyphs is the number of glyphs the orientation is estimated from
+const maxOrientationGlyphs = 2000
+
+// EstimateSkew returns the angle in degrees, at most maxAngle either way, by which a bitmap
+// must be rotated clockwise to level its lines of text. Lines are level when the projection
+// profile of the ink onto the rows is sharpest, which is measured by its variance: level
+// lines pile their ink into few rows and leave the rows between them empty.
+func EstimateSkew(b *Bitmap, maxAngle float64) float64 {
+	var points []image.Point
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			if b.At(x, y) {
+				points = append(points, image.Pt(x, y))
+			}
+		}
+	}
+	if len(points) == 0 || maxAngle <= 0 {
+		return 0
+	}
+	maxAngle = math.Min(maxAngle, 45)
+	if len(points) > maxSkewPoints {
+		stride := (len(points) + maxSkewPoints - 1) / maxSkewPoints
+		sampled := make([]image.Point, 0, maxSkewPoints)
+		for i := 0; i < len(points); i += stride {
+			sampled = append(sampled, points[i])
+		}
+		points = sampled
+	}
+
+	best, bestScore := 0.0, profileVariance(points, 0, b.Width, b.Height)
+	search := func(from, to, step float64) {
+		// Angles nearer level are tried first, so that they win ties
+		for offset := step; offset <= (to-from)/2+1e-9; offset += step {
+			for _, angle := range []float64{(from+to)/2 - offset, (from+to)/2 + offset} {
+				if math.Abs(angle) > maxAngle+1e-9 {
+					continue
+				}
+				if score := profileVariance(points, angle, b.Width, b.Height); score > bestScore {
+					best, bestScore = angle, score
+				}
+			}
+		}
+	}
+	search(-maxAngle, maxAngle, skewStep)
+	search(best-skewStep, best+skewStep, skewStep/5)
+	return math.Round(best*10) / 10
+}
+
+// profileVariance returns the sum of the squared counts of the projection profile of ink pixels
+// of a bitmap of the given size rotated clockwise by an angle; with the number of pixels fixed
+// it grows with the variance of the profile
+func profileVariance(points []image.Point, angle float64, width, height int) float64 {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	// Rotated by less than a quarter turn either way, the rows run from -width to width+height
+	rows := make([]int, 2*width+height+2)
+	for _, p := range points {
+		rows[width+1+int(math.Floor(sin*float64(p.X)+cos*float64(p.Y)))]++
+	}
+	var score float64
+	for _, count := range rows {
+		score += float64(count) * float64(count)
+	}
+	return score
+}
+
+// TextIsVertical reports whether the lines of text of a bitmap run from top to bottom, as in
+// an image turned a quarter turn. Glyphs are closer to their neighbours in a line than to
+// those of the next line, so the direction from each glyph to its nearest neighbour shows
+// the direction of the lines.
+func TextIsVertical(b *Bitmap) bool {
+	boxes := connectedComponents(b, image.Rect(0, 0, b.Width, b.Height))
+	if len(boxes) < 2 {
+		return false
+	}
+
+	// Leave out specks, which lie anywhere
+	sizes := make([]int, len(boxes))
+	for i, box := range boxes {
+		sizes[i] = maxInt(box.Dx(), box.Dy())
+	}
+	minSize := float64(median(sizes)) * separatorRatio
+	var centers []image.Point
+	for _, box := range boxes {
+		if float64(maxInt(box.Dx(), box.Dy())) >= minSize {
+			centers = append(centers, image.Pt((box.Min.X+box.Max.X)/2, (box.Min.Y+box.Max.Y)/2))
+		}
+	}
+	if len(centers) > maxOrientationGlyphs {
+		sort.Slice(centers, func(i, j int) bool { return centers[i].Y < centers[j].Y })
+		stride := (len(centers) + maxOrientationGlyphs - 1) / maxOrientationGlyphs
+		sampled := make([]image.Point, 0, maxOrientationGlyphs)
+		for i := 0; i < len(centers); i += stride {
+			sampled = append(sampled, centers[i])
+		}
+		centers = sampled
+	}
+
+	horizontal, vertical := 0, 0
+	for i, c := range centers {
+		nearest, distance := -1, math.MaxInt
+		for j, other := range centers {
+			if i == j {
+				continue
+			}
+			dx, dy := other.X-c.X, other.Y-c.Y
+			if d := dx*dx + dy*dy; d < distance {
+				nearest, distance = j, d
+			}
+		}
+		if nearest < 0 {
+			continue
+		}
+		dx, dy := centers[nearest].X-c.X, centers[nearest].Y-c.Y
+		if dx*dx >= dy*dy {
+			horizontal++
+		} else {
+			vertical++
+		}
+	}
+	return float64(vertical) > float64(horizontal)*verticalMajority
+}
+
+// BackgroundColor estimates the color of the surface of a manuscript image as the median
+// luminance of the pixels along its border, where there is rarely writing
+func BackgroundColor(img image.Image) color.Gray {
+	bounds := img.Bounds()
+	var histogram [256]int
+	total := 0
+	add := func(x, y int) {
+		histogram[color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y]++
+		total++
+	}
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		add(x, bounds.Min.Y)
+		add(x, bounds.Max.Y-1)
+	}
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y++ {
+		add(bounds.Min.X, y)
+		add(bounds.Max.X-1, y)
+	}
+	if total == 0 {
+		return color.Gray{Y: 255}
+	}
+
+	count := 0
+	for value, n := range histogram {
+		count += n
+		if count*2 >= total {
+			return color.Gray{Y: uint8(value)}
+		}
+	}
+	return color.Gray{Y: 255}
+}
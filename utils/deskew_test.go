@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// tiltedLines draws lines of words three pixels thick, sloping down to the right by an angle
+// in degrees, in a 300x200 bitmap
+func tiltedLines(angle float64) *Bitmap {
+	b := NewBitmap(300, 200)
+	slope := math.Tan(angle * math.Pi / 180)
+	for top := 40; top < 170; top += 30 {
+		for x := 20; x < 280; x++ {
+			// Words of 30 pixels with gaps of 10
+			if (x-20)%40 >= 30 {
+				continue
+			}
+			y := top + int(math.Round(float64(x-150)*slope))
+			for dy := 0; dy < 3; dy++ {
+				b.Set(x, y+dy, true)
+			}
+		}
+	}
+	return b
+}
+
+// glyphRowsBitmap draws three lines of ten glyph blocks, 5 wide and 7 high, set 3 pixels
+// apart within a line and 12 pixels between lines
+func glyphRowsBitmap() *Bitmap {
+	b := NewBitmap(90, 50)
+	for line := 0; line < 3; line++ {
+		for glyph := 0; glyph < 10; glyph++ {
+			for y := 0; y < 7; y++ {
+				for x := 0; x < 5; x++ {
+					b.Set(4+glyph*8+x, 4+line*14+y, true)
+				}
+			}
+		}
+	}
+	return b
+}
+
+func TestEstimateSkew(t *testing.T) {
+	tests := []struct {
+		name     string
+		tilt     float64
+		maxAngle float64
+		want     float64
+	}{
+		{name: "level", tilt: 0, maxAngle: 10, want: 0},
+		{name: "sloping down", tilt: 3, maxAngle: 10, want: -3},
+		{name: "sloping up", tilt: -3, maxAngle: 10, want: 3},
+		{name: "steep slope", tilt: 12.5, maxAngle: 20, want: -12.5},
+		{name: "beyond the largest angle", tilt: 8, maxAngle: 5, want: -5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := EstimateSkew(tiltedLines(test.tilt), test.maxAngle); math.Abs(got-test.want) > 0.3 {
+				t.Errorf("EstimateSkew() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEstimateSkewWithoutInk(t *testing.T) {
+	if got := EstimateSkew(NewBitmap(20, 20), 10); got != 0 {
+		t.Errorf("EstimateSkew() of a blank bitmap = %v, want 0", got)
+	}
+	if got := EstimateSkew(tiltedLines(3), 0); got != 0 {
+		t.Errorf("EstimateSkew() with no angle allowed = %v, want 0", got)
+	}
+}
+
+func TestTextIsVertical(t *testing.T) {
+	upright := glyphRowsBitmap()
+	if TextIsVertical(upright) {
+		t.Error("TextIsVertical() of horizontal lines = true, want false")
+	}
+	if !TextIsVertical(upright.TurnClockwise()) {
+		t.Error("TextIsVertical() of lines turned sideways = false, want true")
+	}
+	if TextIsVertical(NewBitmap(10, 10)) {
+		t.Error("TextIsVertical() of a blank bitmap = true, want false")
+	}
+}
+
+func TestBackgroundColor(t *testing.T) {
+	// A light surface with dark writing in the middle and a dark corner
+	img := grayImage(20, 20, func(x, y int) uint8 {
+		if x >= 5 && x < 15 && y >= 5 && y < 15 || x < 3 && y < 3 {
+			return 30
+		}
+		return 210
+	})
+	if got := BackgroundColor(img); got != (color.Gray{Y: 210}) {
+		t.Errorf("BackgroundColor() = %v, want %v", got, color.Gray{Y: 210})
+	}
+	if got := BackgroundColor(image.NewGray(image.Rect(0, 0, 0, 0))); got != (color.Gray{Y: 255}) {
+		t.Errorf("BackgroundColor() of an empty image = %v, want white", got)
+	}
+}
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 	"math"
 	"sync"
 )
@@ -81,11 +83,12 @@ func (p *UpsideDownProcessor) Process(img image.Image) image.Image {
 	return result
 }
 
-// RotateProcessor rotates an image by a given angle in degrees
+// RotateProcessor rotates an image clockwise by a given angle in degrees
 // Demonstrates arithmetic operations, error handling
 type RotateProcessor struct {
 	ImageProcessor
-	angle float64 // in degrees
+	angle      float64     // in degrees
+	background color.Color // fills the corners the rotated image does not cover, transparent if nil
 }
 
 func NewRotateProcessor(angle float64, concurrency int, useParallel bool) *RotateProcessor {
@@ -95,29 +98,55 @@ func NewRotateProcessor(angle float64, concurrency int, useParallel bool) *Rotat
 	}
 }
 
+// NewRotateProcessorWithBackground creates a rotate processor filling the uncovered corners
+// with a background color, so that they are not taken for ink when the image is binarized
+func NewRotateProcessorWithBackground(angle float64, background color.Color, concurrency int, useParallel bool) *RotateProcessor {
+	processor := NewRotateProcessor(angle, concurrency, useParallel)
+	processor.background = background
+	return processor
+}
+
 func (p *RotateProcessor) Process(img image.Image) image.Image {
 	bounds := img.Bounds()
-	width, height := bounds.Max.X, bounds.Max.Y
+	width, height := bounds.Dx(), bounds.Dy()
 	
 	// Convert angle to radians
 	angleRad := p.angle * math.Pi / 180.0
+	sinA, cosA := math.Sin(angleRad), math.Cos(angleRad)
 	
 	// Calculate the dimensions of the rotated image
-	// Using absolute values to handle negative angles
-	sinA, cosA := math.Abs(math.Sin(angleRad)), math.Abs(math.Cos(angleRad))
-	newWidth := int(float64(width)*cosA + float64(height)*sinA)
-	newHeight := int(float64(width)*sinA + float64(height)*cosA)
+	// Using absolute values to handle negative angles; rounding keeps quarter turns exact
+	absSin, absCos := math.Abs(sinA), math.Abs(cosA)
+	newWidth := int(math.Round(float64(width)*absCos + float64(height)*absSin))
+	newHeight := int(math.Round(float64(width)*absSin + float64(height)*absCos))
 	
 	// Create a new image with the calculated dimensions
 	result := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	if p.background != nil {
+		draw.Draw(result, result.Bounds(), image.NewUniform(p.background), image.Point{}, draw.Src)
+	}
 	
 	// Calculate the center of the original and new images
 	origCenterX, origCenterY := float64(width)/2, float64(height)/2
 	newCenterX, newCenterY := float64(newWidth)/2, float64(newHeight)/2
 	
+	// rotatePixel copies the original pixel that lands on a pixel of the rotated image.
+	// Pixel centers are translated to the origin, rotated back, then translated back.
+	rotatePixel := func(newX, newY int) {
+		dx, dy := float64(newX)+0.5-newCenterX, float64(newY)+0.5-newCenterY
+		origX := math.Floor(cosA*dx + sinA*dy + origCenterX)
+		origY := math.Floor(-sinA*dx + cosA*dy + origCenterY)
+		
+		// Check if the pixel is within the bounds of the original image
+		if origX >= 0 && origX < float64(width) && origY >= 0 && origY < float64(height) {
+			// Get the color at the original position
+			result.Set(newX, newY, img.At(bounds.Min.X+int(origX), bounds.Min.Y+int(origY)))
+		}
+	}
+	
 	// Rotation using a channel for work distribution
 	var wg sync.WaitGroup
-	pixelChan := make(chan [2]int, p.concurrency*10) // Buffered channel for work distribution
+	rowChan := make(chan int, p.concurrency*10) // Buffered channel for work distribution
 	
 	// Start worker goroutines
 	if p.useParallel {
@@ -126,18 +155,9 @@ func (p *RotateProcessor) Process(img image.Image) image.Image {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				for pixel := range pixelChan {
-					newX, newY := pixel[0], pixel[1]
-					
-					// Calculate the corresponding position in the original image
-					// Translate to origin, rotate, then translate back
-					origX := cosA*(float64(newX)-newCenterX) + sinA*(float64(newY)-newCenterY) + origCenterX
-					origY := -sinA*(float64(newX)-newCenterX) + cosA*(float64(newY)-newCenterY) + origCenterY
-					
-					// Check if the pixel is within the bounds of the original image
-					if origX >= 0 && origX < float64(width) && origY >= 0 && origY < float64(height) {
-						// Get the color at the original position
-						result.Set(newX, newY, img.At(int(origX), int(origY)))
+				for newY := range rowChan {
+					for newX := 0; newX < newWidth; newX++ {
+						rotatePixel(newX, newY)
 					}
 				}
 			}()
@@ -145,25 +165,15 @@ func (p *RotateProcessor) Process(img image.Image) image.Image {
 		
 		// Send work to the channel
 		for newY := 0; newY < newHeight; newY++ {
-			for newX := 0; newX < newWidth; newX++ {
-				pixelChan <- [2]int{newX, newY}
-			}
+			rowChan <- newY
 		}
-		close(pixelChan)
+		close(rowChan)
 		wg.Wait()
 	} else {
 		// Single-threaded processing
 		for newY := 0; newY < newHeight; newY++ {
 			for newX := 0; newX < newWidth; newX++ {
-				// Calculate the corresponding position in the original image
-				origX := cosA*(float64(newX)-newCenterX) + sinA*(float64(newY)-newCenterY) + origCenterX
-				origY := -sinA*(float64(newX)-newCenterX) + cosA*(float64(newY)-newCenterY) + origCenterY
-				
-				// Check if the pixel is within the bounds of the original image
-				if origX >= 0 && origX < float64(width) && origY >= 0 && origY < float64(height) {
-					// Get the color at the original position
-					result.Set(newX, newY, img.At(int(origX), int(origY)))
-				}
+				rotatePixel(newX, newY)
 			}
 		}
 	}
@@ -742,6 +752,339 @@ func (p *SobelEdgeDetector) Process(img image.Image) image.Image {
 	return result
 }
 
+// StructuringElementShape is the shape of the neighbourhood a morphological operation takes
+// the minimum or maximum over
+type StructuringElementShape string
+
+// Shapes of structuring elements
+const (
+	// RectElement covers the whole rectangle
+	RectElement StructuringElementShape = "rect"
+	// CrossElement covers the middle row and column of the rectangle
+	CrossElement StructuringElementShape = "cross"
+	// EllipseElement covers the ellipse inscribed in the rectangle
+	EllipseElement StructuringElementShape = "ellipse"
+)
+
+// ParseStructuringElementShape validates the name of a structuring element shape; an empty name means rect
+func ParseStructuringElementShape(name string) (StructuringElementShape, error) {
+	if name == "" {
+		return RectElement, nil
+	}
+	switch shape := StructuringElementShape(name); shape {
+	case RectElement, CrossElement, EllipseElement:
+		return shape, nil
+	}
+	return "", fmt.Errorf("unknown structuring element %q: must be rect, cross or ellipse", name)
+}
+
+// StructuringElement is the neighbourhood of a pixel a morphological operation looks at,
+// given as offsets from the pixel at its centre
+type StructuringElement struct {
+	Shape   StructuringElementShape
+	Width   int
+	Height  int
+	offsets []image.Point
+}
+
+// NewStructuringElement creates a structuring element of a shape within a rectangle.
+// Even sides are made odd so that the element has a centre, and sides below 1 become 1.
+func NewStructuringElement(shape StructuringElementShape, width, height int) StructuringElement {
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	if width%2 == 0 {
+		width++
+	}
+	if height%2 == 0 {
+		height++
+	}
+	
+	element := StructuringElement{Shape: shape, Width: width, Height: height}
+	radiusX, radiusY := width/2, height/2
+	for dy := -radiusY; dy <= radiusY; dy++ {
+		for dx := -radiusX; dx <= radiusX; dx++ {
+			switch shape {
+			case CrossElement:
+				if dx != 0 && dy != 0 {
+					continue
+				}
+			case EllipseElement:
+				// Normalize by the half sides plus half a pixel so the ends of the axes are covered
+				nx := float64(dx) / (float64(radiusX) + 0.5)
+				ny := float64(dy) / (float64(radiusY) + 0.5)
+				if nx*nx+ny*ny > 1 {
+					continue
+				}
+			}
+			element.offsets = append(element.offsets, image.Point{X: dx, Y: dy})
+		}
+	}
+	return element
+}
+
+// MorphologyOperation is a morphological operation of the image processing pipeline
+type MorphologyOperation string
+
+// Morphological operations
+const (
+	NoMorphology      MorphologyOperation = "none"
+	ErodeOperation    MorphologyOperation = "erode"
+	DilateOperation   MorphologyOperation = "dilate"
+	OpenOperation     MorphologyOperation = "open"
+	CloseOperation    MorphologyOperation = "close"
+	TopHatOperation   MorphologyOperation = "tophat"
+	BlackHatOperation MorphologyOperation = "blackhat"
+)
+
+// ParseMorphologyOperation validates the name of a morphological operation; an empty name means none
+func ParseMorphologyOperation(name string) (MorphologyOperation, error) {
+	if name == "" {
+		return NoMorphology, nil
+	}
+	switch operation := MorphologyOperation(name); operation {
+	case NoMorphology, ErodeOperation, DilateOperation, OpenOperation, CloseOperation, TopHatOperation, BlackHatOperation:
+		return operation, nil
+	}
+	return "", fmt.Errorf("unknown morphological operation %q: must be none, erode, dilate, open, close, tophat or blackhat", name)
+}
+
+// NewMorphologyProcessor creates the processor of a morphological operation, nil for none
+func NewMorphologyProcessor(operation MorphologyOperation, element StructuringElement, concurrency int, useParallel bool) ImageProcessingAlgorithm {
+	switch operation {
+	case ErodeOperation:
+		return NewErodeProcessor(element, concurrency, useParallel)
+	case DilateOperation:
+		return NewDilateProcessor(element, concurrency, useParallel)
+	case OpenOperation:
+		return NewOpenProcessor(element, concurrency, useParallel)
+	case CloseOperation:
+		return NewCloseProcessor(element, concurrency, useParallel)
+	case TopHatOperation:
+		return NewTopHatProcessor(element, concurrency, useParallel)
+	case BlackHatOperation:
+		return NewBlackHatProcessor(element, concurrency, useParallel)
+	}
+	return nil
+}
+
+// morphology holds the structuring element shared by the morphological processors
+// The operations work on the luminance: erosion takes the darkest pixel under the element
+// and dilation the lightest. On dark ink over a light surface, as the binarization processors
+// produce, erosion therefore thickens the strokes and dilation thins them; opening fills
+// pinholes and breaks in the strokes, and closing removes specks of ink smaller than the element.
+type morphology struct {
+	ImageProcessor
+	element StructuringElement
+}
+
+// ErodeProcessor replaces each pixel with the darkest pixel under the structuring element
+type ErodeProcessor struct {
+	morphology
+}
+
+func NewErodeProcessor(element StructuringElement, concurrency int, useParallel bool) *ErodeProcessor {
+	return &ErodeProcessor{
+		morphology: morphology{
+			ImageProcessor: NewImageProcessor("Erode", concurrency, useParallel),
+			element:        element,
+		},
+	}
+}
+
+func (p *ErodeProcessor) Process(img image.Image) image.Image {
+	return p.erode(p.grayLevels(img), img.Bounds())
+}
+
+// DilateProcessor replaces each pixel with the lightest pixel under the structuring element
+type DilateProcessor struct {
+	morphology
+}
+
+func NewDilateProcessor(element StructuringElement, concurrency int, useParallel bool) *DilateProcessor {
+	return &DilateProcessor{
+		morphology: morphology{
+			ImageProcessor: NewImageProcessor("Dilate", concurrency, useParallel),
+			element:        element,
+		},
+	}
+}
+
+func (p *DilateProcessor) Process(img image.Image) image.Image {
+	return p.dilate(p.grayLevels(img), img.Bounds())
+}
+
+// OpenProcessor erodes and then dilates an image, removing light details smaller than the
+// structuring element while keeping the size of larger ones
+type OpenProcessor struct {
+	morphology
+}
+
+func NewOpenProcessor(element StructuringElement, concurrency int, useParallel bool) *OpenProcessor {
+	return &OpenProcessor{
+		morphology: morphology{
+			ImageProcessor: NewImageProcessor("Open", concurrency, useParallel),
+			element:        element,
+		},
+	}
+}
+
+func (p *OpenProcessor) Process(img image.Image) image.Image {
+	return p.open(p.grayLevels(img), img.Bounds())
+}
+
+// CloseProcessor dilates and then erodes an image, removing dark details smaller than the
+// structuring element while keeping the size of larger ones
+type CloseProcessor struct {
+	morphology
+}
+
+func NewCloseProcessor(element StructuringElement, concurrency int, useParallel bool) *CloseProcessor {
+	return &CloseProcessor{
+		morphology: morphology{
+			ImageProcessor: NewImageProcessor("Close", concurrency, useParallel),
+			element:        element,
+		},
+	}
+}
+
+func (p *CloseProcessor) Process(img image.Image) image.Image {
+	return p.close(p.grayLevels(img), img.Bounds())
+}
+
+// TopHatProcessor subtracts the opening of an image from the image, leaving the light details
+// smaller than the structuring element on black
+type TopHatProcessor struct {
+	morphology
+}
+
+func NewTopHatProcessor(element StructuringElement, concurrency int, useParallel bool) *TopHatProcessor {
+	return &TopHatProcessor{
+		morphology: morphology{
+			ImageProcessor: NewImageProcessor("Top Hat", concurrency, useParallel),
+			element:        element,
+		},
+	}
+}
+
+func (p *TopHatProcessor) Process(img image.Image) image.Image {
+	gray := p.grayLevels(img)
+	opened := p.open(gray, gray.Rect)
+	return difference(gray, opened, img.Bounds())
+}
+
+// BlackHatProcessor subtracts an image from its closing, leaving the dark details smaller than
+// the structuring element light on black. With an element wider than the strokes it extracts
+// dark ink from a surface of uneven shade.
+type BlackHatProcessor struct {
+	morphology
+}
+
+func NewBlackHatProcessor(element StructuringElement, concurrency int, useParallel bool) *BlackHatProcessor {
+	return &BlackHatProcessor{
+		morphology: morphology{
+			ImageProcessor: NewImageProcessor("Black Hat", concurrency, useParallel),
+			element:        element,
+		},
+	}
+}
+
+func (p *BlackHatProcessor) Process(img image.Image) image.Image {
+	gray := p.grayLevels(img)
+	closed := p.close(gray, gray.Rect)
+	return difference(closed, gray, img.Bounds())
+}
+
+// open erodes and then dilates a gray image at the origin, returning the result within bounds
+func (p *morphology) open(gray *image.Gray, bounds image.Rectangle) *image.Gray {
+	return p.dilate(p.erode(gray, gray.Rect), bounds)
+}
+
+// close dilates and then erodes a gray image at the origin, returning the result within bounds
+func (p *morphology) close(gray *image.Gray, bounds image.Rectangle) *image.Gray {
+	return p.erode(p.dilate(gray, gray.Rect), bounds)
+}
+
+// erode takes the darkest pixel under the structuring element
+func (p *morphology) erode(gray *image.Gray, bounds image.Rectangle) *image.Gray {
+	return p.rank(gray, bounds, func(a, b uint8) bool { return a < b })
+}
+
+// dilate takes the lightest pixel under the structuring element
+func (p *morphology) dilate(gray *image.Gray, bounds image.Rectangle) *image.Gray {
+	return p.rank(gray, bounds, func(a, b uint8) bool { return a > b })
+}
+
+// rank replaces each pixel of a gray image at the origin with the pixel under the structuring
+// element that comes first by better, returning the result within bounds. Pixels of the
+// element beyond the borders of the image are ignored.
+func (p *morphology) rank(gray *image.Gray, bounds image.Rectangle, better func(a, b uint8) bool) *image.Gray {
+	width, height := gray.Rect.Dx(), gray.Rect.Dy()
+	result := image.NewGray(bounds)
+	
+	processRow := func(y int) {
+		for x := 0; x < width; x++ {
+			value := gray.Pix[y*gray.Stride+x]
+			for _, offset := range p.element.offsets {
+				nx, ny := x+offset.X, y+offset.Y
+				
+				// Check if the neighbor is within bounds
+				if nx >= 0 && nx < width && ny >= 0 && ny < height {
+					if neighbor := gray.Pix[ny*gray.Stride+nx]; better(neighbor, value) {
+						value = neighbor
+					}
+				}
+			}
+			result.Pix[y*result.Stride+x] = value
+		}
+	}
+	
+	if p.useParallel {
+		// Start worker goroutines taking rows from a channel
+		var wg sync.WaitGroup
+		rowChan := make(chan int, height)
+		for i := 0; i < p.concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for y := range rowChan {
+					processRow(y)
+				}
+			}()
+		}
+		
+		// Send work items
+		for y := 0; y < height; y++ {
+			rowChan <- y
+		}
+		
+		close(rowChan)
+		wg.Wait()
+	} else {
+		// Single-threaded processing
+		for y := 0; y < height; y++ {
+			processRow(y)
+		}
+	}
+	
+	return result
+}
+
+// difference subtracts one gray image at the origin from another, clamping at black, and
+// returns the result within bounds
+func difference(a, b *image.Gray, bounds image.Rectangle) *image.Gray {
+	result := image.NewGray(bounds)
+	for i := range result.Pix {
+		if a.Pix[i] > b.Pix[i] {
+			result.Pix[i] = a.Pix[i] - b.Pix[i]
+		}
+	}
+	return result
+}
+
 // Create a pipeline of image processing algorithms
 // Demonstrates the use of channels, interfaces, and goroutines
 func ProcessImagePipeline(img image.Image, algorithms []ImageProcessingAlgorithm) image.Image {
@@ -0,0 +1,255 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// grayRows builds a gray image from rows drawn with # for black ink and . for a white surface
+func grayRows(rows ...string) *image.Gray {
+	return grayImage(len(rows[0]), len(rows), func(x, y int) uint8 {
+		if rows[y][x] == '#' {
+			return 0
+		}
+		return 255
+	})
+}
+
+// rowsOf draws an image back as rows of # for black and . for white, ? for any other shade
+func rowsOf(img image.Image) []string {
+	bounds := img.Bounds()
+	rows := make([]string, 0, bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		var row strings.Builder
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			switch color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y {
+			case 0:
+				row.WriteByte('#')
+			case 255:
+				row.WriteByte('.')
+			default:
+				row.WriteByte('?')
+			}
+		}
+		rows = append(rows, row.String())
+	}
+	return rows
+}
+
+// inkBlot is a block of ink with a pinhole at 4, 3 and a speck of ink at 9, 7
+var inkBlot = []string{
+	"............",
+	".#######....",
+	".#######....",
+	".###.###....",
+	".#######....",
+	".#######....",
+	"............",
+	".........#..",
+	"............",
+	"............",
+}
+
+func TestStructuringElements(t *testing.T) {
+	tests := []struct {
+		name          string
+		shape         StructuringElementShape
+		width, height int
+		want          []string
+	}{
+		{name: "rect", shape: RectElement, width: 3, height: 3, want: []string{"XXX", "XXX", "XXX"}},
+		{name: "cross", shape: CrossElement, width: 3, height: 3, want: []string{".X.", "XXX", ".X."}},
+		{name: "ellipse", shape: EllipseElement, width: 5, height: 5, want: []string{".XXX.", "XXXXX", "XXXXX", "XXXXX", ".XXX."}},
+		{name: "wide ellipse", shape: EllipseElement, width: 7, height: 3, want: []string{".XXXXX.", "XXXXXXX", ".XXXXX."}},
+		{name: "even sides are made odd", shape: RectElement, width: 2, height: 0, want: []string{"XXX"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			element := NewStructuringElement(test.shape, test.width, test.height)
+			var got []string
+			for dy := -element.Height / 2; dy <= element.Height/2; dy++ {
+				row := []byte(strings.Repeat(".", element.Width))
+				for _, offset := range element.offsets {
+					if offset.Y == dy {
+						row[offset.X+element.Width/2] = 'X'
+					}
+				}
+				got = append(got, string(row))
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("NewStructuringElement() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMorphologyProcessors(t *testing.T) {
+	rect := NewStructuringElement(RectElement, 3, 3)
+	cross := NewStructuringElement(CrossElement, 3, 3)
+	tests := []struct {
+		name      string
+		operation MorphologyOperation
+		element   StructuringElement
+		want      []string
+	}{
+		{
+			// Erosion takes the darkest pixel, thickening the ink
+			name: "erode", operation: ErodeOperation, element: rect,
+			want: []string{
+				"#########...",
+				"#########...",
+				"#########...",
+				"#########...",
+				"#########...",
+				"#########...",
+				"###########.",
+				"........###.",
+				"........###.",
+				"............",
+			},
+		},
+		{
+			name: "erode with a cross", operation: ErodeOperation, element: cross,
+			want: []string{
+				".#######....",
+				"#########...",
+				"#########...",
+				"#########...",
+				"#########...",
+				"#########...",
+				".#######.#..",
+				"........###.",
+				".........#..",
+				"............",
+			},
+		},
+		{
+			// Dilation takes the lightest pixel, thinning the ink around the pinhole away
+			name: "dilate", operation: DilateOperation, element: rect,
+			want: []string{
+				"............",
+				"............",
+				"..#...#.....",
+				"..#...#.....",
+				"..#...#.....",
+				"............",
+				"............",
+				"............",
+				"............",
+				"............",
+			},
+		},
+		{
+			name: "dilate with a cross", operation: DilateOperation, element: cross,
+			want: []string{
+				"............",
+				"............",
+				"..##.##.....",
+				"..#...#.....",
+				"..##.##.....",
+				"............",
+				"............",
+				"............",
+				"............",
+				"............",
+			},
+		},
+		{
+			// Opening fills the pinhole and keeps the speck
+			name: "open", operation: OpenOperation, element: rect,
+			want: []string{
+				"########....",
+				"########....",
+				"########....",
+				"########....",
+				"########....",
+				"########....",
+				"............",
+				".........#..",
+				"............",
+				"............",
+			},
+		},
+		{
+			// Closing removes the speck
+			name: "close", operation: CloseOperation, element: rect,
+			want: []string{
+				"............",
+				".###.###....",
+				".###.###....",
+				".###.###....",
+				".###.###....",
+				".###.###....",
+				"............",
+				"............",
+				"............",
+				"............",
+			},
+		},
+		{
+			// The top hat leaves the light surface the opening covered, the pinhole among it
+			name: "tophat", operation: TopHatOperation, element: rect,
+			want: []string{
+				"........####",
+				".###########",
+				".###########",
+				".###.#######",
+				".###########",
+				".###########",
+				"############",
+				"############",
+				"############",
+				"############",
+			},
+		},
+		{
+			// The black hat leaves the ink the closing removed, the speck among it
+			name: "blackhat", operation: BlackHatOperation, element: rect,
+			want: []string{
+				"############",
+				"####.#######",
+				"####.#######",
+				"############",
+				"####.#######",
+				"####.#######",
+				"############",
+				"#########.##",
+				"############",
+				"############",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			processor := NewMorphologyProcessor(test.operation, test.element, 1, false)
+			if got := rowsOf(processor.Process(grayRows(inkBlot...))); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s = %q, want %q", test.operation, got, test.want)
+			}
+
+			parallel := NewMorphologyProcessor(test.operation, test.element, 4, true)
+			if got := rowsOf(parallel.Process(grayRows(inkBlot...))); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parallel %s = %q, want %q", test.operation, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseMorphologyOperation(t *testing.T) {
+	if operation, err := ParseMorphologyOperation(""); err != nil || operation != NoMorphology {
+		t.Errorf("ParseMorphologyOperation(\"\") = %q, %v, want %q", operation, err, NoMorphology)
+	}
+	if operation, err := ParseMorphologyOperation("tophat"); err != nil || operation != TopHatOperation {
+		t.Errorf("ParseMorphologyOperation(\"tophat\") = %q, %v, want %q", operation, err, TopHatOperation)
+	}
+	if _, err := ParseMorphologyOperation("skeleton"); err == nil {
+		t.Error("ParseMorphologyOperation() of an unknown operation succeeded, want an error")
+	}
+	if processor := NewMorphologyProcessor(NoMorphology, NewStructuringElement(RectElement, 3, 3), 1, false); processor != nil {
+		t.Errorf("NewMorphologyProcessor(none) = %v, want nil", processor)
+	}
+}
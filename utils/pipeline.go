@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Operations of the steps of an image processing pipeline; the morphological operations
+// erode, dilate, open, close, tophat and blackhat are steps of their own as well
+const (
+	RotateStep        = "rotate"
+	UpsideDownStep    = "upsideDown"
+	GrayscaleStep     = "grayscale"
+	BrightnessStep    = "brightness"
+	ContrastStep      = "contrast"
+	GammaStep         = "gamma"
+	CLAHEStep         = "clahe"
+	BoxBlurStep       = "boxBlur"
+	GaussianBlurStep  = "gaussianBlur"
+	MedianStep        = "median"
+	BilateralStep     = "bilateral"
+	NonLocalMeansStep = "nlm"
+	BinarizeStep      = "binarize"
+	EdgeDetectionStep = "edgeDetection"
+)
+
+// Upper bounds of the sizes of pipeline steps, which keep a request from tying up the server
+const (
+	maxStepSize     = 99
+	maxStepWindow   = 255
+	maxStepTiles    = 64
+	maxStepSigma    = 50
+	maxStepStrength = 255
+	// maxStepSigmaSpace bounds the bilateral filter, whose window grows with the square of its spread
+	maxStepSigmaSpace = MaxBilateralSigmaSpace
+)
+
+// stepParameters lists the parameters each operation takes
+var stepParameters = map[string][]string{
+	RotateStep:                {"angle"},
+	UpsideDownStep:            {},
+	GrayscaleStep:             {},
+	BrightnessStep:            {"adjust"},
+	ContrastStep:              {"factor", "clip"},
+	GammaStep:                 {"gamma"},
+	CLAHEStep:                 {"clipLimit", "tiles"},
+	BoxBlurStep:               {"size"},
+	GaussianBlurStep:          {"sigma", "size"},
+	MedianStep:                {"size"},
+	BilateralStep:             {"sigmaSpace", "sigmaColor"},
+	NonLocalMeansStep:         {"strength"},
+	BinarizeStep:              {"method", "window", "k"},
+	EdgeDetectionStep:         {"threshold"},
+	string(ErodeOperation):    {"element", "size"},
+	string(DilateOperation):   {"element", "size"},
+	string(OpenOperation):     {"element", "size"},
+	string(CloseOperation):    {"element", "size"},
+	string(TopHatOperation):   {"element", "size"},
+	string(BlackHatOperation): {"element", "size"},
+}
+
+// PipelineStep is one step of an image processing pipeline: an operation and its parameters.
+// Parameters an operation does not take must be left out, and those left at zero take the
+// configured defaults.
+type PipelineStep struct {
+	// Op is the operation of the step
+	Op string `yaml:"op" json:"op"`
+	// Angle is the clockwise turn in degrees of rotate
+	Angle float64 `yaml:"angle,omitempty" json:"angle,omitempty"`
+	// Size is the side in pixels of the kernel of the blurs, the median filter and the morphological operations
+	Size int `yaml:"size,omitempty" json:"size,omitempty"`
+	// Sigma is the standard deviation of the Gaussian blur
+	Sigma float64 `yaml:"sigma,omitempty" json:"sigma,omitempty"`
+	// Threshold is the gradient magnitude above which edge detection marks an edge
+	Threshold int `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+	// Adjust is the share of the full range brightness adds, from -1 to 1
+	Adjust float64 `yaml:"adjust,omitempty" json:"adjust,omitempty"`
+	// Factor is the contrast multiplier and Clip the share of the darkest and lightest pixels contrast clips
+	Factor float64 `yaml:"factor,omitempty" json:"factor,omitempty"`
+	Clip   float64 `yaml:"clip,omitempty" json:"clip,omitempty"`
+	// Gamma is the exponent of gamma
+	Gamma float64 `yaml:"gamma,omitempty" json:"gamma,omitempty"`
+	// ClipLimit caps the tile histograms and Tiles is the number of tiles across and down of clahe
+	ClipLimit float64 `yaml:"clipLimit,omitempty" json:"clipLimit,omitempty"`
+	Tiles     int     `yaml:"tiles,omitempty" json:"tiles,omitempty"`
+	// Method is the thresholding of binarize: otsu, sauvola or niblack
+	Method string `yaml:"method,omitempty" json:"method,omitempty"`
+	// Window is the side of the window and K the weight of the local thresholds of binarize
+	Window int     `yaml:"window,omitempty" json:"window,omitempty"`
+	K      float64 `yaml:"k,omitempty" json:"k,omitempty"`
+	// Element is the structuring element of the morphological operations: rect, cross or ellipse
+	Element string `yaml:"element,omitempty" json:"element,omitempty"`
+	// SigmaSpace and SigmaColor are the spreads of the distance and shade weights of bilateral
+	SigmaSpace float64 `yaml:"sigmaSpace,omitempty" json:"sigmaSpace,omitempty"`
+	SigmaColor float64 `yaml:"sigmaColor,omitempty" json:"sigmaColor,omitempty"`
+	// Strength is the filtering strength of nlm in gray levels
+	Strength float64 `yaml:"strength,omitempty" json:"strength,omitempty"`
+}
+
+// UnmarshalYAML decodes a step, rejecting parameters it does not know so that a misspelt
+// parameter in a preset is reported rather than silently left at its default
+func (s *PipelineStep) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var fields map[string]interface{}
+	if err := unmarshal(&fields); err != nil {
+		return err
+	}
+	for name := range fields {
+		if name != "op" && !knownParameter(name) {
+			return fmt.Errorf("unknown pipeline step parameter %q", name)
+		}
+	}
+
+	type plain PipelineStep
+	return unmarshal((*plain)(s))
+}
+
+// knownParameter reports whether any operation takes a parameter
+func knownParameter(name string) bool {
+	for _, parameters := range stepParameters {
+		for _, parameter := range parameters {
+			if parameter == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PipelineError reports the step of a pipeline that is not valid
+type PipelineError struct {
+	// Step is the position of the step, counted from 1
+	Step int
+	Op   string
+	Err  error
+}
+
+func (e *PipelineError) Error() string {
+	if e.Op == "" {
+		return fmt.Sprintf("step %d: %v", e.Step, e.Err)
+	}
+	return fmt.Sprintf("step %d (%s): %v", e.Step, e.Op, e.Err)
+}
+
+func (e *PipelineError) Unwrap() error {
+	return e.Err
+}
+
+// ValidatePipeline checks that the steps of a pipeline name known operations with parameters
+// they take, within range. The first invalid step is reported as a *PipelineError.
+func ValidatePipeline(steps []PipelineStep) error {
+	for i, step := range steps {
+		if err := step.validate(); err != nil {
+			return &PipelineError{Step: i + 1, Op: step.Op, Err: err}
+		}
+	}
+	return nil
+}
+
+// validate checks the operation of a step and its parameters
+func (s PipelineStep) validate() error {
+	if s.Op == "" {
+		return fmt.Errorf("missing operation")
+	}
+	takes, ok := stepParameters[s.Op]
+	if !ok {
+		return fmt.Errorf("unknown operation %q: must be one of %s", s.Op, strings.Join(StepOperations(), ", "))
+	}
+	for _, parameter := range s.parameters() {
+		if !containsString(takes, parameter) {
+			return fmt.Errorf("%s does not take the parameter %s", s.Op, parameter)
+		}
+	}
+
+	switch {
+	case s.Size < 0 || s.Size > maxStepSize:
+		return fmt.Errorf("size must be between 0 and %d", maxStepSize)
+	case s.Sigma < 0 || s.Sigma > maxStepSigma:
+		return fmt.Errorf("sigma must be between 0 and %d", maxStepSigma)
+	case s.Threshold < 0 || s.Threshold > 255:
+		return fmt.Errorf("threshold must be between 0 and 255")
+	case s.Adjust < -1 || s.Adjust > 1:
+		return fmt.Errorf("adjust must be between -1 and 1")
+	case s.Factor < 0:
+		return fmt.Errorf("factor must not be negative")
+	case s.Clip < 0 || s.Clip >= 0.5:
+		return fmt.Errorf("clip must be at least 0 and below 0.5")
+	case s.Gamma < 0:
+		return fmt.Errorf("gamma must not be negative")
+	case s.ClipLimit < 0:
+		return fmt.Errorf("clipLimit must not be negative")
+	case s.Tiles < 0 || s.Tiles > maxStepTiles:
+		return fmt.Errorf("tiles must be between 0 and %d", maxStepTiles)
+	case s.Window < 0 || s.Window > maxStepWindow:
+		return fmt.Errorf("window must be between 0 and %d", maxStepWindow)
+	case s.SigmaSpace < 0 || s.SigmaSpace > maxStepSigmaSpace:
+		return fmt.Errorf("sigmaSpace must be between 0 and %d", maxStepSigmaSpace)
+	case s.SigmaColor < 0:
+		return fmt.Errorf("sigmaColor must not be negative")
+	case s.Strength < 0 || s.Strength > maxStepStrength:
+		return fmt.Errorf("strength must be between 0 and %d", maxStepStrength)
+	}
+	if s.Method != "" {
+		if _, err := ParseBinarizationMethod(s.Method); err != nil {
+			return err
+		}
+	}
+	if s.Element != "" {
+		if _, err := ParseStructuringElementShape(s.Element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parameters returns the names of the parameters set on a step
+func (s PipelineStep) parameters() []string {
+	set := map[string]bool{
+		"angle":      s.Angle != 0,
+		"size":       s.Size != 0,
+		"sigma":      s.Sigma != 0,
+		"threshold":  s.Threshold != 0,
+		"adjust":     s.Adjust != 0,
+		"factor":     s.Factor != 0,
+		"clip":       s.Clip != 0,
+		"gamma":      s.Gamma != 0,
+		"clipLimit":  s.ClipLimit != 0,
+		"tiles":      s.Tiles != 0,
+		"method":     s.Method != "",
+		"window":     s.Window != 0,
+		"k":          s.K != 0,
+		"element":    s.Element != "",
+		"sigmaSpace": s.SigmaSpace != 0,
+		"sigmaColor": s.SigmaColor != 0,
+		"strength":   s.Strength != 0,
+	}
+	var names []string
+	for name, isSet := range set {
+		if isSet {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StepOperations returns the operations pipeline steps can name, sorted
+func StepOperations() []string {
+	operations := make([]string, 0, len(stepParameters))
+	for operation := range stepParameters {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+// containsString reports whether a list holds a string
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidatePipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []PipelineStep
+		wantErr string
+		// wantStep is the step the error reports, counted from 1
+		wantStep int
+	}{
+		{
+			name:  "empty pipeline",
+			steps: nil,
+		},
+		{
+			name: "valid steps",
+			steps: []PipelineStep{
+				{Op: GrayscaleStep},
+				{Op: ContrastStep, Factor: 1.5, Clip: 0.01},
+				{Op: CLAHEStep, ClipLimit: 2, Tiles: 8},
+				{Op: NonLocalMeansStep, Strength: 10},
+				{Op: BinarizeStep, Method: "sauvola", Window: 25, K: 0.3},
+				{Op: string(OpenOperation), Element: "ellipse", Size: 3},
+				{Op: EdgeDetectionStep, Threshold: 128},
+			},
+		},
+		{
+			name:     "missing operation",
+			steps:    []PipelineStep{{Op: GrayscaleStep}, {Size: 3}},
+			wantErr:  "step 2: missing operation",
+			wantStep: 2,
+		},
+		{
+			name:     "unknown operation",
+			steps:    []PipelineStep{{Op: "sharpen"}},
+			wantErr:  `step 1 (sharpen): unknown operation "sharpen": must be one of ` + strings.Join(StepOperations(), ", "),
+			wantStep: 1,
+		},
+		{
+			name:     "parameter the operation does not take",
+			steps:    []PipelineStep{{Op: GrayscaleStep, Size: 3}},
+			wantErr:  "step 1 (grayscale): grayscale does not take the parameter size",
+			wantStep: 1,
+		},
+		{
+			name:     "size out of range",
+			steps:    []PipelineStep{{Op: MedianStep, Size: 100}},
+			wantErr:  "step 1 (median): size must be between 0 and 99",
+			wantStep: 1,
+		},
+		{
+			name:     "negative sigma",
+			steps:    []PipelineStep{{Op: GaussianBlurStep, Sigma: -1}},
+			wantErr:  "step 1 (gaussianBlur): sigma must be between 0 and 50",
+			wantStep: 1,
+		},
+		{
+			name:     "threshold out of range",
+			steps:    []PipelineStep{{Op: EdgeDetectionStep, Threshold: 256}},
+			wantErr:  "step 1 (edgeDetection): threshold must be between 0 and 255",
+			wantStep: 1,
+		},
+		{
+			name:     "adjust out of range",
+			steps:    []PipelineStep{{Op: BrightnessStep, Adjust: 1.5}},
+			wantErr:  "step 1 (brightness): adjust must be between -1 and 1",
+			wantStep: 1,
+		},
+		{
+			name:     "clip of half the pixels",
+			steps:    []PipelineStep{{Op: ContrastStep, Clip: 0.5}},
+			wantErr:  "step 1 (contrast): clip must be at least 0 and below 0.5",
+			wantStep: 1,
+		},
+		{
+			name:     "negative gamma",
+			steps:    []PipelineStep{{Op: GammaStep, Gamma: -2}},
+			wantErr:  "step 1 (gamma): gamma must not be negative",
+			wantStep: 1,
+		},
+		{
+			name:     "too many tiles",
+			steps:    []PipelineStep{{Op: CLAHEStep, Tiles: 65}},
+			wantErr:  "step 1 (clahe): tiles must be between 0 and 64",
+			wantStep: 1,
+		},
+		{
+			name:     "bilateral spread out of range",
+			steps:    []PipelineStep{{Op: BilateralStep, SigmaSpace: 11}},
+			wantErr:  "step 1 (bilateral): sigmaSpace must be between 0 and 10",
+			wantStep: 1,
+		},
+		{
+			name:     "window out of range",
+			steps:    []PipelineStep{{Op: BinarizeStep, Window: 256}},
+			wantErr:  "step 1 (binarize): window must be between 0 and 255",
+			wantStep: 1,
+		},
+		{
+			name:     "strength out of range",
+			steps:    []PipelineStep{{Op: NonLocalMeansStep, Strength: 300}},
+			wantErr:  "step 1 (nlm): strength must be between 0 and 255",
+			wantStep: 1,
+		},
+		{
+			name:     "unknown binarization method",
+			steps:    []PipelineStep{{Op: BinarizeStep, Method: "wolf"}},
+			wantErr:  `step 1 (binarize): unknown binarization method "wolf": must be none, otsu, sauvola or niblack`,
+			wantStep: 1,
+		},
+		{
+			name:     "unknown structuring element",
+			steps:    []PipelineStep{{Op: string(DilateOperation), Element: "disk"}},
+			wantErr:  `step 1 (dilate): unknown structuring element "disk": must be rect, cross or ellipse`,
+			wantStep: 1,
+		},
+		{
+			name:     "first invalid step is reported",
+			steps:    []PipelineStep{{Op: GrayscaleStep}, {Op: MedianStep, Size: -1}, {Op: "sharpen"}},
+			wantErr:  "step 2 (median): size must be between 0 and 99",
+			wantStep: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidatePipeline(test.steps)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidatePipeline() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("ValidatePipeline() error = nil, want %q", test.wantErr)
+			}
+			if err.Error() != test.wantErr {
+				t.Errorf("ValidatePipeline() error = %q, want %q", err.Error(), test.wantErr)
+			}
+			var pipelineErr *PipelineError
+			if !errors.As(err, &pipelineErr) {
+				t.Fatalf("ValidatePipeline() error = %T, want *PipelineError", err)
+			}
+			if pipelineErr.Step != test.wantStep {
+				t.Errorf("PipelineError.Step = %d, want %d", pipelineErr.Step, test.wantStep)
+			}
+		})
+	}
+}
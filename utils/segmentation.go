@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+// ReadingDirection is the direction in which the lines of a text are read
+type ReadingDirection string
+
+// Reading directions of manuscript layouts
+const (
+	// LeftToRight lines are read from left to right, as in classical Latin and Greek
+	LeftToRight ReadingDirection = "ltr"
+	// RightToLeft lines are read from right to left, as in early Greek and some runic inscriptions
+	RightToLeft ReadingDirection = "rtl"
+	// Boustrophedon lines alternate, the first read from left to right and the next from right to left
+	Boustrophedon ReadingDirection = "boustrophedon"
+)
+
+// ParseReadingDirection validates the name of a reading direction
+func ParseReadingDirection(name string) (ReadingDirection, error) {
+	switch direction := ReadingDirection(name); direction {
+	case LeftToRight, RightToLeft, Boustrophedon:
+		return direction, nil
+	}
+	return "", fmt.Errorf("unknown reading direction %q: must be ltr, rtl or boustrophedon", name)
+}
+
+// smallBandRatio is the height, relative to the median line, below which a band of ink
+// rows is taken for accents or other marks belonging to the neighbouring line
+const smallBandRatio = 0.4
+
+// separatorRatio is the size, relative to the median glyph height, below which a glyph
+// is taken for an interpunct or a speck rather than a letter
+const separatorRatio = 0.3
+
+// wordGapRatio is the gap between glyphs, relative to the median glyph height, that separates words
+const wordGapRatio = 0.45
+
+// GlyphBox is the bounding box of a glyph, or of a separator between words
+type GlyphBox struct {
+	Box image.Rectangle
+	// Separator marks an interpunct or a speck too small to be a letter
+	Separator bool
+	// SpaceBefore marks the first glyph of a word other than the first of its line
+	SpaceBefore bool
+}
+
+// TextLine is a line of text with its glyphs in reading order
+type TextLine struct {
+	// Box bounds the glyphs of the line
+	Box image.Rectangle
+	// Direction is LeftToRight or RightToLeft; the lines of a boustrophedon text alternate
+	Direction ReadingDirection
+	Glyphs    []GlyphBox
+}
+
+// Segmenter splits a binarized manuscript image into lines and glyphs.
+// Lines are found from the horizontal projection profile, the count of ink pixels of
+// each row, and glyphs from the connected components of ink within each line.
+type Segmenter struct {
+	direction ReadingDirection
+}
+
+// NewSegmenter creates a segmenter for texts read in the given direction
+func NewSegmenter(direction ReadingDirection) *Segmenter {
+	return &Segmenter{direction: direction}
+}
+
+// Segment returns the lines of a bitmap from top to bottom, each with its glyphs in reading order
+func (s *Segmenter) Segment(b *Bitmap) []TextLine {
+	var lines []TextLine
+	for _, band := range findLines(b) {
+		boxes := findGlyphs(b, band)
+		if len(boxes) == 0 {
+			continue
+		}
+
+		direction := s.lineDirection(len(lines))
+		if direction == RightToLeft {
+			for i, j := 0, len(boxes)-1; i < j; i, j = i+1, j-1 {
+				boxes[i], boxes[j] = boxes[j], boxes[i]
+			}
+		}
+
+		line := TextLine{Box: boxes[0], Direction: direction}
+		for _, box := range boxes {
+			line.Box = line.Box.Union(box)
+		}
+		line.Glyphs = markGlyphs(boxes, direction)
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// lineDirection returns the direction of the line with the given index
+func (s *Segmenter) lineDirection(index int) ReadingDirection {
+	switch s.direction {
+	case RightToLeft:
+		return RightToLeft
+	case Boustrophedon:
+		if index%2 == 1 {
+			return RightToLeft
+		}
+	}
+	return LeftToRight
+}
+
+// findLines splits a bitmap into bands of rows holding ink, from top to bottom.
+// Bands too thin to be lines, such as rows of accents, join the nearest line.
+func findLines(b *Bitmap) []image.Rectangle {
+	var bands []image.Rectangle
+	start := -1
+	for y := 0; y <= b.Height; y++ {
+		ink := false
+		for x := 0; y < b.Height && x < b.Width && !ink; x++ {
+			ink = b.At(x, y)
+		}
+		switch {
+		case ink && start < 0:
+			start = y
+		case !ink && start >= 0:
+			bands = append(bands, image.Rect(0, start, b.Width, y))
+			start = -1
+		}
+	}
+	if len(bands) < 2 {
+		return bands
+	}
+
+	heights := make([]int, len(bands))
+	for i, band := range bands {
+		heights[i] = band.Dy()
+	}
+	minHeight := float64(median(heights)) * smallBandRatio
+
+	for merged := true; merged && len(bands) > 1; {
+		merged = false
+		for i, band := range bands {
+			if float64(band.Dy()) >= minHeight {
+				continue
+			}
+			// Join the thin band to the closer of its neighbours
+			target := i - 1
+			if i == 0 || i < len(bands)-1 && bands[i+1].Min.Y-band.Max.Y < band.Min.Y-bands[i-1].Max.Y {
+				target = i + 1
+			}
+			bands[target] = bands[target].Union(band)
+			bands = append(bands[:i], bands[i+1:]...)
+			merged = true
+			break
+		}
+	}
+	return bands
+}
+
+// findGlyphs returns the glyph boxes of a line from left to right. Connected components
+// overlapping horizontally, such as the bars of Ξ or a letter and its accent, form one glyph.
+func findGlyphs(b *Bitmap, line image.Rectangle) []image.Rectangle {
+	components := connectedComponents(b, line)
+	sort.Slice(components, func(i, j int) bool { return components[i].Min.X < components[j].Min.X })
+
+	var boxes []image.Rectangle
+	for _, component := range components {
+		if n := len(boxes); n > 0 && overlapsHorizontally(boxes[n-1], component) {
+			boxes[n-1] = boxes[n-1].Union(component)
+			continue
+		}
+		boxes = append(boxes, component)
+	}
+	return boxes
+}
+
+// markGlyphs tells separators from letters and finds the word breaks of a line whose
+// glyph boxes are in reading order
+func markGlyphs(boxes []image.Rectangle, direction ReadingDirection) []GlyphBox {
+	heights := make([]int, len(boxes))
+	for i, box := range boxes {
+		heights[i] = box.Dy()
+	}
+	glyphHeight := float64(median(heights))
+
+	glyphs := make([]GlyphBox, 0, len(boxes))
+	for i, box := range boxes {
+		gap := 0
+		if i > 0 {
+			if direction == RightToLeft {
+				gap = boxes[i-1].Min.X - box.Max.X
+			} else {
+				gap = box.Min.X - boxes[i-1].Max.X
+			}
+		}
+		glyphs = append(glyphs, GlyphBox{
+			Box:         box,
+			Separator:   float64(box.Dx()) < glyphHeight*separatorRatio && float64(box.Dy()) < glyphHeight*separatorRatio,
+			SpaceBefore: float64(gap) > glyphHeight*wordGapRatio,
+		})
+	}
+	return glyphs
+}
+
+// connectedComponents returns the bounding boxes of the 8-connected regions of ink within a line
+func connectedComponents(b *Bitmap, line image.Rectangle) []image.Rectangle {
+	visited := make([]bool, line.Dx()*line.Dy())
+	index := func(x, y int) int { return (y-line.Min.Y)*line.Dx() + x - line.Min.X }
+
+	var components []image.Rectangle
+	var stack []image.Point
+	for y := line.Min.Y; y < line.Max.Y; y++ {
+		for x := line.Min.X; x < line.Max.X; x++ {
+			if !b.At(x, y) || visited[index(x, y)] {
+				continue
+			}
+
+			box := image.Rect(x, y, x+1, y+1)
+			visited[index(x, y)] = true
+			stack = append(stack[:0], image.Pt(x, y))
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				box = box.Union(image.Rect(p.X, p.Y, p.X+1, p.Y+1))
+
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						n := image.Pt(p.X+dx, p.Y+dy)
+						if !n.In(line) || !b.At(n.X, n.Y) || visited[index(n.X, n.Y)] {
+							continue
+						}
+						visited[index(n.X, n.Y)] = true
+						stack = append(stack, n)
+					}
+				}
+			}
+			components = append(components, box)
+		}
+	}
+	return components
+}
+
+// overlapsHorizontally reports whether two boxes share at least half the width of the narrower one
+func overlapsHorizontally(a, b image.Rectangle) bool {
+	overlap := minInt(a.Max.X, b.Max.X) - maxInt(a.Min.X, b.Min.X)
+	return overlap*2 >= minInt(a.Dx(), b.Dx())
+}
+
+// median returns the median of values, which must not be empty
+func median(values []int) int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	return sorted[len(sorted)/2]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
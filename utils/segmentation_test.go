@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+// bitmapRows builds a bitmap from rows drawn with X for ink and . for background
+func bitmapRows(rows ...string) *Bitmap {
+	b := NewBitmap(len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x, c := range row {
+			b.Set(x, y, c == 'X')
+		}
+	}
+	return b
+}
+
+// twoLines has a line of three glyphs, the third a word of its own, and a line of two
+// glyphs with an interpunct between them
+var twoLines = bitmapRows(
+	"XXX.XXX....XXX",
+	"X.X..X.....X..",
+	"XXX..X.....XXX",
+	"X.X..X.......X",
+	"X.X..X.....XXX",
+	"..............",
+	"..............",
+	"XXX.....XXX...",
+	"X....X..X.....",
+	"XXX.....XXX...",
+	"X.......X.....",
+	"XXX.....XXX...",
+)
+
+func TestSegmentBoxes(t *testing.T) {
+	lines := NewSegmenter(LeftToRight).Segment(twoLines)
+	if len(lines) != 2 {
+		t.Fatalf("Segment() lines = %d, want 2", len(lines))
+	}
+
+	want := []TextLine{
+		{
+			Box:       image.Rect(0, 0, 14, 5),
+			Direction: LeftToRight,
+			Glyphs: []GlyphBox{
+				{Box: image.Rect(0, 0, 3, 5)},
+				{Box: image.Rect(4, 0, 7, 5)},
+				{Box: image.Rect(11, 0, 14, 5), SpaceBefore: true},
+			},
+		},
+		{
+			Box:       image.Rect(0, 7, 11, 12),
+			Direction: LeftToRight,
+			Glyphs: []GlyphBox{
+				{Box: image.Rect(0, 7, 3, 12)},
+				{Box: image.Rect(5, 8, 6, 9), Separator: true},
+				{Box: image.Rect(8, 7, 11, 12)},
+			},
+		},
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("Segment() = %+v, want %+v", lines, want)
+	}
+}
+
+func TestSegmentReadingOrder(t *testing.T) {
+	tests := []struct {
+		direction ReadingDirection
+		// want holds the left edges of the glyphs of each line in reading order
+		want           [][]int
+		wantDirections []ReadingDirection
+		// wantSpace is the reading position of the glyph starting the second word of the first line
+		wantSpace int
+	}{
+		{
+			direction:      LeftToRight,
+			want:           [][]int{{0, 4, 11}, {0, 5, 8}},
+			wantDirections: []ReadingDirection{LeftToRight, LeftToRight},
+			wantSpace:      2,
+		},
+		{
+			direction:      RightToLeft,
+			want:           [][]int{{11, 4, 0}, {8, 5, 0}},
+			wantDirections: []ReadingDirection{RightToLeft, RightToLeft},
+			wantSpace:      1,
+		},
+		{
+			direction:      Boustrophedon,
+			want:           [][]int{{0, 4, 11}, {8, 5, 0}},
+			wantDirections: []ReadingDirection{LeftToRight, RightToLeft},
+			wantSpace:      2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.direction), func(t *testing.T) {
+			lines := NewSegmenter(test.direction).Segment(twoLines)
+			var got [][]int
+			var directions []ReadingDirection
+			for _, line := range lines {
+				var edges []int
+				for _, glyph := range line.Glyphs {
+					edges = append(edges, glyph.Box.Min.X)
+				}
+				got = append(got, edges)
+				directions = append(directions, line.Direction)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Segment() glyph order = %v, want %v", got, test.want)
+			}
+			if !reflect.DeepEqual(directions, test.wantDirections) {
+				t.Errorf("Segment() directions = %v, want %v", directions, test.wantDirections)
+			}
+			for i, glyph := range lines[0].Glyphs {
+				if glyph.SpaceBefore != (i == test.wantSpace) {
+					t.Errorf("glyph %d of the first line SpaceBefore = %v, want %v", i, glyph.SpaceBefore, i == test.wantSpace)
+				}
+			}
+		})
+	}
+}
+
+func TestSegmentJoinsAccents(t *testing.T) {
+	// The acute above the first glyph is a band too thin to be a line of its own
+	b := bitmapRows(
+		"..X....",
+		".......",
+		"XXX.XXX",
+		"X.X.X.X",
+		"XXX.XXX",
+		"X.X.X..",
+		"X.X.X..",
+	)
+
+	lines := NewSegmenter(LeftToRight).Segment(b)
+	if len(lines) != 1 {
+		t.Fatalf("Segment() lines = %d, want 1", len(lines))
+	}
+	if want := image.Rect(0, 0, 7, 7); lines[0].Box != want {
+		t.Errorf("line box = %v, want %v", lines[0].Box, want)
+	}
+	want := []GlyphBox{{Box: image.Rect(0, 0, 3, 7)}, {Box: image.Rect(4, 2, 7, 7)}}
+	if !reflect.DeepEqual(lines[0].Glyphs, want) {
+		t.Errorf("glyphs = %+v, want %+v", lines[0].Glyphs, want)
+	}
+}
+
+func TestSegmentBlankBitmap(t *testing.T) {
+	if lines := NewSegmenter(LeftToRight).Segment(NewBitmap(10, 10)); len(lines) != 0 {
+		t.Errorf("Segment() of a blank bitmap = %+v, want no lines", lines)
+	}
+}
+
+func TestParseReadingDirection(t *testing.T) {
+	for _, name := range []string{"ltr", "rtl", "boustrophedon"} {
+		if direction, err := ParseReadingDirection(name); err != nil || string(direction) != name {
+			t.Errorf("ParseReadingDirection(%q) = %q, %v, want %q", name, direction, err, name)
+		}
+	}
+	if _, err := ParseReadingDirection("ttb"); err == nil {
+		t.Error("ParseReadingDirection() of an unknown direction succeeded, want an error")
+	}
+}
@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"image"
+	"math"
+)
+
+// Stroke directions of the histogram in WritingFeatures
+const (
+	StrokeHorizontal = iota
+	// StrokeRising runs from the bottom left to the top right, like /
+	StrokeRising
+	StrokeVertical
+	// StrokeFalling runs from the top left to the bottom right, like \
+	StrokeFalling
+)
+
+// minComponentArea is the number of ink pixels below which a component is taken for a speck
+const minComponentArea = 4
+
+// wedgeHeadRatio is how many times wider than its tail the head of a wedge must be
+const wedgeHeadRatio = 2.0
+
+// wedgeElongation is how many times longer than wide a wedge must be
+const wedgeElongation = 1.5
+
+// WritingFeatures are visual statistics of the writing of a manuscript image,
+// measured over the glyphs the Segmenter found
+type WritingFeatures struct {
+	// Glyphs is the number of glyphs measured, separators left out
+	Glyphs int
+	// Strokes is the share of the edges of the ink running in each stroke direction,
+	// indexed by StrokeHorizontal, StrokeRising, StrokeVertical and StrokeFalling
+	Strokes [4]float64
+	// Wedges is the share of components shaped like a wedge, with a broad head tapering into a tail
+	Wedges float64
+	// ComponentsPerGlyph is the mean number of separate pieces of ink making up a glyph
+	ComponentsPerGlyph float64
+	// Density is the mean share of ink in the boxes of the glyphs
+	Density float64
+	// HeightVariation is the coefficient of variation of the glyph heights
+	HeightVariation float64
+	// LineRegularity is the share of glyphs whose height is within a quarter of the median of their line
+	LineRegularity float64
+}
+
+// component is a connected region of ink with its pixels
+type component struct {
+	box    image.Rectangle
+	pixels []image.Point
+}
+
+// MeasureWriting computes the visual statistics of the glyphs of a segmented bitmap
+func MeasureWriting(b *Bitmap, lines []TextLine) WritingFeatures {
+	var features WritingFeatures
+	var heights []float64
+	var density float64
+	var components, wedges, regular int
+
+	for _, line := range lines {
+		lineHeights := make([]int, 0, len(line.Glyphs))
+		for _, glyph := range line.Glyphs {
+			if !glyph.Separator {
+				lineHeights = append(lineHeights, glyph.Box.Dy())
+			}
+		}
+		if len(lineHeights) == 0 {
+			continue
+		}
+		lineHeight := float64(median(lineHeights))
+
+		for _, glyph := range line.Glyphs {
+			if glyph.Separator {
+				continue
+			}
+			features.Glyphs++
+			height := float64(glyph.Box.Dy())
+			heights = append(heights, height)
+			if math.Abs(height-lineHeight) <= lineHeight/4 {
+				regular++
+			}
+
+			ink := 0
+			for y := glyph.Box.Min.Y; y < glyph.Box.Max.Y; y++ {
+				for x := glyph.Box.Min.X; x < glyph.Box.Max.X; x++ {
+					if b.At(x, y) {
+						ink++
+					}
+				}
+			}
+			density += float64(ink) / float64(glyph.Box.Dx()*glyph.Box.Dy())
+			addStrokes(b, glyph.Box, &features.Strokes)
+
+			for _, c := range glyphComponents(b, glyph.Box) {
+				if len(c.pixels) < minComponentArea {
+					continue
+				}
+				components++
+				if isWedge(c.pixels) {
+					wedges++
+				}
+			}
+		}
+	}
+	if features.Glyphs == 0 {
+		return features
+	}
+
+	glyphs := float64(features.Glyphs)
+	features.Density = density / glyphs
+	features.ComponentsPerGlyph = float64(components) / glyphs
+	features.LineRegularity = float64(regular) / glyphs
+	if components > 0 {
+		features.Wedges = float64(wedges) / float64(components)
+	}
+
+	var mean, variance float64
+	for _, height := range heights {
+		mean += height
+	}
+	mean /= glyphs
+	for _, height := range heights {
+		variance += (height - mean) * (height - mean)
+	}
+	features.HeightVariation = math.Sqrt(variance/glyphs) / mean
+
+	var edges float64
+	for _, share := range features.Strokes {
+		edges += share
+	}
+	if edges > 0 {
+		for i := range features.Strokes {
+			features.Strokes[i] /= edges
+		}
+	}
+	return features
+}
+
+// addStrokes adds the edges of the ink within a box to the stroke direction histogram.
+// The Sobel gradient at an edge points across the stroke, so the stroke runs at right
+// angles to it; each edge is weighted by the strength of its gradient.
+func addStrokes(b *Bitmap, box image.Rectangle, strokes *[4]float64) {
+	value := func(x, y int) float64 {
+		if b.At(x, y) {
+			return 1
+		}
+		return 0
+	}
+
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			gx := value(x+1, y-1) + 2*value(x+1, y) + value(x+1, y+1) -
+				value(x-1, y-1) - 2*value(x-1, y) - value(x-1, y+1)
+			gy := value(x-1, y+1) + 2*value(x, y+1) + value(x+1, y+1) -
+				value(x-1, y-1) - 2*value(x, y-1) - value(x+1, y-1)
+			magnitude := math.Hypot(gx, gy)
+			if magnitude == 0 {
+				continue
+			}
+
+			// Image rows grow downwards, so the angle is negated to measure it counterclockwise
+			angle := math.Atan2(-gy, gx)*180/math.Pi + 90
+			angle = math.Mod(angle+180, 180)
+			bin := int(math.Floor(angle/45+0.5)) % 4
+			strokes[bin] += magnitude
+		}
+	}
+}
+
+// glyphComponents returns the 8-connected regions of ink within a glyph box with their pixels
+func glyphComponents(b *Bitmap, box image.Rectangle) []component {
+	visited := make([]bool, box.Dx()*box.Dy())
+	index := func(x, y int) int { return (y-box.Min.Y)*box.Dx() + x - box.Min.X }
+
+	var components []component
+	var stack []image.Point
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			if !b.At(x, y) || visited[index(x, y)] {
+				continue
+			}
+
+			c := component{box: image.Rect(x, y, x+1, y+1)}
+			visited[index(x, y)] = true
+			stack = append(stack[:0], image.Pt(x, y))
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				c.pixels = append(c.pixels, p)
+				c.box = c.box.Union(image.Rect(p.X, p.Y, p.X+1, p.Y+1))
+
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						n := image.Pt(p.X+dx, p.Y+dy)
+						if !n.In(box) || !b.At(n.X, n.Y) || visited[index(n.X, n.Y)] {
+							continue
+						}
+						visited[index(n.X, n.Y)] = true
+						stack = append(stack, n)
+					}
+				}
+			}
+			components = append(components, c)
+		}
+	}
+	return components
+}
+
+// isWedge reports whether a component is shaped like the impression of a stylus: elongated
+// along its principal axis, with one end much wider than the other
+func isWedge(pixels []image.Point) bool {
+	n := float64(len(pixels))
+	var meanX, meanY float64
+	for _, p := range pixels {
+		meanX += float64(p.X)
+		meanY += float64(p.Y)
+	}
+	meanX /= n
+	meanY /= n
+
+	var xx, yy, xy float64
+	for _, p := range pixels {
+		dx, dy := float64(p.X)-meanX, float64(p.Y)-meanY
+		xx += dx * dx
+		yy += dy * dy
+		xy += dx * dy
+	}
+	xx, yy, xy = xx/n, yy/n, xy/n
+
+	// Eigenvalues of the covariance give the spread along the principal axes
+	spread := math.Sqrt((xx-yy)*(xx-yy)/4 + xy*xy)
+	major, minor := (xx+yy)/2+spread, (xx+yy)/2-spread
+	if minor <= 0 || math.Sqrt(major/minor) < wedgeElongation {
+		return false
+	}
+	axis := math.Atan2(2*xy, xx-yy) / 2
+	cos, sin := math.Cos(axis), math.Sin(axis)
+
+	// Measure the width across the axis in the first and last third of the length
+	along := make([]float64, len(pixels))
+	low, high := math.Inf(1), math.Inf(-1)
+	for i, p := range pixels {
+		along[i] = (float64(p.X)-meanX)*cos + (float64(p.Y)-meanY)*sin
+		low = math.Min(low, along[i])
+		high = math.Max(high, along[i])
+	}
+	third := (high - low) / 3
+	if third <= 0 {
+		return false
+	}
+
+	headMin, headMax := math.Inf(1), math.Inf(-1)
+	tailMin, tailMax := math.Inf(1), math.Inf(-1)
+	for i, p := range pixels {
+		across := -(float64(p.X)-meanX)*sin + (float64(p.Y)-meanY)*cos
+		switch {
+		case along[i] <= low+third:
+			headMin, headMax = math.Min(headMin, across), math.Max(headMax, across)
+		case along[i] >= high-third:
+			tailMin, tailMax = math.Min(tailMin, across), math.Max(tailMax, across)
+		}
+	}
+	head, tail := headMax-headMin+1, tailMax-tailMin+1
+	if head < tail {
+		head, tail = tail, head
+	}
+	return head >= tail*wedgeHeadRatio
+}